@@ -0,0 +1,136 @@
+package concreteimplemetations
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"sync"
+	"time"
+
+	"online_bookStore/models"
+)
+
+// Tokens the fake gateway treats specially; any other non-empty token is
+// a card that always goes through
+const (
+	FakeTokenDeclined          = "tok_declined"
+	FakeTokenInsufficientFunds = "tok_insufficient_funds"
+	FakeTokenCaptureFails      = "tok_capture_fails"
+)
+
+type fakeAuthorization struct {
+	amount   float64
+	token    string
+	captured bool
+	voided   bool
+}
+
+type fakeCapture struct {
+	amount   float64
+	refunded float64
+}
+
+// FakePaymentGateway is an in-process gateway for local runs and tests. Its
+// answers depend only on the token and the order of calls. It forgets
+// everything on restart; its references are numbered within a run and
+// carry the run's start time (fake_cap_<run>_1, ...), so a reference from
+// an earlier run is unknown instead of matching a new one.
+type FakePaymentGateway struct {
+	mu             sync.Mutex
+	run            string
+	seq            int
+	authorizations map[string]*fakeAuthorization
+	captures       map[string]*fakeCapture
+}
+
+// Constructor
+func NewFakePaymentGateway() *FakePaymentGateway {
+	return &FakePaymentGateway{
+		run:            strconv.FormatInt(time.Now().UnixNano(), 36),
+		authorizations: make(map[string]*fakeAuthorization),
+		captures:       make(map[string]*fakeCapture),
+	}
+}
+
+func (g *FakePaymentGateway) Name() string {
+	return "fake"
+}
+
+func (g *FakePaymentGateway) nextID(prefix string) string {
+	g.seq++
+	return fmt.Sprintf("fake_%s_%s_%d", prefix, g.run, g.seq)
+}
+
+func (g *FakePaymentGateway) Authorize(ctx context.Context, charge models.GatewayCharge) (string, error) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	switch {
+	case charge.Amount <= 0:
+		return "", &models.GatewayError{Code: "invalid_amount", Message: "amount must be positive"}
+	case charge.Token == "":
+		return "", &models.GatewayError{Code: "invalid_token", Message: "no card token given"}
+	case charge.Token == FakeTokenDeclined:
+		return "", &models.GatewayError{Code: "card_declined", Message: "the card was declined"}
+	case charge.Token == FakeTokenInsufficientFunds:
+		return "", &models.GatewayError{Code: "insufficient_funds", Message: "the card has insufficient funds"}
+	}
+
+	id := g.nextID("auth")
+	g.authorizations[id] = &fakeAuthorization{amount: charge.Amount, token: charge.Token}
+	return id, nil
+}
+
+func (g *FakePaymentGateway) Capture(ctx context.Context, authorizationID string, amount float64) (string, error) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	auth, ok := g.authorizations[authorizationID]
+	switch {
+	case !ok:
+		return "", &models.GatewayError{Code: "unknown_authorization", Message: "authorization not found"}
+	case auth.captured || auth.voided:
+		return "", &models.GatewayError{Code: "authorization_closed", Message: "authorization was already captured or voided"}
+	case amount <= 0 || amount > auth.amount:
+		return "", &models.GatewayError{Code: "invalid_amount", Message: "amount exceeds the authorization"}
+	case auth.token == FakeTokenCaptureFails:
+		return "", &models.GatewayError{Code: "processing_error", Message: "the capture could not be processed"}
+	}
+
+	auth.captured = true
+	id := g.nextID("cap")
+	g.captures[id] = &fakeCapture{amount: amount}
+	return id, nil
+}
+
+func (g *FakePaymentGateway) Void(ctx context.Context, authorizationID string) error {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	auth, ok := g.authorizations[authorizationID]
+	switch {
+	case !ok:
+		return &models.GatewayError{Code: "unknown_authorization", Message: "authorization not found"}
+	case auth.captured:
+		return &models.GatewayError{Code: "authorization_closed", Message: "captured authorizations must be refunded"}
+	}
+
+	auth.voided = true
+	return nil
+}
+
+func (g *FakePaymentGateway) Refund(ctx context.Context, captureID string, amount float64) (string, error) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	capture, ok := g.captures[captureID]
+	switch {
+	case !ok:
+		return "", &models.GatewayError{Code: "unknown_capture", Message: "capture not found"}
+	case amount <= 0 || roundCents(capture.refunded+amount) > capture.amount:
+		return "", &models.GatewayError{Code: "invalid_amount", Message: "amount exceeds what is left to refund"}
+	}
+
+	capture.refunded = roundCents(capture.refunded + amount)
+	return g.nextID("ref"), nil
+}
@@ -2,6 +2,7 @@ package concreteimplemetations
 
 import (
 	"database/sql"
+	"time"
 
 	"online_bookStore/models"
 	"context"
@@ -35,12 +36,12 @@ func (s *MySQLAuthorStore) CreateAuthor(ctx context.Context, author models.Autho
 	)
 
 	if err != nil {
-		return author, err
+		return author, storeError("author", err)
 	}
 
 	id, err := result.LastInsertId()
 	if err != nil {
-		return author, err
+		return author, storeError("author", err)
 	}
 
 	author.ID = int(id)
@@ -50,9 +51,9 @@ func (s *MySQLAuthorStore) CreateAuthor(ctx context.Context, author models.Autho
 func (s *MySQLAuthorStore) GetAuthor(ctx context.Context, id int) (models.Author, error) {
 
 	query := `
-		SELECT id, first_name, last_name, bio
+		SELECT id, first_name, last_name, bio, deleted_at
 		FROM authors
-		WHERE id = ?
+		WHERE id = ? AND ` + notDeleted(ctx, "authors") + `
 	`
 
 	var author models.Author
@@ -62,10 +63,11 @@ func (s *MySQLAuthorStore) GetAuthor(ctx context.Context, id int) (models.Author
 		&author.FirstName,
 		&author.LastName,
 		&author.Bio,
+		&author.DeletedAt,
 	)
 
 	if err != nil {
-		return author, err
+		return author, storeError("author", err)
 	}
 
 	return author, nil
@@ -77,7 +79,7 @@ func (s *MySQLAuthorStore) UpdateAuthor(ctx context.Context,id int, author model
 	query := `
 		UPDATE authors
 		SET first_name = ?, last_name = ?, bio = ?
-		WHERE id = ?
+		WHERE id = ? AND deleted_at IS NULL
 	`
 
 	result, err := s.db.ExecContext(
@@ -90,12 +92,12 @@ func (s *MySQLAuthorStore) UpdateAuthor(ctx context.Context,id int, author model
 	)
 
 	if err != nil {
-		return author, err
+		return author, storeError("author", err)
 	}
 
 	rowsAffected, err := result.RowsAffected()
 	if err != nil {
-		return author, err
+		return author, storeError("author", err)
 	}
 
 	if rowsAffected == 0 {
@@ -108,43 +110,52 @@ func (s *MySQLAuthorStore) UpdateAuthor(ctx context.Context,id int, author model
 
 }
 
-func (s *MySQLAuthorStore) DeleteAuthor(ctx context.Context,id int) error {
-	query := `
-		DELETE FROM authors
-		WHERE id = ?
-	`
-
-	result, err := s.db.ExecContext(ctx,query, id)
-
-	if err != nil {
-		return err
-	}
-
-	rowAffected, err := result.RowsAffected()
+// DeleteAuthor soft-deletes the author; their books are kept
+var authorDependents = []dependentCheck{
+	{"books", "SELECT COUNT(*) FROM books WHERE author_id = ? AND deleted_at IS NULL"},
+	{"book_contributors", `SELECT COUNT(*) FROM book_contributors bc JOIN books b ON b.id = bc.book_id
+		WHERE bc.author_id = ? AND b.author_id <> bc.author_id AND b.deleted_at IS NULL`},
+	{"coupons", "SELECT COUNT(*) FROM coupons WHERE author_id = ? AND active"},
+}
 
-	if err != nil {
-		return err
+// DeleteAuthor refuses while the author has books, contributes to other
+// authors' books or has active coupons; force deletes the author's books
+// along with the author and drops them from the contributors of the rest
+func (s *MySQLAuthorStore) DeleteAuthor(ctx context.Context, id int, force bool) error {
+	cascade := func(tx *sql.Tx) error {
+		_, err := tx.ExecContext(ctx, "UPDATE books SET deleted_at = ? WHERE author_id = ? AND deleted_at IS NULL", time.Now(), id)
+		if err != nil {
+			return storeError("author", err)
+		}
+		// contributions to the author's own books stay for a restore
+		_, err = tx.ExecContext(ctx, `DELETE FROM book_contributors
+			WHERE author_id = ? AND book_id NOT IN (SELECT id FROM books WHERE author_id = ?)`, id, id)
+		return storeError("author", err)
 	}
+	err := guardedSoftDelete(ctx, s.db, "author", "authors", id, force, authorDependents, cascade)
+	return storeError("author", err)
+}
 
-	if rowAffected == 0 {
-		return sql.ErrNoRows
+func (s *MySQLAuthorStore) RestoreAuthor(ctx context.Context, id int) (models.Author, error) {
+	if err := restoreDeleted(ctx, s.db, "authors", id); err != nil {
+		return models.Author{}, storeError("author", err)
 	}
-
-	return nil
+	return s.GetAuthor(ctx, id)
 }
 
 func (s *MySQLAuthorStore) GetAllAuthors(ctx context.Context) ([]models.Author, error) {
 	query := `
-	  SELECT id, first_name, last_name, bio
+	  SELECT id, first_name, last_name, bio, deleted_at
 	  FROM authors
-	
+	  WHERE ` + notDeleted(ctx, "authors") + `
 	`
 	rows, err := s.db.QueryContext(ctx,query)
 	
 
 	if err != nil {
-		return nil, err
+		return nil, storeError("author", err)
 	}
+	defer rows.Close()
 
 	var authors []models.Author
 
@@ -156,16 +167,17 @@ func (s *MySQLAuthorStore) GetAllAuthors(ctx context.Context) ([]models.Author,
 			&author.FirstName,
 			&author.LastName,
 			&author.Bio,
+			&author.DeletedAt,
 		)
 
 		if err != nil {
-			return nil, err
+			return nil, storeError("author", err)
 		}
 
 		authors = append(authors, author)
 	}
 
-	return authors, nil
+	return authors, storeError("author", rows.Err())
 
 }
 
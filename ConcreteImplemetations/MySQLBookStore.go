@@ -3,7 +3,6 @@ package concreteimplemetations
 import (
 	"context"
 	"database/sql"
-	"encoding/json"
 	"online_bookStore/models"
 	
 )
@@ -23,40 +22,68 @@ func NewMySQLBookStore(db *sql.DB) *MySQLBookStore {
 
 func (s *MySQLBookStore) CreateBook(ctx context.Context,book models.Book) (models.Book, error) {
 
-	
-
-	genresJson, err := json.Marshal(book.Genres)
+	genres, err := models.NormalizeGenres(book.Genres)
+	if err != nil {
+		return book, err
+	}
 
+	book, err = models.NormalizeContributors(book)
 	if err != nil {
 		return book, err
 	}
 
 	query := `
-		INSERT INTO books (title, genres, published_at, price, stock, author_id)
+		INSERT INTO books (title, published_at, price, stock, weight_grams, author_id)
 		VALUES (?, ?, ?, ?, ?, ?)
 	`
 
-	result, err := s.db.ExecContext(
-		ctx,
-		query,
-		book.Title,
-		string(genresJson),
-		book.PublishedAt,
-		book.Price,
-		book.Stock,
-		book.Author.ID,
-	)
-
-	if err != nil {
-		return book, err
-	}
+	err = runInTx(ctx, s.db, func(tx *sql.Tx) error {
+		if err := checkContributorAuthors(ctx, tx, book.Contributors); err != nil {
+			return err
+		}
+
+		result, err := tx.ExecContext(
+			ctx,
+			query,
+			book.Title,
+			book.PublishedAt,
+			book.Price,
+			book.Stock,
+			book.WeightGrams,
+			book.Author.ID,
+		)
+		if err != nil {
+			return err
+		}
+
+		id, err := result.LastInsertId()
+		if err != nil {
+			return err
+		}
+		book.ID = int(id)
+
+		if err := setBookGenres(ctx, s.db, tx, book.ID, genres); err != nil {
+			return err
+		}
+
+		if err := setBookContributors(ctx, tx, book.ID, book.Contributors); err != nil {
+			return err
+		}
+
+		book.Contributors, err = bookContributors(ctx, tx, book.ID)
+		if err != nil {
+			return err
+		}
+
+		// answer with the stored spelling and order
+		book.Genres, err = bookGenres(ctx, tx, book.ID)
+		return err
+	})
 
-	id, err := result.LastInsertId()
 	if err != nil {
-		return book, err
+		return book, storeError("book", err)
 	}
 
-	book.ID = int(id)
 	return book, nil
 }
 
@@ -64,24 +91,23 @@ func (s *MySQLBookStore) GetBook(ctx context.Context, id int) (models.Book, erro
 
     query := `
 		SELECT
-			b.id, b.title, b.genres, b.published_at, b.price, b.stock,
+			b.id, b.title, b.published_at, b.price, b.stock, b.weight_grams, b.deleted_at,
 			a.id, a.first_name, a.last_name, a.bio
 		FROM books b
 		JOIN authors a ON b.author_id = a.id
-		WHERE b.id = ?
+		WHERE b.id = ? AND ` + notDeleted(ctx, "b") + `
 	`
 
 	var book models.Book
-	var genresJson string
-
 
 	err := s.db.QueryRowContext(ctx,query, id).Scan(
 		&book.ID,                 // book ID
 		&book.Title,              // book title
-		&genresJson,              // genres as JSON string
 		&book.PublishedAt,        // publication date
 		&book.Price,              // price
 		&book.Stock,              // stock
+		&book.WeightGrams,        // shipping weight
+		&book.DeletedAt,          // set when soft-deleted
 		&book.Author.ID,          // author ID
 		&book.Author.FirstName,   // author first name
 		&book.Author.LastName,    // author last name
@@ -89,14 +115,20 @@ func (s *MySQLBookStore) GetBook(ctx context.Context, id int) (models.Book, erro
 	)
     
 	if err != nil {
-		return book, err
+		return book, storeError("book", err)
 	}
 
 
-	err = json.Unmarshal([]byte(genresJson),&book.Genres)
+	book.Genres, err = bookGenres(ctx, s.db, book.ID)
 
 	if err != nil {
-		return book, err
+		return book, storeError("book", err)
+	}
+
+	book.Contributors, err = bookContributors(ctx, s.db, book.ID)
+
+	if err != nil {
+		return book, storeError("book", err)
 	}
 
 
@@ -106,7 +138,12 @@ func (s *MySQLBookStore) GetBook(ctx context.Context, id int) (models.Book, erro
 
 func (s *MySQLBookStore) UpdateBook(ctx context.Context, id int, book models.Book) (models.Book, error){
 
-	genresJSON, err := json.Marshal(book.Genres)
+	genres, err := models.NormalizeGenres(book.Genres)
+	if err != nil {
+		return book, err
+	}
+
+	book, err = models.NormalizeContributors(book)
 	if err != nil {
 		return book, err
 	}
@@ -115,35 +152,65 @@ func (s *MySQLBookStore) UpdateBook(ctx context.Context, id int, book models.Boo
 
 	query := `
 		UPDATE books
-		SET title = ?, genres = ?, published_at = ?, price = ?, stock = ?, author_id = ?
-		WHERE id = ?
+		SET title = ?, published_at = ?, price = ?, stock = ?, weight_grams = ?, author_id = ?
+		WHERE id = ? AND deleted_at IS NULL
 	`
 
 
-	result, err := s.db.ExecContext(
-		ctx,
-		query,
-		book.Title,
-		string(genresJSON),
-		book.PublishedAt,
-		book.Price,
-		book.Stock,
-		book.Author.ID,
-		id,
-	)
+	err = runInTx(ctx, s.db, func(tx *sql.Tx) error {
+		if err := checkContributorAuthors(ctx, tx, book.Contributors); err != nil {
+			return err
+		}
+
+		result, err := tx.ExecContext(
+			ctx,
+			query,
+			book.Title,
+			book.PublishedAt,
+			book.Price,
+			book.Stock,
+			book.WeightGrams,
+			book.Author.ID,
+			id,
+		)
+		if err != nil {
+			return err
+		}
+
+		rowsAffected, err := result.RowsAffected()
+		if err != nil {
+			return err
+		}
+
+		// MySQL reports 0 affected rows when only the genres or
+		// contributors change, so confirm the book exists before reporting not found
+		if rowsAffected == 0 {
+			var exists int
+			err := tx.QueryRowContext(ctx, "SELECT 1 FROM books WHERE id = ? AND deleted_at IS NULL", id).Scan(&exists)
+			if err != nil {
+				return err
+			}
+		}
+
+		if err := setBookGenres(ctx, s.db, tx, id, genres); err != nil {
+			return err
+		}
+
+		if err := setBookContributors(ctx, tx, id, book.Contributors); err != nil {
+			return err
+		}
+
+		book.Contributors, err = bookContributors(ctx, tx, id)
+		if err != nil {
+			return err
+		}
+
+		book.Genres, err = bookGenres(ctx, tx, id)
+		return err
+	})
 
 	if err != nil {
-		return book, err
-	}
-
-	rowsAffected, err := result.RowsAffected()
-    if err !=nil {
-		return book, err
-	}
-
-
-	if rowsAffected==0{
-		return book, sql.ErrNoRows
+		return book, storeError("book", err)
 	}
 
 
@@ -154,31 +221,25 @@ func (s *MySQLBookStore) UpdateBook(ctx context.Context, id int, book models.Boo
 }
 
 
-func (s *MySQLBookStore) DeleteBook(ctx context.Context,id int) error {
-	 query := `
-		DELETE FROM books
-		WHERE id = ?
-	`
-
-	result, err:= s.db.ExecContext(ctx,query,id)
-
-	if err !=nil{
-		return err
-	} 
-
-	rowAffected, err := result.RowsAffected()
-
-	if err != nil {
-		return err
-	}
+// DeleteBook soft-deletes the book: it leaves the catalogue and carts
+// but past orders keep it
+var bookDependents = []dependentCheck{
+	{"open_orders", "SELECT COUNT(DISTINCT o.id) FROM order_items oi JOIN orders o ON o.id = oi.order_id WHERE oi.book_id = ? AND " + openOrder},
+	{"carts", "SELECT COUNT(*) FROM cart_items WHERE book_id = ?"},
+}
 
+// DeleteBook refuses while the book is in orders still being fulfilled
+// or in carts
+func (s *MySQLBookStore) DeleteBook(ctx context.Context, id int, force bool) error {
+	err := guardedSoftDelete(ctx, s.db, "book", "books", id, force, bookDependents, nil)
+	return storeError("book", err)
+}
 
-	if rowAffected == 0 {
-		return sql.ErrNoRows
+func (s *MySQLBookStore) RestoreBook(ctx context.Context, id int) (models.Book, error) {
+	if err := restoreDeleted(ctx, s.db, "books", id); err != nil {
+		return models.Book{}, storeError("book", err)
 	}
-
-
-	return nil
+	return s.GetBook(ctx, id)
 }
 
 
@@ -186,44 +247,72 @@ func (s *MySQLBookStore) DeleteBook(ctx context.Context,id int) error {
 
 func (s *MySQLBookStore) SearchBooks(ctx context.Context, c models.SearchCriteria) ([]models.Book, error) {
 	query := `
-		SELECT id, title, published_at, price, stock, author_id
-		FROM books
-		WHERE 1=1
+		SELECT
+			b.id, b.title, b.published_at, b.price, b.stock, b.weight_grams, b.deleted_at,
+			a.id, a.first_name, a.last_name, a.bio
+		FROM books b
+		JOIN authors a ON b.author_id = a.id
+		WHERE ` + notDeleted(ctx, "b") + `
 	`
 	var args []interface{}
 
 	if c.Title != "" {
-		query += " AND title LIKE ?"
+		query += " AND b.title LIKE ?"
 		args = append(args, "%"+c.Title+"%")
 	}
 	if c.AuthorId != 0 {
-		query += " AND author_id = ?"
+		// the author in any role
+		query += " AND b.id IN (SELECT book_id FROM book_contributors WHERE author_id = ?)"
 		args = append(args, c.AuthorId)
 	}
 	if c.Genre != "" {
-		query += " AND genres LIKE ?"
-		args = append(args, "%"+c.Genre+"%")
+		// exact name, in any case
+		query += " AND b.id IN (SELECT bg.book_id FROM book_genres bg JOIN genres g ON g.id = bg.genre_id WHERE g.name = ?)"
+		args = append(args, c.Genre)
 	}
 	if c.MinPrice != 0 {
-		query += " AND price >= ?"
+		query += " AND b.price >= ?"
 		args = append(args, c.MinPrice)
 	}
 	if c.MaxPrice != 0 {
-		query += " AND price <= ?"
+		query += " AND b.price <= ?"
 		args = append(args, c.MaxPrice)
 	}
+	query += " ORDER BY b.id"
 
 	rows, err := s.db.QueryContext(ctx, query, args...)
 	if err != nil {
-		return nil, err
+		return nil, storeError("book", err)
 	}
 	defer rows.Close()
 
 	var books []models.Book
 	for rows.Next() {
 		var b models.Book
-		rows.Scan(&b.ID, &b.Title, &b.PublishedAt, &b.Price, &b.Stock, &b.Author.ID)
+		err := rows.Scan(
+			&b.ID, &b.Title, &b.PublishedAt, &b.Price, &b.Stock, &b.WeightGrams, &b.DeletedAt,
+			&b.Author.ID, &b.Author.FirstName, &b.Author.LastName, &b.Author.Bio,
+		)
+		if err != nil {
+			return nil, storeError("book", err)
+		}
 		books = append(books, b)
 	}
+	if err := rows.Err(); err != nil {
+		return nil, storeError("book", err)
+	}
+	rows.Close()
+
+	// filled in once the rows are read, so they can reuse the connection
+	for i := range books {
+		books[i].Genres, err = bookGenres(ctx, s.db, books[i].ID)
+		if err != nil {
+			return nil, storeError("book", err)
+		}
+		books[i].Contributors, err = bookContributors(ctx, s.db, books[i].ID)
+		if err != nil {
+			return nil, storeError("book", err)
+		}
+	}
 	return books, nil
 }
\ No newline at end of file
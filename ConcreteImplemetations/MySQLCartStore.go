@@ -0,0 +1,278 @@
+package concreteimplemetations
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"online_bookStore/models"
+)
+
+type MySQLCartStore struct {
+	db  *sql.DB
+	ttl time.Duration
+}
+
+// Constructor: carts expire ttl after their last change
+func NewMySQLCartStore(db *sql.DB, ttl time.Duration) *MySQLCartStore {
+	return &MySQLCartStore{
+		db:  db,
+		ttl: ttl,
+	}
+}
+
+func (s *MySQLCartStore) GetCart(ctx context.Context, customerID int) (models.Cart, error) {
+	q := conn(ctx, s.db)
+	cart := models.Cart{CustomerID: customerID, Items: []models.CartItem{}}
+
+	// inside a transaction (checkout) the cart row is locked
+	query := `
+		SELECT id, updated_at, expires_at
+		FROM carts
+		WHERE customer_id = ?
+	`
+	if _, inTx := ctx.Value(txKey{}).(*sql.Tx); inTx {
+		query += forUpdate(s.db)
+	}
+
+	err := q.QueryRowContext(ctx, query, customerID).Scan(&cart.ID, &cart.UpdatedAt, &cart.ExpiresAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return cart, nil
+	}
+	if err != nil {
+		return cart, storeError("cart", err)
+	}
+
+	if time.Now().After(cart.ExpiresAt) {
+		if _, err := q.ExecContext(ctx, "DELETE FROM carts WHERE id = ?", cart.ID); err != nil {
+			return cart, storeError("cart", err)
+		}
+		return models.Cart{CustomerID: customerID, Items: []models.CartItem{}}, nil
+	}
+
+	// books deleted from the catalogue drop out of the cart
+	itemsQuery := `
+		SELECT
+			ci.quantity,
+			b.id, b.title, b.published_at, b.price, b.stock, b.author_id
+		FROM cart_items ci
+		JOIN books b ON ci.book_id = b.id
+		WHERE ci.cart_id = ? AND b.deleted_at IS NULL
+		ORDER BY ci.added_at, b.id
+	`
+
+	rows, err := q.QueryContext(ctx, itemsQuery, cart.ID)
+	if err != nil {
+		return cart, storeError("cart", err)
+	}
+	defer rows.Close()
+
+	subtotal := 0.0
+	for rows.Next() {
+		var item models.CartItem
+		err := rows.Scan(
+			&item.Quantity,
+			&item.Book.ID,
+			&item.Book.Title,
+			&item.Book.PublishedAt,
+			&item.Book.Price,
+			&item.Book.Stock,
+			&item.Book.Author.ID,
+		)
+		if err != nil {
+			return cart, storeError("cart", err)
+		}
+
+		item.UnitPrice = item.Book.Price
+		item.InStock = item.Book.Stock >= item.Quantity
+		subtotal += item.UnitPrice * float64(item.Quantity)
+
+		cart.Items = append(cart.Items, item)
+	}
+	if err := rows.Err(); err != nil {
+		return cart, storeError("cart", err)
+	}
+
+	cart.Subtotal = roundCents(subtotal)
+	return cart, nil
+}
+
+// AddItem adds quantity copies of a book, on top of any already in the cart
+func (s *MySQLCartStore) AddItem(ctx context.Context, customerID int, bookID int, quantity int) (models.Cart, error) {
+	if quantity <= 0 {
+		return models.Cart{}, models.NewValidationError(models.ErrInvalidQuantity, "cart", fmt.Sprintf("book %d", bookID),
+			models.FieldError{Field: "quantity", Message: "must be positive"})
+	}
+
+	cartID, err := s.ensureCart(ctx, customerID)
+	if err != nil {
+		return models.Cart{}, storeError("cart", err)
+	}
+
+	var current int
+	err = s.db.QueryRowContext(ctx, "SELECT quantity FROM cart_items WHERE cart_id = ? AND book_id = ?", cartID, bookID).Scan(&current)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return models.Cart{}, storeError("cart", err)
+	}
+
+	cart, err := s.setQuantity(ctx, customerID, cartID, bookID, current+quantity)
+	return cart, storeError("cart", err)
+}
+
+// SetItemQuantity replaces the quantity of a book; 0 removes it
+func (s *MySQLCartStore) SetItemQuantity(ctx context.Context, customerID int, bookID int, quantity int) (models.Cart, error) {
+	if quantity < 0 {
+		return models.Cart{}, models.NewValidationError(models.ErrInvalidQuantity, "cart", fmt.Sprintf("book %d", bookID),
+			models.FieldError{Field: "quantity", Message: "must be positive"})
+	}
+	if quantity == 0 {
+		return s.RemoveItem(ctx, customerID, bookID)
+	}
+
+	cartID, err := s.ensureCart(ctx, customerID)
+	if err != nil {
+		return models.Cart{}, storeError("cart", err)
+	}
+
+	cart, err := s.setQuantity(ctx, customerID, cartID, bookID, quantity)
+	return cart, storeError("cart", err)
+}
+
+func (s *MySQLCartStore) setQuantity(ctx context.Context, customerID int, cartID int, bookID int, quantity int) (models.Cart, error) {
+	// validate against the live catalogue
+	var stock int
+	err := s.db.QueryRowContext(ctx, "SELECT stock FROM books WHERE id = ? AND deleted_at IS NULL", bookID).Scan(&stock)
+	if errors.Is(err, sql.ErrNoRows) {
+		return models.Cart{}, fmt.Errorf("%w: %d", models.ErrUnknownBook, bookID)
+	}
+	if err != nil {
+		return models.Cart{}, err
+	}
+
+	if stock < quantity {
+		return models.Cart{}, &models.InsufficientStockError{BookIDs: []int{bookID}}
+	}
+
+	query := `
+		INSERT INTO cart_items (cart_id, book_id, quantity)
+		VALUES (?, ?, ?)
+		ON DUPLICATE KEY UPDATE quantity = VALUES(quantity)
+	`
+	if isSQLite(s.db) {
+		query = `
+			INSERT INTO cart_items (cart_id, book_id, quantity)
+			VALUES (?, ?, ?)
+			ON CONFLICT (cart_id, book_id) DO UPDATE SET quantity = excluded.quantity
+		`
+	}
+
+	if _, err := s.db.ExecContext(ctx, query, cartID, bookID, quantity); err != nil {
+		return models.Cart{}, err
+	}
+
+	return s.GetCart(ctx, customerID)
+}
+
+func (s *MySQLCartStore) RemoveItem(ctx context.Context, customerID int, bookID int) (models.Cart, error) {
+	query := `
+		DELETE FROM cart_items
+		WHERE cart_id IN (SELECT id FROM carts WHERE customer_id = ?) AND book_id = ?
+	`
+
+	result, err := s.db.ExecContext(ctx, query, customerID, bookID)
+	if err != nil {
+		return models.Cart{}, storeError("cart", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return models.Cart{}, storeError("cart", err)
+	}
+
+	if rowsAffected == 0 {
+		return models.Cart{}, sql.ErrNoRows
+	}
+
+	if err := s.touch(ctx, customerID); err != nil {
+		return models.Cart{}, storeError("cart", err)
+	}
+
+	return s.GetCart(ctx, customerID)
+}
+
+func (s *MySQLCartStore) ClearCart(ctx context.Context, customerID int) error {
+	_, err := conn(ctx, s.db).ExecContext(ctx, "DELETE FROM carts WHERE customer_id = ?", customerID)
+	return storeError("cart", err)
+}
+
+func (s *MySQLCartStore) DeleteExpiredCarts(ctx context.Context, before time.Time) (int64, error) {
+	result, err := s.db.ExecContext(ctx, "DELETE FROM carts WHERE expires_at < ?", before)
+	if err != nil {
+		return 0, storeError("cart", err)
+	}
+
+	return result.RowsAffected()
+}
+
+// ensureCart returns the id of the customer's live cart, creating it (or
+// emptying an expired one) as needed, and pushes its expiry forward
+func (s *MySQLCartStore) ensureCart(ctx context.Context, customerID int) (int, error) {
+	now := time.Now()
+
+	_, err := s.db.ExecContext(ctx, "DELETE FROM carts WHERE customer_id = ? AND expires_at < ?", customerID, now)
+	if err != nil {
+		return 0, err
+	}
+
+	query := `
+		INSERT INTO carts (customer_id, updated_at, expires_at)
+		VALUES (?, ?, ?)
+		ON DUPLICATE KEY UPDATE
+			id = LAST_INSERT_ID(id),
+			updated_at = VALUES(updated_at),
+			expires_at = VALUES(expires_at)
+	`
+
+	// SQLite does not report the id of a row updated by an upsert
+	if isSQLite(s.db) {
+		query = `
+			INSERT INTO carts (customer_id, updated_at, expires_at)
+			VALUES (?, ?, ?)
+			ON CONFLICT (customer_id) DO UPDATE SET
+				updated_at = excluded.updated_at,
+				expires_at = excluded.expires_at
+			RETURNING id
+		`
+
+		var id int
+		err := s.db.QueryRowContext(ctx, query, customerID, now, now.Add(s.ttl)).Scan(&id)
+		return id, err
+	}
+
+	result, err := s.db.ExecContext(ctx, query, customerID, now, now.Add(s.ttl))
+	if err != nil {
+		return 0, err
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return 0, err
+	}
+
+	return int(id), nil
+}
+
+func (s *MySQLCartStore) touch(ctx context.Context, customerID int) error {
+	now := time.Now()
+
+	query := `
+		UPDATE carts
+		SET updated_at = ?, expires_at = ?
+		WHERE customer_id = ?
+	`
+
+	_, err := s.db.ExecContext(ctx, query, now, now.Add(s.ttl), customerID)
+	return err
+}
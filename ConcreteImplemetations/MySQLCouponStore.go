@@ -0,0 +1,229 @@
+package concreteimplemetations
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"online_bookStore/models"
+)
+
+const couponColumns = `id, code, type, value, buy_quantity, free_quantity, genre, author_id,
+	min_order_value, usage_limit, used_count, valid_from, valid_until, active, created_at`
+
+type MySQLCouponStore struct {
+	db *sql.DB
+}
+
+// Constructor
+func NewMySQLCouponStore(db *sql.DB) *MySQLCouponStore {
+	return &MySQLCouponStore{
+		db: db,
+	}
+}
+
+func scanCoupon(row rowScanner) (models.Coupon, error) {
+	var coupon models.Coupon
+	var genre sql.NullString
+	var authorID sql.NullInt64
+	var validFrom, validUntil sql.NullTime
+
+	err := row.Scan(
+		&coupon.ID,
+		&coupon.Code,
+		&coupon.Type,
+		&coupon.Value,
+		&coupon.BuyQuantity,
+		&coupon.FreeQuantity,
+		&genre,
+		&authorID,
+		&coupon.MinOrderValue,
+		&coupon.UsageLimit,
+		&coupon.UsedCount,
+		&validFrom,
+		&validUntil,
+		&coupon.Active,
+		&coupon.CreatedAt,
+	)
+	if err != nil {
+		return coupon, err
+	}
+
+	coupon.Genre = genre.String
+	coupon.AuthorID = int(authorID.Int64)
+	if validFrom.Valid {
+		coupon.ValidFrom = &validFrom.Time
+	}
+	if validUntil.Valid {
+		coupon.ValidUntil = &validUntil.Time
+	}
+	return coupon, nil
+}
+
+// couponArgs returns the writable columns in couponColumns order
+func couponArgs(coupon models.Coupon) []interface{} {
+	var genre interface{}
+	if coupon.Genre != "" {
+		genre = coupon.Genre
+	}
+
+	return []interface{}{
+		coupon.Code,
+		coupon.Type,
+		coupon.Value,
+		coupon.BuyQuantity,
+		coupon.FreeQuantity,
+		genre,
+		nullableID(coupon.AuthorID),
+		coupon.MinOrderValue,
+		coupon.UsageLimit,
+		coupon.ValidFrom,
+		coupon.ValidUntil,
+		coupon.Active,
+	}
+}
+
+func (s *MySQLCouponStore) CreateCoupon(ctx context.Context, coupon models.Coupon) (models.Coupon, error) {
+	coupon.Code = models.NormalizeCouponCode(coupon.Code)
+	if err := coupon.Validate(); err != nil {
+		return coupon, storeError("coupon", err)
+	}
+
+	query := `
+		INSERT INTO coupons (code, type, value, buy_quantity, free_quantity, genre, author_id,
+			min_order_value, usage_limit, valid_from, valid_until, active)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+	`
+
+	result, err := s.db.ExecContext(ctx, query, couponArgs(coupon)...)
+	if isDuplicateEntry(err) {
+		return coupon, models.ErrCouponCodeTaken
+	}
+	if err != nil {
+		return coupon, storeError("coupon", err)
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return coupon, storeError("coupon", err)
+	}
+
+	return s.GetCoupon(ctx, int(id))
+}
+
+func (s *MySQLCouponStore) GetCoupon(ctx context.Context, id int) (models.Coupon, error) {
+	row := s.db.QueryRowContext(ctx, "SELECT "+couponColumns+" FROM coupons WHERE id = ?", id)
+	coupon, err := scanCoupon(row)
+	return coupon, storeError("coupon", err)
+}
+
+func (s *MySQLCouponStore) GetCouponByCode(ctx context.Context, code string) (models.Coupon, error) {
+	row := s.db.QueryRowContext(ctx, "SELECT "+couponColumns+" FROM coupons WHERE code = ?", models.NormalizeCouponCode(code))
+	coupon, err := scanCoupon(row)
+	return coupon, storeError("coupon", err)
+}
+
+// UpdateCoupon replaces the definition; the usage count is kept
+func (s *MySQLCouponStore) UpdateCoupon(ctx context.Context, id int, coupon models.Coupon) (models.Coupon, error) {
+	coupon.Code = models.NormalizeCouponCode(coupon.Code)
+	if err := coupon.Validate(); err != nil {
+		return coupon, storeError("coupon", err)
+	}
+
+	query := `
+		UPDATE coupons
+		SET code = ?, type = ?, value = ?, buy_quantity = ?, free_quantity = ?, genre = ?, author_id = ?,
+			min_order_value = ?, usage_limit = ?, valid_from = ?, valid_until = ?, active = ?
+		WHERE id = ?
+	`
+
+	args := append(couponArgs(coupon), id)
+	_, err := s.db.ExecContext(ctx, query, args...)
+	if isDuplicateEntry(err) {
+		return coupon, models.ErrCouponCodeTaken
+	}
+	if err != nil {
+		return coupon, storeError("coupon", err)
+	}
+
+	return s.GetCoupon(ctx, id)
+}
+
+func (s *MySQLCouponStore) DeleteCoupon(ctx context.Context, id int) error {
+	result, err := s.db.ExecContext(ctx, "DELETE FROM coupons WHERE id = ?", id)
+	if err != nil {
+		return storeError("coupon", err)
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return storeError("coupon", err)
+	}
+	if rows == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
+
+func (s *MySQLCouponStore) GetAllCoupons(ctx context.Context) ([]models.Coupon, error) {
+	rows, err := s.db.QueryContext(ctx, "SELECT "+couponColumns+" FROM coupons ORDER BY id")
+	if err != nil {
+		return nil, storeError("coupon", err)
+	}
+	defer rows.Close()
+
+	coupons := []models.Coupon{}
+	for rows.Next() {
+		coupon, err := scanCoupon(rows)
+		if err != nil {
+			return nil, storeError("coupon", err)
+		}
+		coupons = append(coupons, coupon)
+	}
+
+	return coupons, rows.Err()
+}
+
+// redeemCoupon locks the coupon, checks it can be used for the priced
+// order and counts the use. It runs inside the order transaction and
+// returns the coupon id.
+func redeemCoupon(ctx context.Context, db *sql.DB, tx *sql.Tx, order *models.Order) (int, error) {
+	row := tx.QueryRowContext(ctx, "SELECT "+couponColumns+" FROM coupons WHERE code = ?"+forUpdate(db), models.NormalizeCouponCode(order.CouponCode))
+	coupon, err := scanCoupon(row)
+	if errors.Is(err, sql.ErrNoRows) {
+		return 0, fmt.Errorf("%w: %s", models.ErrUnknownCoupon, order.CouponCode)
+	}
+	if err != nil {
+		return 0, err
+	}
+
+	if err := coupon.CheckUsable(time.Now()); err != nil {
+		return 0, err
+	}
+
+	discount, err := coupon.Discount(order.Items)
+	if err != nil {
+		return 0, err
+	}
+
+	_, err = tx.ExecContext(ctx, "UPDATE coupons SET used_count = used_count + 1 WHERE id = ?", coupon.ID)
+	if err != nil {
+		return 0, err
+	}
+
+	order.CouponCode = coupon.Code
+	order.Discount = discount
+	return coupon.ID, nil
+}
+
+// releaseCoupon gives back the use of the coupon an order redeemed
+func releaseCoupon(ctx context.Context, tx *sql.Tx, orderID int) error {
+	_, err := tx.ExecContext(ctx, `
+		UPDATE coupons
+		SET used_count = used_count - 1
+		WHERE id = (SELECT coupon_id FROM orders WHERE id = ?) AND used_count > 0
+	`, orderID)
+	return err
+}
@@ -2,6 +2,7 @@ package concreteimplemetations
 
 import (
 	"database/sql"
+	"errors"
 
 	"online_bookStore/models"
 	"context"
@@ -19,78 +20,26 @@ func NewMySQLCustomerStore(db *sql.DB) *MySQLCustomerStore {
 	}
 }
 
+// customerColumns is read by scanCustomer; Customer.Address is the
+// default shipping address
+const customerColumns = `c.id, c.name, c.email, c.created_at, c.deleted_at,
+			COALESCE(a.id, 0), COALESCE(a.street, ''), COALESCE(a.city, ''), COALESCE(a.state, ''),
+			COALESCE(a.postal_code, ''), COALESCE(a.country, '')`
 
-func (s *MySQLCustomerStore) CreateCustomer(ctx context.Context, customer models.Customer) (models.Customer, error) {
-
-	// 1. Insert address
-	addressQuery := `
-		INSERT INTO addresses (street, city, state, postal_code, country)
-		VALUES (?, ?, ?, ?, ?)
-	`
-
-	addressResult, err := s.db.ExecContext(
-		ctx,
-		addressQuery,
-		customer.Address.Street,
-		customer.Address.City,
-		customer.Address.State,
-		customer.Address.PostalCode,
-		customer.Address.Country,
-	)
-	if err != nil {
-		return customer, err
-	}
-
-	addressID, err := addressResult.LastInsertId()
-	if err != nil {
-		return customer, err
-	}
-
-	// 2. Insert customer
-	customerQuery := `
-		INSERT INTO customers (name, email, address_id)
-		VALUES (?, ?, ?)
-	`
-
-	result, err := s.db.Exec(
-		customerQuery,
-		customer.Name,
-		customer.Email,
-		addressID,
-	)
-	if err != nil {
-		return customer, err
-	}
-
-	customerID, err := result.LastInsertId()
-	if err != nil {
-		return customer, err
-	}
-
-	customer.ID = int(customerID)
-	customer.Address.ID = int(addressID)
-
-	return customer, nil
-}
-
-func (s *MySQLCustomerStore) GetCustomer(ctx context.Context,id int) (models.Customer, error) {
-
-	query := `
-		SELECT
-			c.id, c.name, c.email, c.created_at,
-			a.id, a.street, a.city, a.state, a.postal_code, a.country
+const customerFrom = `
 		FROM customers c
-		JOIN addresses a ON c.address_id = a.id
-		WHERE c.id = ?
-	`
+		LEFT JOIN customer_addresses a ON a.customer_id = c.id AND a.is_default_shipping
+`
 
+func scanCustomer(row rowScanner) (models.Customer, error) {
 	var customer models.Customer
 
-	err := s.db.QueryRowContext(ctx,query, id).Scan(
+	err := row.Scan(
 		&customer.ID,
 		&customer.Name,
 		&customer.Email,
 		&customer.CreatedAt,
+		&customer.DeletedAt,
 		&customer.Address.ID,
 		&customer.Address.Street,
 		&customer.Address.City,
@@ -98,130 +47,447 @@ func (s *MySQLCustomerStore) GetCustomer(ctx context.Context,id int) (models.Cus
 		&customer.Address.PostalCode,
 		&customer.Address.Country,
 	)
+	return customer, err
+}
+
+// CreateCustomer stores the customer and its address, which becomes the
+// default shipping and billing address
+func (s *MySQLCustomerStore) CreateCustomer(ctx context.Context, customer models.Customer) (models.Customer, error) {
+	if err := customer.Address.Validate(); err != nil {
+		return customer, storeError("customer", models.PrefixFields(err, "address"))
+	}
+
+	err := runInTx(ctx, s.db, func(tx *sql.Tx) error {
+		customerQuery := `
+			INSERT INTO customers (name, email)
+			VALUES (?, ?)
+		`
+
+		result, err := tx.ExecContext(
+			ctx,
+			customerQuery,
+			customer.Name,
+			customer.Email,
+		)
+		if err != nil {
+			return storeError("customer", err)
+		}
+
+		customerID, err := result.LastInsertId()
+		if err != nil {
+			return storeError("customer", err)
+		}
+		customer.ID = int(customerID)
+
+		address := models.CustomerAddress{
+			Address:           customer.Address,
+			CustomerID:        customer.ID,
+			Label:             models.DefaultAddressLabel,
+			IsDefaultShipping: true,
+			IsDefaultBilling:  true,
+		}
+		addressID, err := insertCustomerAddress(ctx, tx, address)
+		if err != nil {
+			return storeError("customer", err)
+		}
+		customer.Address.ID = addressID
+
+		return nil
+	})
 	if err != nil {
-		return customer, err
+		return customer, storeError("customer", err)
 	}
 
-	return customer, nil
+	return s.GetCustomer(ctx, customer.ID)
+}
+
+func (s *MySQLCustomerStore) GetCustomer(ctx context.Context,id int) (models.Customer, error) {
+	query := `SELECT ` + customerColumns + customerFrom + `WHERE c.id = ? AND ` + notDeleted(ctx, "c")
+	customer, err := scanCustomer(conn(ctx, s.db).QueryRowContext(ctx, query, id))
+	return customer, storeError("customer", err)
 }
 
 
+// UpdateCustomer changes name and email and, when an address is sent,
+// the default shipping address. Orders keep their own address copies.
 func (s *MySQLCustomerStore) UpdateCustomer(ctx context.Context,id int, customer models.Customer) (models.Customer, error) {
+	updateAddress := customer.Address != (models.Address{ID: customer.Address.ID})
+	if updateAddress {
+		if err := customer.Address.Validate(); err != nil {
+			return customer, storeError("customer", models.PrefixFields(err, "address"))
+		}
+	}
 
-	// 1. Update address
-	addressQuery := `
-		UPDATE addresses
-		SET street = ?, city = ?, state = ?, postal_code = ?, country = ?
-		WHERE id = ?
-	`
+	err := runInTx(ctx, s.db, func(tx *sql.Tx) error {
+		if err := s.lockCustomer(ctx, tx, id); err != nil {
+			return storeError("customer", err)
+		}
 
-	_, err := s.db.ExecContext(
-		ctx,
-		addressQuery,
-		customer.Address.Street,
-		customer.Address.City,
-		customer.Address.State,
-		customer.Address.PostalCode,
-		customer.Address.Country,
-		customer.Address.ID,
-	)
+		customerQuery := `
+			UPDATE customers
+			SET name = ?, email = ?
+			WHERE id = ?
+		`
+
+		_, err := tx.ExecContext(ctx, customerQuery, customer.Name, customer.Email, id)
+		if err != nil {
+			return storeError("customer", err)
+		}
+
+		if !updateAddress {
+			return nil
+		}
+
+		addressQuery := `
+			UPDATE customer_addresses
+			SET street = ?, city = ?, state = ?, postal_code = ?, country = ?
+			WHERE customer_id = ? AND is_default_shipping
+		`
+
+		_, err = tx.ExecContext(
+			ctx,
+			addressQuery,
+			customer.Address.Street,
+			customer.Address.City,
+			customer.Address.State,
+			customer.Address.PostalCode,
+			customer.Address.Country,
+			id,
+		)
+		return storeError("customer", err)
+	})
 	if err != nil {
-		return customer, err
+		return customer, storeError("customer", err)
 	}
 
-	// 2. Update customer
-	customerQuery := `
-		UPDATE customers
-		SET name = ?, email = ?
-		WHERE id = ?
-	`
+	return s.GetCustomer(ctx, id)
+}
 
-	result, err := s.db.Exec(
-		customerQuery,
-		customer.Name,
-		customer.Email,
-		id,
-	)
-	if err != nil {
-		return customer, err
+
+// DeleteCustomer soft-deletes the customer; orders and addresses are kept
+var customerDependents = []dependentCheck{
+	{"open_orders", "SELECT COUNT(*) FROM orders o WHERE o.customer_id = ? AND " + openOrder},
+	{"open_returns", "SELECT COUNT(*) FROM order_returns WHERE customer_id = ? AND status = 'requested'"},
+	{"users", "SELECT COUNT(*) FROM users WHERE customer_id = ?"},
+}
+
+// DeleteCustomer refuses while the customer has orders being fulfilled,
+// returns awaiting review or a linked user account
+func (s *MySQLCustomerStore) DeleteCustomer(ctx context.Context, id int, force bool) error {
+	err := guardedSoftDelete(ctx, s.db, "customer", "customers", id, force, customerDependents, nil)
+	return storeError("customer", err)
+}
+
+func (s *MySQLCustomerStore) RestoreCustomer(ctx context.Context, id int) (models.Customer, error) {
+	if err := restoreDeleted(ctx, s.db, "customers", id); err != nil {
+		return models.Customer{}, storeError("customer", err)
 	}
+	return s.GetCustomer(ctx, id)
+}
+
 
-	rowsAffected, err := result.RowsAffected()
+func (s *MySQLCustomerStore) GetAllCustomers(ctx context.Context) ([]models.Customer, error) {
+	query := `SELECT ` + customerColumns + customerFrom + `WHERE ` + notDeleted(ctx, "c") + ` ORDER BY c.id`
+
+	rows, err := s.db.QueryContext(ctx, query)
 	if err != nil {
-		return customer, err
+		return nil, storeError("customer", err)
 	}
+	defer rows.Close()
 
-	if rowsAffected == 0 {
-		return customer, sql.ErrNoRows
+	var customers []models.Customer
+	for rows.Next() {
+		c, err := scanCustomer(rows)
+		if err != nil {
+			return nil, storeError("customer", err)
+		}
+		customers = append(customers, c)
 	}
-
-	customer.ID = id
-	return customer, nil
+	return customers, rows.Err()
 }
 
+const customerAddressColumns = `id, customer_id, label, street, city, state, postal_code, country,
+			is_default_shipping, is_default_billing, created_at`
+
+func scanCustomerAddress(row rowScanner) (models.CustomerAddress, error) {
+	var address models.CustomerAddress
+
+	err := row.Scan(
+		&address.ID,
+		&address.CustomerID,
+		&address.Label,
+		&address.Street,
+		&address.City,
+		&address.State,
+		&address.PostalCode,
+		&address.Country,
+		&address.IsDefaultShipping,
+		&address.IsDefaultBilling,
+		&address.CreatedAt,
+	)
+	return address, err
+}
 
-func (s *MySQLCustomerStore) DeleteCustomer(ctx context.Context,id int) error {
+// lockCustomer serializes changes to a customer's addresses so there is
+// always exactly one default of each kind; deleted customers cannot change
+func (s *MySQLCustomerStore) lockCustomer(ctx context.Context, tx *sql.Tx, customerID int) error {
+	var id int
+	return tx.QueryRowContext(ctx, "SELECT id FROM customers WHERE id = ? AND deleted_at IS NULL"+forUpdate(s.db), customerID).Scan(&id)
+}
 
+func insertCustomerAddress(ctx context.Context, tx *sql.Tx, address models.CustomerAddress) (int, error) {
 	query := `
-		DELETE FROM customers
-		WHERE id = ?
+		INSERT INTO customer_addresses (customer_id, label, street, city, state, postal_code, country,
+			is_default_shipping, is_default_billing)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)
 	`
 
-	result, err := s.db.ExecContext(ctx,query, id)
+	result, err := tx.ExecContext(
+		ctx,
+		query,
+		address.CustomerID,
+		address.Label,
+		address.Street,
+		address.City,
+		address.State,
+		address.PostalCode,
+		address.Country,
+		address.IsDefaultShipping,
+		address.IsDefaultBilling,
+	)
 	if err != nil {
-		return err
+		return 0, err
 	}
 
-	rowsAffected, err := result.RowsAffected()
-	if err != nil {
-		return err
-	}
+	id, err := result.LastInsertId()
+	return int(id), err
+}
 
-	if rowsAffected == 0 {
-		return sql.ErrNoRows
+// clearDefaults removes the default flags the address is taking over
+// from the customer's other addresses
+func clearDefaults(ctx context.Context, tx *sql.Tx, address models.CustomerAddress) error {
+	if address.IsDefaultShipping {
+		_, err := tx.ExecContext(ctx, "UPDATE customer_addresses SET is_default_shipping = FALSE WHERE customer_id = ? AND id <> ?", address.CustomerID, address.ID)
+		if err != nil {
+			return err
+		}
+	}
+	if address.IsDefaultBilling {
+		_, err := tx.ExecContext(ctx, "UPDATE customer_addresses SET is_default_billing = FALSE WHERE customer_id = ? AND id <> ?", address.CustomerID, address.ID)
+		if err != nil {
+			return err
+		}
 	}
-
 	return nil
 }
 
+func (s *MySQLCustomerStore) GetAddresses(ctx context.Context, customerID int) ([]models.CustomerAddress, error) {
+	var exists int
+	err := s.db.QueryRowContext(ctx, "SELECT 1 FROM customers c WHERE c.id = ? AND "+notDeleted(ctx, "c"), customerID).Scan(&exists)
+	if err != nil {
+		return nil, storeError("address", err)
+	}
 
-func (s *MySQLCustomerStore) GetAllCustomers(ctx context.Context) ([]models.Customer, error) {
 	query := `
-		SELECT 
-			c.id, c.name, c.email, c.created_at,
-			a.id, a.street, a.city, a.state, a.postal_code, a.country
-		FROM customers c
-		JOIN addresses a ON c.address_id = a.id
+		SELECT ` + customerAddressColumns + `
+		FROM customer_addresses
+		WHERE customer_id = ?
+		ORDER BY id
 	`
 
-	rows, err := s.db.QueryContext(ctx, query)
+	rows, err := s.db.QueryContext(ctx, query, customerID)
 	if err != nil {
-		return nil, err
+		return nil, storeError("address", err)
 	}
 	defer rows.Close()
 
-	var customers []models.Customer
+	addresses := []models.CustomerAddress{}
 	for rows.Next() {
-		var c models.Customer
-		err := rows.Scan(
-			&c.ID,
-			&c.Name,
-			&c.Email,
-			&c.CreatedAt,
-			&c.Address.ID,
-			&c.Address.Street,
-			&c.Address.City,
-			&c.Address.State,
-			&c.Address.PostalCode,
-			&c.Address.Country,
+		address, err := scanCustomerAddress(rows)
+		if err != nil {
+			return nil, storeError("address", err)
+		}
+		addresses = append(addresses, address)
+	}
+
+	return addresses, rows.Err()
+}
+
+func (s *MySQLCustomerStore) GetAddress(ctx context.Context, customerID int, addressID int) (models.CustomerAddress, error) {
+	query := `
+		SELECT ` + customerAddressColumns + `
+		FROM customer_addresses
+		WHERE id = ? AND customer_id = ?
+	`
+
+	address, err := scanCustomerAddress(conn(ctx, s.db).QueryRowContext(ctx, query, addressID, customerID))
+	return address, storeError("address", err)
+}
+
+// AddAddress saves a new address; the customer's first address, and any
+// address flagged as default, becomes the default for that kind
+func (s *MySQLCustomerStore) AddAddress(ctx context.Context, customerID int, address models.CustomerAddress) (models.CustomerAddress, error) {
+	address.Normalize()
+	if err := address.Validate(); err != nil {
+		return address, storeError("address", err)
+	}
+	address.CustomerID = customerID
+
+	err := runInTx(ctx, s.db, func(tx *sql.Tx) error {
+		if err := s.lockCustomer(ctx, tx, customerID); err != nil {
+			return storeError("address", err)
+		}
+
+		var count int
+		err := tx.QueryRowContext(ctx, "SELECT COUNT(*) FROM customer_addresses WHERE customer_id = ?", customerID).Scan(&count)
+		if err != nil {
+			return storeError("address", err)
+		}
+		if count == 0 {
+			address.IsDefaultShipping = true
+			address.IsDefaultBilling = true
+		}
+
+		address.ID, err = insertCustomerAddress(ctx, tx, address)
+		if err != nil {
+			return storeError("address", err)
+		}
+
+		return clearDefaults(ctx, tx, address)
+	})
+	if err != nil {
+		return address, storeError("address", err)
+	}
+
+	return s.GetAddress(ctx, customerID, address.ID)
+}
+
+// UpdateAddress replaces the address fields and label. Default flags can
+// only be moved to an address, never cleared: mark another address as
+// default instead.
+func (s *MySQLCustomerStore) UpdateAddress(ctx context.Context, customerID int, addressID int, address models.CustomerAddress) (models.CustomerAddress, error) {
+	address.Normalize()
+	if err := address.Validate(); err != nil {
+		return address, storeError("address", err)
+	}
+
+	err := runInTx(ctx, s.db, func(tx *sql.Tx) error {
+		if err := s.lockCustomer(ctx, tx, customerID); err != nil {
+			return storeError("address", err)
+		}
+
+		current, err := s.GetAddress(context.WithValue(ctx, txKey{}, tx), customerID, addressID)
+		if err != nil {
+			return storeError("address", err)
+		}
+
+		address.ID = addressID
+		address.CustomerID = customerID
+		address.IsDefaultShipping = address.IsDefaultShipping || current.IsDefaultShipping
+		address.IsDefaultBilling = address.IsDefaultBilling || current.IsDefaultBilling
+
+		query := `
+			UPDATE customer_addresses
+			SET label = ?, street = ?, city = ?, state = ?, postal_code = ?, country = ?,
+				is_default_shipping = ?, is_default_billing = ?
+			WHERE id = ?
+		`
+
+		_, err = tx.ExecContext(
+			ctx,
+			query,
+			address.Label,
+			address.Street,
+			address.City,
+			address.State,
+			address.PostalCode,
+			address.Country,
+			address.IsDefaultShipping,
+			address.IsDefaultBilling,
+			addressID,
 		)
 		if err != nil {
-			return nil, err
+			return storeError("address", err)
 		}
-		customers = append(customers, c)
+
+		return clearDefaults(ctx, tx, address)
+	})
+	if err != nil {
+		return address, storeError("address", err)
 	}
-	return customers, nil
+
+	return s.GetAddress(ctx, customerID, addressID)
 }
 
+// DeleteAddress removes a saved address. The last address cannot be
+// removed; defaults held by the removed address move to the oldest one left.
+func (s *MySQLCustomerStore) DeleteAddress(ctx context.Context, customerID int, addressID int) error {
+	return runInTx(ctx, s.db, func(tx *sql.Tx) error {
+		if err := s.lockCustomer(ctx, tx, customerID); err != nil {
+			return storeError("address", err)
+		}
+
+		current, err := s.GetAddress(context.WithValue(ctx, txKey{}, tx), customerID, addressID)
+		if err != nil {
+			return storeError("address", err)
+		}
+
+		var count int
+		err = tx.QueryRowContext(ctx, "SELECT COUNT(*) FROM customer_addresses WHERE customer_id = ?", customerID).Scan(&count)
+		if err != nil {
+			return storeError("address", err)
+		}
+		if count <= 1 {
+			return models.ErrLastAddress
+		}
+
+		if _, err := tx.ExecContext(ctx, "DELETE FROM customer_addresses WHERE id = ?", addressID); err != nil {
+			return storeError("address", err)
+		}
+
+		var oldestID int
+		err = tx.QueryRowContext(ctx, "SELECT MIN(id) FROM customer_addresses WHERE customer_id = ?", customerID).Scan(&oldestID)
+		if err != nil {
+			return storeError("address", err)
+		}
+
+		if current.IsDefaultShipping {
+			if _, err := tx.ExecContext(ctx, "UPDATE customer_addresses SET is_default_shipping = TRUE WHERE id = ?", oldestID); err != nil {
+				return storeError("address", err)
+			}
+		}
+		if current.IsDefaultBilling {
+			if _, err := tx.ExecContext(ctx, "UPDATE customer_addresses SET is_default_billing = TRUE WHERE id = ?", oldestID); err != nil {
+				return storeError("address", err)
+			}
+		}
+		return nil
+	})
+}
 
+// lookupCustomerAddress returns the saved address with the given id, or
+// the customer's default of the given kind when id is 0
+func lookupCustomerAddress(ctx context.Context, tx *sql.Tx, customerID int, addressID int, kind string) (models.Address, error) {
+	query := `
+		SELECT ` + customerAddressColumns + `
+		FROM customer_addresses
+		WHERE customer_id = ?
+	`
+	args := []interface{}{customerID}
+
+	switch {
+	case addressID != 0:
+		query += " AND id = ?"
+		args = append(args, addressID)
+	case kind == models.AddressBilling:
+		query += " AND is_default_billing"
+	default:
+		query += " AND is_default_shipping"
+	}
 
+	address, err := scanCustomerAddress(tx.QueryRowContext(ctx, query, args...))
+	if errors.Is(err, sql.ErrNoRows) {
+		return models.Address{}, models.ErrUnknownAddress
+	}
+	return address.Address, err
+}
@@ -0,0 +1,102 @@
+package concreteimplemetations
+
+import (
+	"context"
+	"database/sql"
+
+	"online_bookStore/models"
+)
+
+type MySQLGenreStore struct {
+	db *sql.DB
+}
+
+// Constructor
+func NewMySQLGenreStore(db *sql.DB) *MySQLGenreStore {
+	return &MySQLGenreStore{
+		db: db,
+	}
+}
+
+// ListGenres returns every genre by name; genres whose books were all
+// deleted or moved stay listed with a count of 0
+func (s *MySQLGenreStore) ListGenres(ctx context.Context) ([]models.Genre, error) {
+	query := `
+		SELECT g.id, g.name, COUNT(b.id)
+		FROM genres g
+		LEFT JOIN book_genres bg ON bg.genre_id = g.id
+		LEFT JOIN books b ON b.id = bg.book_id AND b.deleted_at IS NULL
+		GROUP BY g.id, g.name
+		ORDER BY g.name
+	`
+
+	rows, err := s.db.QueryContext(ctx, query)
+	if err != nil {
+		return nil, storeError("genre", err)
+	}
+	defer rows.Close()
+
+	genres := []models.Genre{}
+	for rows.Next() {
+		var genre models.Genre
+		if err := rows.Scan(&genre.ID, &genre.Name, &genre.BookCount); err != nil {
+			return nil, storeError("genre", err)
+		}
+		genres = append(genres, genre)
+	}
+
+	return genres, storeError("genre", rows.Err())
+}
+
+// bookGenres returns the genre names of a book in name order
+func bookGenres(ctx context.Context, q querier, bookID int) ([]string, error) {
+	query := `
+		SELECT g.name
+		FROM book_genres bg
+		JOIN genres g ON g.id = bg.genre_id
+		WHERE bg.book_id = ?
+		ORDER BY g.name
+	`
+
+	rows, err := q.QueryContext(ctx, query, bookID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	genres := []string{}
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			return nil, err
+		}
+		genres = append(genres, name)
+	}
+
+	return genres, rows.Err()
+}
+
+// setBookGenres replaces the genres of a book, adding the names the
+// genres table does not have yet; names are matched regardless of case,
+// so the first spelling stored is the one every book shows
+func setBookGenres(ctx context.Context, db *sql.DB, tx *sql.Tx, bookID int, genres []string) error {
+	if _, err := tx.ExecContext(ctx, "DELETE FROM book_genres WHERE book_id = ?", bookID); err != nil {
+		return err
+	}
+
+	for _, name := range genres {
+		if _, err := tx.ExecContext(ctx, insertIgnore(db)+" INTO genres (name) VALUES (?)", name); err != nil {
+			return err
+		}
+
+		query := `
+			INSERT INTO book_genres (book_id, genre_id)
+			SELECT ?, id FROM genres WHERE name = ?
+		`
+		if _, err := tx.ExecContext(ctx, query, bookID, name); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,105 @@
+package concreteimplemetations
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"online_bookStore/models"
+)
+
+type MySQLIdempotencyStore struct {
+	db *sql.DB
+}
+
+// Constructor
+func NewMySQLIdempotencyStore(db *sql.DB) *MySQLIdempotencyStore {
+	return &MySQLIdempotencyStore{db: db}
+}
+
+func (s *MySQLIdempotencyStore) Reserve(ctx context.Context, record models.IdempotencyRecord) (models.IdempotencyRecord, bool, error) {
+	// an expired key can be reused
+	_, err := s.db.ExecContext(ctx, `
+		DELETE FROM idempotency_keys
+		WHERE user_id = ? AND idem_key = ? AND expires_at < ?
+	`, record.UserID, record.Key, time.Now())
+	if err != nil {
+		return models.IdempotencyRecord{}, false, storeError("idempotency key", err)
+	}
+
+	result, err := s.db.ExecContext(ctx, insertIgnore(s.db)+` INTO idempotency_keys (user_id, idem_key, request_hash, created_at, expires_at)
+		VALUES (?, ?, ?, ?, ?)
+	`, record.UserID, record.Key, record.RequestHash, record.CreatedAt, record.ExpiresAt)
+	if err != nil {
+		return models.IdempotencyRecord{}, false, storeError("idempotency key", err)
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return models.IdempotencyRecord{}, false, storeError("idempotency key", err)
+	}
+	if rows == 1 {
+		return record, true, nil
+	}
+
+	existing, err := s.get(ctx, record.UserID, record.Key)
+	if err != nil {
+		return models.IdempotencyRecord{}, false, storeError("idempotency key", err)
+	}
+	return existing, false, nil
+}
+
+func (s *MySQLIdempotencyStore) get(ctx context.Context, userID int, key string) (models.IdempotencyRecord, error) {
+	var (
+		record      models.IdempotencyRecord
+		statusCode  sql.NullInt64
+		contentType sql.NullString
+	)
+
+	err := s.db.QueryRowContext(ctx, `
+		SELECT user_id, idem_key, request_hash, status_code, content_type, response_body, created_at, expires_at
+		FROM idempotency_keys
+		WHERE user_id = ? AND idem_key = ?
+	`, userID, key).Scan(
+		&record.UserID,
+		&record.Key,
+		&record.RequestHash,
+		&statusCode,
+		&contentType,
+		&record.ResponseBody,
+		&record.CreatedAt,
+		&record.ExpiresAt,
+	)
+	if err != nil {
+		return models.IdempotencyRecord{}, err
+	}
+
+	record.StatusCode = int(statusCode.Int64)
+	record.ContentType = contentType.String
+	return record, nil
+}
+
+func (s *MySQLIdempotencyStore) Complete(ctx context.Context, userID int, key string, statusCode int, contentType string, body []byte) error {
+	_, err := s.db.ExecContext(ctx, `
+		UPDATE idempotency_keys
+		SET status_code = ?, content_type = ?, response_body = ?
+		WHERE user_id = ? AND idem_key = ?
+	`, statusCode, contentType, body, userID, key)
+	return storeError("idempotency key", err)
+}
+
+func (s *MySQLIdempotencyStore) Release(ctx context.Context, userID int, key string) error {
+	_, err := s.db.ExecContext(ctx, `
+		DELETE FROM idempotency_keys
+		WHERE user_id = ? AND idem_key = ? AND status_code IS NULL
+	`, userID, key)
+	return storeError("idempotency key", err)
+}
+
+func (s *MySQLIdempotencyStore) DeleteExpiredKeys(ctx context.Context, before time.Time) (int64, error) {
+	result, err := s.db.ExecContext(ctx, "DELETE FROM idempotency_keys WHERE expires_at < ?", before)
+	if err != nil {
+		return 0, storeError("idempotency key", err)
+	}
+	return result.RowsAffected()
+}
@@ -3,105 +3,50 @@ package concreteimplemetations
 import (
 	"context"
 	"database/sql"
-	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"sort"
 	"time"
 
+	"online_bookStore/Interfaces"
 	"online_bookStore/models"
 )
 
 type MySQLOrderStore struct {
-	db *sql.DB
+	db       *sql.DB
+	tax      interfaces.TaxCalculator
+	shipping interfaces.ShippingCalculator
 }
 
-func NewMySQLOrderStore(db *sql.DB) *MySQLOrderStore {
+// Constructor: a nil calculator charges no tax or shipping
+func NewMySQLOrderStore(db *sql.DB, tax interfaces.TaxCalculator, shipping interfaces.ShippingCalculator) *MySQLOrderStore {
 	return &MySQLOrderStore{
-		db: db,
+		db:       db,
+		tax:      tax,
+		shipping: shipping,
 	}
 }
 
-func (s *MySQLOrderStore) CreateOrder(ctx context.Context, order models.Order) (models.Order, error) {
-
-
-    tx, err := s.db.BeginTx(ctx, nil)
-    if err != nil {
-        return order, err
-    }
-
-    defer func() {
-        if p := recover(); p != nil {
-            tx.Rollback()
-            panic(p)
-        }
-    }()
-
-    orderQuery := `
-        INSERT INTO orders (customer_id, total_price, status)
-        VALUES (?, ?, ?)
-    `
-
-    result, err := tx.ExecContext(
-        ctx,
-        orderQuery,
-        order.Customer.ID,
-        order.TotalPrice,
-        order.Status,
-    )
-    if err != nil {
-        tx.Rollback()
-        return order, err
-    }
-
-    orderID, err := result.LastInsertId()
-    if err != nil {
-        tx.Rollback()
-        return order, err
-    }
-
-    order.ID = int(orderID)
-
-    itemQuery := `
-        INSERT INTO order_items (order_id, book_id, quantity)
-        VALUES (?, ?, ?)
-    `
-
-    for _, item := range order.Items {
-        _, err = tx.ExecContext(
-            ctx,
-            itemQuery,
-            order.ID,
-            item.Book.ID,
-            item.Quantity,
-        )
-        if err != nil {
-            tx.Rollback()
-            return order, err
-        }
-    }
-
-    if err = tx.Commit(); err != nil {
-        tx.Rollback()
-        return order, err
-    }
-
-    return order, nil
-}
-
-func (s *MySQLOrderStore) GetOrder(ctx context.Context, id int) (models.Order, error) {
-	query := `
-		SELECT 
-			o.id, o.total_price, o.created_at, o.status,
-			c.id, c.name, c.email
-		FROM orders o
-		JOIN customers c ON o.customer_id = c.id
-		WHERE o.id = ?
-	`
+// orderColumns is read by scanOrder; queries alias orders as o and customers as c
+const orderColumns = `o.id, o.subtotal, o.discount, o.coupon_code, o.tax, o.shipping, o.total_price, o.created_at, o.status, o.deleted_at,
+			c.id, c.name, c.email`
 
+func scanOrder(row rowScanner) (models.Order, error) {
 	var order models.Order
-	err := s.db.QueryRowContext(ctx, query, id).Scan(
+	var couponCode sql.NullString
+
+	err := row.Scan(
 		&order.ID,
+		&order.Subtotal,
+		&order.Discount,
+		&couponCode,
+		&order.Tax,
+		&order.Shipping,
 		&order.TotalPrice,
 		&order.CreatedAt,
 		&order.Status,
+		&order.DeletedAt,
 		&order.Customer.ID,
 		&order.Customer.Name,
 		&order.Customer.Email,
@@ -110,100 +55,626 @@ func (s *MySQLOrderStore) GetOrder(ctx context.Context, id int) (models.Order, e
 		return order, err
 	}
 
+	order.CouponCode = couponCode.String
+	return order, nil
+}
+
+// CreateOrder prices, reserves stock for and stores an order. It joins the
+// transaction carried by ctx (see MySQLTransactor) or runs its own.
+func (s *MySQLOrderStore) CreateOrder(ctx context.Context, order models.Order) (models.Order, error) {
+
+	if len(order.Items) == 0 {
+		return order, models.ErrEmptyOrder
+	}
+
+	// every order starts its lifecycle as pending
+	order.Status = models.NormalizeOrderStatus(order.Status)
+	if order.Status == "" {
+		order.Status = models.OrderStatusPending
+	}
+	if order.Status != models.OrderStatusPending {
+		return order, &models.InvalidTransitionError{From: "", To: order.Status}
+	}
+
+	// copy the items so a failed attempt leaves the caller's order untouched
+	order.Items = append([]models.OrderItem(nil), order.Items...)
+
+	err := runInTx(ctx, s.db, func(tx *sql.Tx) error {
+		return s.createOrderTx(ctx, tx, &order)
+	})
+	if err != nil {
+		return order, storeError("order", err)
+	}
+
+	return order, nil
+}
+
+func (s *MySQLOrderStore) createOrderTx(ctx context.Context, tx *sql.Tx, order *models.Order) error {
+	// total quantity per book, so a book listed twice is checked once
+	wanted := make(map[int]int)
+	for i, item := range order.Items {
+		if item.Quantity <= 0 {
+			return models.NewValidationError(models.ErrInvalidQuantity, "order", fmt.Sprintf("book %d", item.Book.ID),
+				models.FieldError{Field: fmt.Sprintf("items[%d].quantity", i), Message: "must be positive"})
+		}
+		wanted[item.Book.ID] += item.Quantity
+	}
+
+	// lock the book rows in id order so concurrent orders cannot deadlock
+	bookIDs := make([]int, 0, len(wanted))
+	for id := range wanted {
+		bookIDs = append(bookIDs, id)
+	}
+	sort.Ints(bookIDs)
+
+	books := make(map[int]models.Book)
+	var shortBookIDs []int
+	for _, id := range bookIDs {
+		book := models.Book{ID: id}
+		err := tx.QueryRowContext(ctx, "SELECT price, stock, weight_grams, author_id FROM books WHERE id = ? AND deleted_at IS NULL"+forUpdate(s.db), id).Scan(
+			&book.Price,
+			&book.Stock,
+			&book.WeightGrams,
+			&book.Author.ID,
+		)
+		if errors.Is(err, sql.ErrNoRows) {
+			return fmt.Errorf("%w: %d", models.ErrUnknownBook, id)
+		}
+		if err != nil {
+			return err
+		}
+
+		// coupons scoped to a genre need the book's genres
+		book.Genres, err = bookGenres(ctx, tx, id)
+		if err != nil {
+			return err
+		}
+
+		books[id] = book
+		if book.Stock < wanted[id] {
+			shortBookIDs = append(shortBookIDs, id)
+		}
+	}
+
+	if len(shortBookIDs) > 0 {
+		return &models.InsufficientStockError{BookIDs: shortBookIDs}
+	}
+
+	for _, id := range bookIDs {
+		_, err := tx.ExecContext(ctx, "UPDATE books SET stock = stock - ? WHERE id = ?", wanted[id], id)
+		if err != nil {
+			return err
+		}
+	}
+
+	// price every line from the catalogue, never from the payload
+	subtotal := 0.0
+	for i, item := range order.Items {
+		book := books[item.Book.ID]
+		order.Items[i].Book.Author.ID = book.Author.ID
+		order.Items[i].Book.Genres = book.Genres
+		order.Items[i].Book.WeightGrams = book.WeightGrams
+		order.Items[i].UnitPrice = book.Price
+		subtotal += order.Items[i].UnitPrice * float64(item.Quantity)
+	}
+	order.Subtotal = roundCents(subtotal)
+
+	order.Discount = 0
+	couponID := 0
+	if order.CouponCode != "" {
+		id, err := redeemCoupon(ctx, s.db, tx, order)
+		if err != nil {
+			return err
+		}
+		couponID = id
+	}
+	if err := resolveOrderAddresses(ctx, tx, order); err != nil {
+		return err
+	}
+	if err := s.applyTaxAndShipping(ctx, order); err != nil {
+		return err
+	}
+	total := roundCents(order.Subtotal - order.Discount + order.Tax + order.Shipping)
+
+	// a client-supplied total is only accepted as a cross-check
+	if order.TotalPrice != 0 && math.Abs(order.TotalPrice-total) > 0.005 {
+		return fmt.Errorf("%w: expected %.2f, got %.2f", models.ErrTotalMismatch, total, order.TotalPrice)
+	}
+	order.TotalPrice = total
+
+	var couponCode interface{}
+	if order.CouponCode != "" {
+		couponCode = order.CouponCode
+	}
+
+	orderQuery := `
+		INSERT INTO orders (customer_id, subtotal, discount, coupon_id, coupon_code, tax, shipping, total_price, status)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)
+	`
+
+	result, err := tx.ExecContext(
+		ctx,
+		orderQuery,
+		order.Customer.ID,
+		order.Subtotal,
+		order.Discount,
+		nullableID(couponID),
+		couponCode,
+		order.Tax,
+		order.Shipping,
+		order.TotalPrice,
+		order.Status,
+	)
+	if err != nil {
+		return err
+	}
+
+	orderID, err := result.LastInsertId()
+	if err != nil {
+		return err
+	}
+
+	order.ID = int(orderID)
+
+	itemQuery := `
+		INSERT INTO order_items (order_id, book_id, quantity, unit_price)
+		VALUES (?, ?, ?, ?)
+	`
+
+	for i, item := range order.Items {
+		itemResult, err := tx.ExecContext(
+			ctx,
+			itemQuery,
+			order.ID,
+			item.Book.ID,
+			item.Quantity,
+			item.UnitPrice,
+		)
+		if err != nil {
+			return err
+		}
+
+		itemID, err := itemResult.LastInsertId()
+		if err != nil {
+			return err
+		}
+		order.Items[i].ID = int(itemID)
+	}
+
+	if err := insertOrderAddress(ctx, tx, order.ID, models.AddressShipping, order.ShippingAddress); err != nil {
+		return err
+	}
+	if err := insertOrderAddress(ctx, tx, order.ID, models.AddressBilling, order.BillingAddress); err != nil {
+		return err
+	}
+
+	return recordStatusChange(ctx, tx, order.ID, "", order.Status, 0)
+}
+
+// resolveOrderAddresses snapshots the shipping and billing addresses:
+// an address sent with the order wins, then a saved address picked by id,
+// then the customer's default address of that kind
+func resolveOrderAddresses(ctx context.Context, tx *sql.Tx, order *models.Order) error {
+	var exists int
+	err := tx.QueryRowContext(ctx, "SELECT 1 FROM customers WHERE id = ? AND deleted_at IS NULL", order.Customer.ID).Scan(&exists)
+	if errors.Is(err, sql.ErrNoRows) {
+		return fmt.Errorf("%w: %d", models.ErrUnknownCustomer, order.Customer.ID)
+	}
+	if err != nil {
+		return err
+	}
+
+	order.ShippingAddress, err = snapshotAddress(ctx, tx, order, order.ShippingAddress, order.ShippingAddressID, models.AddressShipping)
+	if err != nil {
+		return fmt.Errorf("shipping_address: %w", models.PrefixFields(err, "shipping_address"))
+	}
+	order.BillingAddress, err = snapshotAddress(ctx, tx, order, order.BillingAddress, order.BillingAddressID, models.AddressBilling)
+	if err != nil {
+		return fmt.Errorf("billing_address: %w", models.PrefixFields(err, "billing_address"))
+	}
+	return nil
+}
+
+// snapshotAddress returns a detached copy of the address to store
+func snapshotAddress(ctx context.Context, tx *sql.Tx, order *models.Order, given *models.Address, savedID int, kind string) (*models.Address, error) {
+	var address models.Address
+	if given != nil {
+		if err := given.Validate(); err != nil {
+			return nil, err
+		}
+		address = *given
+	} else {
+		saved, err := lookupCustomerAddress(ctx, tx, order.Customer.ID, savedID, kind)
+		if err != nil {
+			return nil, err
+		}
+		address = saved
+	}
+
+	address.ID = 0
+	return &address, nil
+}
+
+// applyTaxAndShipping prices tax and delivery to the shipping address;
+// both are charged on the discounted subtotal
+func (s *MySQLOrderStore) applyTaxAndShipping(ctx context.Context, order *models.Order) error {
+	amount := roundCents(order.Subtotal - order.Discount)
+
+	var err error
+	order.Tax = 0
+	if s.tax != nil {
+		if order.Tax, err = s.tax.CalculateTax(ctx, *order.ShippingAddress, amount); err != nil {
+			return err
+		}
+	}
+
+	order.Shipping = 0
+	if s.shipping != nil {
+		if order.Shipping, err = s.shipping.CalculateShipping(ctx, *order.ShippingAddress, order.Items, amount); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func insertOrderAddress(ctx context.Context, tx *sql.Tx, orderID int, kind string, address *models.Address) error {
+	query := `
+		INSERT INTO order_addresses (order_id, kind, street, city, state, postal_code, country)
+		VALUES (?, ?, ?, ?, ?, ?, ?)
+	`
+
+	_, err := tx.ExecContext(
+		ctx,
+		query,
+		orderID,
+		kind,
+		address.Street,
+		address.City,
+		address.State,
+		address.PostalCode,
+		address.Country,
+	)
+	return err
+}
+
+// loadOrderAddresses reads the snapshots; orders placed before they were
+// recorded have none
+func (s *MySQLOrderStore) loadOrderAddresses(ctx context.Context, order *models.Order) error {
+	query := `
+		SELECT kind, street, city, state, postal_code, country
+		FROM order_addresses
+		WHERE order_id = ?
+	`
+
+	rows, err := conn(ctx, s.db).QueryContext(ctx, query, order.ID)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var kind string
+		var address models.Address
+		err := rows.Scan(
+			&kind,
+			&address.Street,
+			&address.City,
+			&address.State,
+			&address.PostalCode,
+			&address.Country,
+		)
+		if err != nil {
+			return err
+		}
+
+		switch kind {
+		case models.AddressShipping:
+			order.ShippingAddress = &address
+		case models.AddressBilling:
+			order.BillingAddress = &address
+		}
+	}
+
+	return rows.Err()
+}
+
+func (s *MySQLOrderStore) GetOrder(ctx context.Context, id int) (models.Order, error) {
+	query := `
+		SELECT ` + orderColumns + `
+		FROM orders o
+		JOIN customers c ON o.customer_id = c.id
+		WHERE o.id = ? AND ` + notDeleted(ctx, "o") + `
+	`
+
+	order, err := scanOrder(conn(ctx, s.db).QueryRowContext(ctx, query, id))
+	if err != nil {
+		return order, storeError("order", err)
+	}
+
+	if err := s.loadOrderAddresses(ctx, &order); err != nil {
+		return order, storeError("order", err)
+	}
+
 	itemsQuery := `
 		SELECT 
-			oi.id, oi.quantity,
-			b.id, b.title, b.genres, b.published_at, b.price, b.stock
+			oi.id, oi.quantity, oi.unit_price,
+			b.id, b.title, b.published_at, b.price, b.stock
 		FROM order_items oi
 		JOIN books b ON oi.book_id = b.id
 		WHERE oi.order_id = ?
 	`
 
-	rows, err := s.db.QueryContext(ctx, itemsQuery, order.ID)
+	rows, err := conn(ctx, s.db).QueryContext(ctx, itemsQuery, order.ID)
 	if err != nil {
-		return order, err
+		return order, storeError("order", err)
 	}
 	defer rows.Close()
 
 	for rows.Next() {
 		var item models.OrderItem
-		var genresJSON string
 
 		err := rows.Scan(
 			&item.ID,
 			&item.Quantity,
+			&item.UnitPrice,
 			&item.Book.ID,
 			&item.Book.Title,
-			&genresJSON,
 			&item.Book.PublishedAt,
 			&item.Book.Price,
 			&item.Book.Stock,
 		)
 		if err != nil {
-			return order, err
+			return order, storeError("order", err)
 		}
 
-		_ = json.Unmarshal([]byte(genresJSON), &item.Book.Genres)
 		order.Items = append(order.Items, item)
 	}
+	if err := rows.Err(); err != nil {
+		return order, storeError("order", err)
+	}
+	// free the connection (a transaction's, maybe) for the genre queries
+	rows.Close()
+
+	for i := range order.Items {
+		genres, err := bookGenres(ctx, conn(ctx, s.db), order.Items[i].Book.ID)
+		if err != nil {
+			return order, storeError("order", err)
+		}
+		order.Items[i].Book.Genres = genres
+	}
 
 	return order, nil
 }
 
 
-func (s *MySQLOrderStore) UpdateOrderStatus(ctx context.Context,id int, status string) (models.Order, error) {
+// UpdateOrderStatus moves an order along its lifecycle. Transitions not
+// allowed by models.CanTransitionOrder are rejected; every accepted change
+// is recorded in order_status_history with the user who made it. It joins
+// the transaction carried by ctx (see MySQLTransactor) or runs its own.
+func (s *MySQLOrderStore) UpdateOrderStatus(ctx context.Context,id int, status string, changedBy int) (models.Order, error) {
 	var order models.Order
 
+	status = models.NormalizeOrderStatus(status)
+	if !models.IsValidOrderStatus(status) {
+		return order, fmt.Errorf("%w: %q", models.ErrUnknownStatus, status)
+	}
+
+	err := runInTx(ctx, s.db, func(tx *sql.Tx) error {
+		var current string
+		err := tx.QueryRowContext(ctx, "SELECT status FROM orders WHERE id = ? AND deleted_at IS NULL"+forUpdate(s.db), id).Scan(&current)
+		if err != nil {
+			return storeError("order", err)
+		}
+		current = models.NormalizeOrderStatus(current)
+
+		if !models.CanTransitionOrder(current, status) {
+			return &models.InvalidTransitionError{From: current, To: status}
+		}
+
+		query := `
+		  UPDATE orders
+		  SET status = ?		
+		  WHERE id = ?
+		`
+
+		_, err = tx.ExecContext(ctx,query, status, id)
+		if err != nil {
+			return storeError("order", err)
+		}
+
+		if models.ReleasesStock(current, status) {
+			if err := restoreOrderStock(ctx, tx, id); err != nil {
+				return storeError("order", err)
+			}
+		}
+
+		// a cancelled order does not use up its coupon
+		if status == models.OrderStatusCancelled {
+			if err := releaseCoupon(ctx, tx, id); err != nil {
+				return storeError("coupon", err)
+			}
+		}
+
+		return recordStatusChange(ctx, tx, id, current, status, changedBy)
+	})
+	if err != nil {
+		return order, storeError("order", err)
+	}
+
+	return s.GetOrder(ctx, id)
+
+}
+
+func recordStatusChange(ctx context.Context, tx *sql.Tx, orderID int, from string, to string, changedBy int) error {
 	query := `
-	  UPDATE orders
-	  SET status = ?		
-	  WHERE id = ?
+		INSERT INTO order_status_history (order_id, from_status, to_status, changed_by)
+		VALUES (?, ?, ?, ?)
 	`
 
-	result, err := s.db.ExecContext(ctx,query, status, id)
-	if err != nil {
-		return order, err
+	var fromStatus interface{}
+	if from != "" {
+		fromStatus = from
 	}
 
-	rowsAffected, err := result.RowsAffected()
+	_, err := tx.ExecContext(ctx, query, orderID, fromStatus, to, nullableID(changedBy))
+	return err
+}
+
+func (s *MySQLOrderStore) GetOrderStatusHistory(ctx context.Context, orderID int) ([]models.OrderStatusChange, error) {
+	var exists int
+	err := s.db.QueryRowContext(ctx, "SELECT 1 FROM orders o WHERE o.id = ? AND "+notDeleted(ctx, "o"), orderID).Scan(&exists)
 	if err != nil {
-		return order, err
+		return nil, storeError("order", err)
 	}
 
-	if rowsAffected == 0 {
-		return order, sql.ErrNoRows
+	query := `
+		SELECT id, order_id, from_status, to_status, changed_by, changed_at
+		FROM order_status_history
+		WHERE order_id = ?
+		ORDER BY changed_at, id
+	`
+
+	rows, err := s.db.QueryContext(ctx, query, orderID)
+	if err != nil {
+		return nil, storeError("order", err)
 	}
+	defer rows.Close()
 
-	order.ID = id
-	order.Status = status
+	history := []models.OrderStatusChange{}
+	for rows.Next() {
+		var change models.OrderStatusChange
+		var fromStatus sql.NullString
+		var changedBy sql.NullInt64
 
-	return order, nil
+		err := rows.Scan(
+			&change.ID,
+			&change.OrderID,
+			&fromStatus,
+			&change.ToStatus,
+			&changedBy,
+			&change.ChangedAt,
+		)
+		if err != nil {
+			return nil, storeError("order", err)
+		}
 
+		change.FromStatus = fromStatus.String
+		change.ChangedBy = int(changedBy.Int64)
+		history = append(history, change)
+	}
+
+	return history, rows.Err()
 }
 
+// DeleteOrder soft-deletes the order; its items, history and payments are
+// kept. A pending or paid order still holds stock, a coupon use or a
+// payment, so it is refused with models.ErrOrderNotDeletable: cancelling
+// gives those back.
 func (s *MySQLOrderStore) DeleteOrder(ctx context.Context,id int) error {
-	query := `
-	    DELETE FROM orders
-		WHERE id = ?
-	 `
+	return runInTx(ctx, s.db, func(tx *sql.Tx) error {
+		var status string
+		err := tx.QueryRowContext(ctx, "SELECT status FROM orders WHERE id = ? AND deleted_at IS NULL"+forUpdate(s.db), id).Scan(&status)
+		if err != nil {
+			return storeError("order", err)
+		}
 
-	result, err := s.db.ExecContext(ctx,query, id)
+		if models.HoldsStock(status) {
+			return fmt.Errorf("%w: order is %s", models.ErrOrderNotDeletable, status)
+		}
 
+		return softDelete(ctx, tx, "orders", id)
+	})
+}
+
+// RestoreOrder undeletes an order. A pending or paid order reserves its
+// stock again, which fails with *models.InsufficientStockError when the
+// books have sold out meanwhile.
+func (s *MySQLOrderStore) RestoreOrder(ctx context.Context, id int) (models.Order, error) {
+	err := runInTx(ctx, s.db, func(tx *sql.Tx) error {
+		var status string
+		err := tx.QueryRowContext(ctx, "SELECT status FROM orders WHERE id = ? AND deleted_at IS NOT NULL"+forUpdate(s.db), id).Scan(&status)
+		if err != nil {
+			return storeError("order", err)
+		}
+
+		if models.HoldsStock(status) {
+			if err := s.reserveOrderStock(ctx, tx, id); err != nil {
+				return storeError("order", err)
+			}
+		}
+
+		return restoreDeleted(ctx, tx, "orders", id)
+	})
 	if err != nil {
-		return err
+		return models.Order{}, storeError("order", err)
 	}
 
-	rowsAffected, err := result.RowsAffected()
+	return s.GetOrder(ctx, id)
+}
+
+// reserveOrderStock takes the quantities of an order off the shelf again
+func (s *MySQLOrderStore) reserveOrderStock(ctx context.Context, tx *sql.Tx, orderID int) error {
+	query := `
+		SELECT b.id, b.stock, SUM(oi.quantity)
+		FROM order_items oi
+		JOIN books b ON oi.book_id = b.id
+		WHERE oi.order_id = ?
+		GROUP BY b.id, b.stock
+		ORDER BY b.id
+	` + forUpdate(s.db)
+
+	rows, err := tx.QueryContext(ctx, query, orderID)
 	if err != nil {
 		return err
 	}
 
-	if rowsAffected == 0 {
-		return sql.ErrNoRows
+	wanted := make(map[int]int)
+	var bookIDs, shortBookIDs []int
+	for rows.Next() {
+		var bookID, stock, quantity int
+		if err := rows.Scan(&bookID, &stock, &quantity); err != nil {
+			rows.Close()
+			return err
+		}
+		if stock < quantity {
+			shortBookIDs = append(shortBookIDs, bookID)
+		}
+		wanted[bookID] = quantity
+		bookIDs = append(bookIDs, bookID)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return err
 	}
 
+	if len(shortBookIDs) > 0 {
+		return &models.InsufficientStockError{BookIDs: shortBookIDs}
+	}
+
+	for _, bookID := range bookIDs {
+		_, err := tx.ExecContext(ctx, "UPDATE books SET stock = stock - ? WHERE id = ?", wanted[bookID], bookID)
+		if err != nil {
+			return err
+		}
+	}
 	return nil
+}
 
+// restoreOrderStock puts the quantities of an order back on the shelf
+func restoreOrderStock(ctx context.Context, tx *sql.Tx, orderID int) error {
+	query := `
+		UPDATE books
+		SET stock = stock + (
+			SELECT SUM(oi.quantity)
+			FROM order_items oi
+			WHERE oi.order_id = ? AND oi.book_id = books.id
+		)
+		WHERE id IN (SELECT book_id FROM order_items WHERE order_id = ?)
+	`
+
+	_, err := tx.ExecContext(ctx, query, orderID, orderID)
+	return err
 }
 
 func (s *MySQLOrderStore) GetOrderByDateRange(
@@ -213,85 +684,93 @@ func (s *MySQLOrderStore) GetOrderByDateRange(
 ) ([]models.Order, error) {
 
 	query := `
-		SELECT 
-			o.id, o.total_price, o.created_at, o.status,
-			c.id, c.name, c.email
+		SELECT ` + orderColumns + `
 		FROM orders o
 		JOIN customers c ON o.customer_id = c.id
-		WHERE o.created_at BETWEEN ? AND ?
+		WHERE o.created_at BETWEEN ? AND ? AND ` + notDeleted(ctx, "o") + `
 		ORDER BY o.created_at ASC
 	`
 
 	rows, err := s.db.QueryContext(ctx,query, from, to)
 	if err != nil {
-		return nil, err
+		return nil, storeError("order", err)
 	}
 	defer rows.Close()
 
 	var orders []models.Order
 
 	for rows.Next() {
-		var order models.Order
-
-		err := rows.Scan(
-			&order.ID,
-			&order.TotalPrice,
-			&order.CreatedAt,
-			&order.Status,
-			&order.Customer.ID,
-			&order.Customer.Name,
-			&order.Customer.Email,
-		)
+		order, err := scanOrder(rows)
 		if err != nil {
-			return nil, err
+			return nil, storeError("order", err)
 		}
 
 		orders = append(orders, order)
 	}
 
-	return orders, nil
+	return orders, storeError("order", rows.Err())
 }
 
 
 func (s *MySQLOrderStore) GetAllOrders(ctx context.Context) ([]models.Order, error) {
 	query := `
-	   SELECT o.id, o.total_price, o.created_at, o.status,
-	   c.id, c.name,c.email
-
+	   SELECT ` + orderColumns + `
 	   From orders o
 	   JOIN customers c ON o.customer_id = c.id
-
+	   WHERE ` + notDeleted(ctx, "o") + `
 	`
 
 	rows , err := s.db.QueryContext(ctx,query)
 
 	if err != nil {
-		return nil , err
+		return nil , storeError("order", err)
 	}
+	defer rows.Close()
 
 	var orders []models.Order
 
 	for rows.Next(){
-		var order models.Order
-		err := rows.Scan(
-			&order.ID,
-			&order.TotalPrice,
-			&order.CreatedAt,
-			&order.Status,
-			&order.Customer.ID,
-			&order.Customer.Name,
-			&order.Customer.Email,
-
-		)
+		order, err := scanOrder(rows)
 
 		if err != nil {
-			return nil, err
+			return nil, storeError("order", err)
 		}
 
 		orders = append(orders, order)
 	}
 
-	return orders, nil
+	return orders, storeError("order", rows.Err())
 
 
-}
\ No newline at end of file
+}
+func (s *MySQLOrderStore) GetOrdersByCustomer(ctx context.Context, customerID int) ([]models.Order, error) {
+	query := `
+		SELECT ` + orderColumns + `
+		FROM orders o
+		JOIN customers c ON o.customer_id = c.id
+		WHERE o.customer_id = ? AND ` + notDeleted(ctx, "o") + `
+		ORDER BY o.created_at DESC
+	`
+
+	rows, err := s.db.QueryContext(ctx, query, customerID)
+	if err != nil {
+		return nil, storeError("order", err)
+	}
+	defer rows.Close()
+
+	var orders []models.Order
+	for rows.Next() {
+		order, err := scanOrder(rows)
+		if err != nil {
+			return nil, storeError("order", err)
+		}
+		orders = append(orders, order)
+	}
+
+	return orders, rows.Err()
+}
+
+// money is kept in DECIMAL(10,2) columns
+func roundCents(amount float64) float64 {
+	return math.Round(amount*100) / 100
+}
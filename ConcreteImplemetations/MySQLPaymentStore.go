@@ -0,0 +1,146 @@
+package concreteimplemetations
+
+import (
+	"context"
+	"database/sql"
+
+	"online_bookStore/models"
+)
+
+const paymentColumns = `id, order_id, amount, status, gateway, authorization_id, capture_id, refund_id,
+	gateway_error, created_at, updated_at`
+
+type MySQLPaymentStore struct {
+	db *sql.DB
+}
+
+// Constructor
+func NewMySQLPaymentStore(db *sql.DB) *MySQLPaymentStore {
+	return &MySQLPaymentStore{
+		db: db,
+	}
+}
+
+func scanPayment(row rowScanner) (models.Payment, error) {
+	var payment models.Payment
+	var authorizationID, captureID, refundID, gatewayError sql.NullString
+
+	err := row.Scan(
+		&payment.ID,
+		&payment.OrderID,
+		&payment.Amount,
+		&payment.Status,
+		&payment.Gateway,
+		&authorizationID,
+		&captureID,
+		&refundID,
+		&gatewayError,
+		&payment.CreatedAt,
+		&payment.UpdatedAt,
+	)
+	if err != nil {
+		return payment, err
+	}
+
+	payment.AuthorizationID = authorizationID.String
+	payment.CaptureID = captureID.String
+	payment.RefundID = refundID.String
+	payment.GatewayError = gatewayError.String
+	return payment, nil
+}
+
+// nullableString stores "" as NULL
+func nullableString(value string) interface{} {
+	if value == "" {
+		return nil
+	}
+	return value
+}
+
+// CreatePayment and UpdatePayment join the transaction carried by ctx
+func (s *MySQLPaymentStore) CreatePayment(ctx context.Context, payment models.Payment) (models.Payment, error) {
+	if payment.Status == "" {
+		payment.Status = models.PaymentStatusPending
+	}
+
+	query := `
+		INSERT INTO payments (order_id, amount, status, gateway, authorization_id, capture_id, refund_id, gateway_error)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?)
+	`
+
+	result, err := conn(ctx, s.db).ExecContext(ctx, query,
+		payment.OrderID,
+		payment.Amount,
+		payment.Status,
+		payment.Gateway,
+		nullableString(payment.AuthorizationID),
+		nullableString(payment.CaptureID),
+		nullableString(payment.RefundID),
+		nullableString(payment.GatewayError),
+	)
+	if err != nil {
+		return payment, storeError("payment", err)
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return payment, storeError("payment", err)
+	}
+
+	return s.GetPayment(ctx, int(id))
+}
+
+func (s *MySQLPaymentStore) UpdatePayment(ctx context.Context, payment models.Payment) (models.Payment, error) {
+	query := `
+		UPDATE payments
+		SET status = ?, authorization_id = ?, capture_id = ?, refund_id = ?, gateway_error = ?
+		WHERE id = ?
+	`
+
+	_, err := conn(ctx, s.db).ExecContext(ctx, query,
+		payment.Status,
+		nullableString(payment.AuthorizationID),
+		nullableString(payment.CaptureID),
+		nullableString(payment.RefundID),
+		nullableString(payment.GatewayError),
+		payment.ID,
+	)
+	if err != nil {
+		return payment, storeError("payment", err)
+	}
+
+	// sql.ErrNoRows when the payment does not exist
+	return s.GetPayment(ctx, payment.ID)
+}
+
+func (s *MySQLPaymentStore) GetPayment(ctx context.Context, id int) (models.Payment, error) {
+	query := `SELECT ` + paymentColumns + ` FROM payments WHERE id = ?`
+	payment, err := scanPayment(conn(ctx, s.db).QueryRowContext(ctx, query, id))
+	return payment, storeError("payment", err)
+}
+
+func (s *MySQLPaymentStore) GetPaymentsByOrder(ctx context.Context, orderID int) ([]models.Payment, error) {
+	query := `
+		SELECT ` + paymentColumns + `
+		FROM payments
+		WHERE order_id = ?
+		ORDER BY id
+	`
+
+	rows, err := conn(ctx, s.db).QueryContext(ctx, query, orderID)
+	if err != nil {
+		return nil, storeError("payment", err)
+	}
+	defer rows.Close()
+
+	payments := []models.Payment{}
+	for rows.Next() {
+		payment, err := scanPayment(rows)
+		if err != nil {
+			return nil, storeError("payment", err)
+		}
+		payments = append(payments, payment)
+	}
+
+	return payments, rows.Err()
+}
@@ -0,0 +1,405 @@
+package concreteimplemetations
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"sort"
+	"time"
+
+	"online_bookStore/models"
+)
+
+const returnColumns = `r.id, r.order_id, r.customer_id, r.status, r.reason, r.reviewed_by, r.review_note,
+	r.created_at, r.reviewed_at,
+	(SELECT COALESCE(SUM(f.amount), 0) FROM refunds f WHERE f.return_id = r.id)`
+
+type MySQLReturnStore struct {
+	db *sql.DB
+}
+
+// Constructor
+func NewMySQLReturnStore(db *sql.DB) *MySQLReturnStore {
+	return &MySQLReturnStore{
+		db: db,
+	}
+}
+
+func scanReturn(row rowScanner) (models.OrderReturn, error) {
+	var ret models.OrderReturn
+	var reviewedBy sql.NullInt64
+	var reviewNote sql.NullString
+	var reviewedAt sql.NullTime
+
+	err := row.Scan(
+		&ret.ID,
+		&ret.OrderID,
+		&ret.CustomerID,
+		&ret.Status,
+		&ret.Reason,
+		&reviewedBy,
+		&reviewNote,
+		&ret.CreatedAt,
+		&reviewedAt,
+		&ret.RefundedAmount,
+	)
+	if err != nil {
+		return ret, err
+	}
+
+	ret.ReviewedBy = int(reviewedBy.Int64)
+	ret.ReviewNote = reviewNote.String
+	if reviewedAt.Valid {
+		ret.ReviewedAt = &reviewedAt.Time
+	}
+	return ret, nil
+}
+
+// CreateReturn records a return for a delivered order. Items naming the
+// same order item are merged, and no order item can be returned more times
+// than it was bought, counting earlier returns that were not rejected.
+func (s *MySQLReturnStore) CreateReturn(ctx context.Context, ret models.OrderReturn) (models.OrderReturn, error) {
+	if len(ret.Items) == 0 {
+		return ret, models.NewValidationError(models.ErrInvalidReturn, "return", "at least one item is required",
+			models.FieldError{Field: "items", Message: "at least one item is required"})
+	}
+
+	wanted := make(map[int]int)
+	for i, item := range ret.Items {
+		if item.Quantity <= 0 {
+			return ret, models.NewValidationError(models.ErrInvalidQuantity, "return", fmt.Sprintf("order item %d", item.OrderItemID),
+				models.FieldError{Field: fmt.Sprintf("items[%d].quantity", i), Message: "must be positive"})
+		}
+		wanted[item.OrderItemID] += item.Quantity
+	}
+
+	var id int64
+	err := runInTx(ctx, s.db, func(tx *sql.Tx) error {
+		var customerID int
+		var status string
+		err := tx.QueryRowContext(ctx, "SELECT customer_id, status FROM orders WHERE id = ? AND deleted_at IS NULL"+forUpdate(s.db), ret.OrderID).Scan(&customerID, &status)
+		if err != nil {
+			return storeError("return", err)
+		}
+
+		// other customers' orders look like missing ones
+		if ret.CustomerID != 0 && ret.CustomerID != customerID {
+			return sql.ErrNoRows
+		}
+		ret.CustomerID = customerID
+
+		if models.NormalizeOrderStatus(status) != models.OrderStatusDelivered {
+			return fmt.Errorf("%w: order is %s", models.ErrOrderNotReturnable, status)
+		}
+
+		left, err := returnableQuantities(ctx, tx, ret.OrderID)
+		if err != nil {
+			return storeError("return", err)
+		}
+
+		orderItemIDs := make([]int, 0, len(wanted))
+		for orderItemID := range wanted {
+			orderItemIDs = append(orderItemIDs, orderItemID)
+		}
+		sort.Ints(orderItemIDs)
+
+		var items []models.ReturnItem
+		for _, orderItemID := range orderItemIDs {
+			item, ok := left[orderItemID]
+			if !ok {
+				return fmt.Errorf("%w: order item %d is not part of order %d", models.ErrInvalidReturn, orderItemID, ret.OrderID)
+			}
+			if wanted[orderItemID] > item.Quantity {
+				return fmt.Errorf("%w: only %d of order item %d can be returned", models.ErrInvalidReturn, item.Quantity, orderItemID)
+			}
+
+			item.Quantity = wanted[orderItemID]
+			items = append(items, item)
+		}
+
+		result, err := tx.ExecContext(ctx, `
+			INSERT INTO order_returns (order_id, customer_id, status, reason)
+			VALUES (?, ?, ?, ?)
+		`, ret.OrderID, ret.CustomerID, models.ReturnStatusRequested, ret.Reason)
+		if err != nil {
+			return storeError("return", err)
+		}
+
+		id, err = result.LastInsertId()
+		if err != nil {
+			return storeError("return", err)
+		}
+
+		for _, item := range items {
+			_, err := tx.ExecContext(ctx, `
+				INSERT INTO order_return_items (return_id, order_item_id, book_id, quantity, unit_price)
+				VALUES (?, ?, ?, ?, ?)
+			`, id, item.OrderItemID, item.BookID, item.Quantity, item.UnitPrice)
+			if err != nil {
+				return storeError("return", err)
+			}
+		}
+
+		return nil
+	})
+	if err != nil {
+		return ret, storeError("return", err)
+	}
+
+	return s.GetReturn(ctx, int(id))
+}
+
+// returnableQuantities lists the order's items with the copies not yet
+// claimed by a requested or approved return
+func returnableQuantities(ctx context.Context, tx *sql.Tx, orderID int) (map[int]models.ReturnItem, error) {
+	query := `
+		SELECT oi.id, oi.book_id, oi.unit_price,
+			oi.quantity - COALESCE((
+				SELECT SUM(ri.quantity)
+				FROM order_return_items ri
+				JOIN order_returns r ON ri.return_id = r.id
+				WHERE ri.order_item_id = oi.id AND r.status <> ?
+			), 0)
+		FROM order_items oi
+		WHERE oi.order_id = ?
+	`
+
+	rows, err := tx.QueryContext(ctx, query, models.ReturnStatusRejected, orderID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	left := make(map[int]models.ReturnItem)
+	for rows.Next() {
+		var item models.ReturnItem
+		if err := rows.Scan(&item.OrderItemID, &item.BookID, &item.UnitPrice, &item.Quantity); err != nil {
+			return nil, err
+		}
+		left[item.OrderItemID] = item
+	}
+
+	return left, rows.Err()
+}
+
+func (s *MySQLReturnStore) GetReturn(ctx context.Context, id int) (models.OrderReturn, error) {
+	query := `SELECT ` + returnColumns + ` FROM order_returns r WHERE r.id = ?`
+
+	ret, err := scanReturn(conn(ctx, s.db).QueryRowContext(ctx, query, id))
+	if err != nil {
+		return ret, storeError("return", err)
+	}
+
+	if err := s.loadReturnItems(ctx, &ret); err != nil {
+		return ret, storeError("return", err)
+	}
+	return ret, nil
+}
+
+func (s *MySQLReturnStore) loadReturnItems(ctx context.Context, ret *models.OrderReturn) error {
+	query := `
+		SELECT id, order_item_id, book_id, quantity, unit_price
+		FROM order_return_items
+		WHERE return_id = ?
+		ORDER BY id
+	`
+
+	rows, err := conn(ctx, s.db).QueryContext(ctx, query, ret.ID)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	ret.Items = []models.ReturnItem{}
+	for rows.Next() {
+		var item models.ReturnItem
+		err := rows.Scan(&item.ID, &item.OrderItemID, &item.BookID, &item.Quantity, &item.UnitPrice)
+		if err != nil {
+			return err
+		}
+		ret.Items = append(ret.Items, item)
+	}
+
+	return rows.Err()
+}
+
+func (s *MySQLReturnStore) GetReturns(ctx context.Context, customerID int, status string) ([]models.OrderReturn, error) {
+	query := `SELECT ` + returnColumns + ` FROM order_returns r WHERE 1 = 1`
+	var args []interface{}
+
+	if customerID != 0 {
+		query += " AND r.customer_id = ?"
+		args = append(args, customerID)
+	}
+	if status != "" {
+		query += " AND r.status = ?"
+		args = append(args, models.NormalizeReturnStatus(status))
+	}
+	query += " ORDER BY r.created_at DESC, r.id DESC"
+
+	rows, err := conn(ctx, s.db).QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, storeError("return", err)
+	}
+	defer rows.Close()
+
+	returns := []models.OrderReturn{}
+	for rows.Next() {
+		ret, err := scanReturn(rows)
+		if err != nil {
+			return nil, storeError("return", err)
+		}
+		returns = append(returns, ret)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, storeError("return", err)
+	}
+
+	for i := range returns {
+		if err := s.loadReturnItems(ctx, &returns[i]); err != nil {
+			return nil, storeError("return", err)
+		}
+	}
+
+	return returns, nil
+}
+
+// ReviewReturn joins the transaction carried by ctx so the refund can be
+// recorded with the decision
+func (s *MySQLReturnStore) ReviewReturn(ctx context.Context, id int, status string, reviewedBy int, note string) (models.OrderReturn, error) {
+	status = models.NormalizeReturnStatus(status)
+	if status != models.ReturnStatusApproved && status != models.ReturnStatusRejected {
+		message := fmt.Sprintf("must be %q or %q", models.ReturnStatusApproved, models.ReturnStatusRejected)
+		return models.OrderReturn{}, models.NewValidationError(models.ErrInvalidReturn, "return", "status "+message,
+			models.FieldError{Field: "status", Message: message})
+	}
+
+	err := runInTx(ctx, s.db, func(tx *sql.Tx) error {
+		var orderID int
+		err := tx.QueryRowContext(ctx, "SELECT order_id FROM order_returns WHERE id = ?", id).Scan(&orderID)
+		if err != nil {
+			return storeError("return", err)
+		}
+
+		// the order first, as CreateReturn does, then the return
+		err = tx.QueryRowContext(ctx, "SELECT id FROM orders WHERE id = ?"+forUpdate(s.db), orderID).Scan(&orderID)
+		if err != nil {
+			return storeError("return", err)
+		}
+
+		var current string
+		err = tx.QueryRowContext(ctx, "SELECT status FROM order_returns WHERE id = ?"+forUpdate(s.db), id).Scan(&current)
+		if err != nil {
+			return storeError("return", err)
+		}
+		if current != models.ReturnStatusRequested {
+			return fmt.Errorf("%w: return is %s", models.ErrReturnReviewed, current)
+		}
+
+		_, err = tx.ExecContext(ctx, `
+			UPDATE order_returns
+			SET status = ?, reviewed_by = ?, review_note = ?, reviewed_at = ?
+			WHERE id = ?
+		`, status, nullableID(reviewedBy), nullableString(note), time.Now(), id)
+		if err != nil {
+			return storeError("return", err)
+		}
+
+		if status != models.ReturnStatusApproved {
+			return nil
+		}
+
+		_, err = tx.ExecContext(ctx, `
+			UPDATE books
+			SET stock = stock + (
+				SELECT SUM(ri.quantity)
+				FROM order_return_items ri
+				WHERE ri.return_id = ? AND ri.book_id = books.id
+			)
+			WHERE id IN (SELECT book_id FROM order_return_items WHERE return_id = ?)
+		`, id, id)
+		return storeError("return", err)
+	})
+	if err != nil {
+		return models.OrderReturn{}, storeError("return", err)
+	}
+
+	return s.GetReturn(ctx, id)
+}
+
+func (s *MySQLReturnStore) CreateRefund(ctx context.Context, refund models.Refund) (models.Refund, error) {
+	result, err := conn(ctx, s.db).ExecContext(ctx, `
+		INSERT INTO refunds (order_id, return_id, payment_id, amount, merchandise, tax, shipping, gateway_refund_id)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?)
+	`, refund.OrderID, nullableID(refund.ReturnID), nullableID(refund.PaymentID),
+		refund.Amount, refund.Merchandise, refund.Tax, refund.Shipping, nullableString(refund.GatewayRefundID))
+	if err != nil {
+		return refund, storeError("refund", err)
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return refund, storeError("refund", err)
+	}
+
+	refund.ID = int(id)
+	refund.CreatedAt = time.Now()
+	return refund, nil
+}
+
+func (s *MySQLReturnStore) GetRefundedTotals(ctx context.Context, orderID int) (models.Refund, error) {
+	query := `
+		SELECT COALESCE(SUM(amount), 0), COALESCE(SUM(merchandise), 0), COALESCE(SUM(tax), 0), COALESCE(SUM(shipping), 0)
+		FROM refunds
+		WHERE order_id = ?
+	`
+
+	totals := models.Refund{OrderID: orderID}
+	err := conn(ctx, s.db).QueryRowContext(ctx, query, orderID).Scan(&totals.Amount, &totals.Merchandise, &totals.Tax, &totals.Shipping)
+	return totals, storeError("refund", err)
+}
+
+func (s *MySQLReturnStore) GetRefundsByDateRange(ctx context.Context, from time.Time, to time.Time) ([]models.Refund, error) {
+	query := `
+		SELECT id, order_id, return_id, payment_id, amount, merchandise, tax, shipping, gateway_refund_id, created_at
+		FROM refunds
+		WHERE created_at BETWEEN ? AND ?
+		ORDER BY created_at ASC
+	`
+
+	rows, err := conn(ctx, s.db).QueryContext(ctx, query, from, to)
+	if err != nil {
+		return nil, storeError("refund", err)
+	}
+	defer rows.Close()
+
+	var refunds []models.Refund
+	for rows.Next() {
+		var refund models.Refund
+		var returnID, paymentID sql.NullInt64
+		var gatewayRefundID sql.NullString
+
+		err := rows.Scan(
+			&refund.ID,
+			&refund.OrderID,
+			&returnID,
+			&paymentID,
+			&refund.Amount,
+			&refund.Merchandise,
+			&refund.Tax,
+			&refund.Shipping,
+			&gatewayRefundID,
+			&refund.CreatedAt,
+		)
+		if err != nil {
+			return nil, storeError("refund", err)
+		}
+
+		refund.ReturnID = int(returnID.Int64)
+		refund.PaymentID = int(paymentID.Int64)
+		refund.GatewayRefundID = gatewayRefundID.String
+		refunds = append(refunds, refund)
+	}
+
+	return refunds, rows.Err()
+}
@@ -0,0 +1,61 @@
+package concreteimplemetations
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"online_bookStore/models"
+)
+
+// MySQLShippingCalculator looks rates up in the shipping_rules table
+type MySQLShippingCalculator struct {
+	db *sql.DB
+}
+
+// Constructor
+func NewMySQLShippingCalculator(db *sql.DB) *MySQLShippingCalculator {
+	return &MySQLShippingCalculator{
+		db: db,
+	}
+}
+
+// CalculateShipping uses the most specific rule for the address, like
+// CalculateTax. Destinations without any rule cannot be shipped to.
+func (c *MySQLShippingCalculator) CalculateShipping(ctx context.Context, address models.Address, items []models.OrderItem, amount float64) (float64, error) {
+	query := `
+		SELECT id, country, COALESCE(state, ''), base_fee, per_item_fee, per_kg_fee,
+			COALESCE(free_shipping_threshold, 0)
+		FROM shipping_rules
+		WHERE (country = ? OR country = '*')
+		  AND (state IS NULL OR state = ?)
+		ORDER BY country = '*', state IS NULL
+		LIMIT 1
+	`
+
+	var rule models.ShippingRule
+	err := conn(ctx, c.db).QueryRowContext(ctx, query, address.Country, address.State).Scan(
+		&rule.ID,
+		&rule.Country,
+		&rule.State,
+		&rule.BaseFee,
+		&rule.PerItemFee,
+		&rule.PerKgFee,
+		&rule.FreeShippingThreshold,
+	)
+	if errors.Is(err, sql.ErrNoRows) {
+		return 0, fmt.Errorf("%w: %s", models.ErrNoShippingRate, address.Country)
+	}
+	if err != nil {
+		return 0, storeError("shipping rule", err)
+	}
+
+	units, weight := 0, 0
+	for _, item := range items {
+		units += item.Quantity
+		weight += item.Book.WeightGrams * item.Quantity
+	}
+
+	return rule.Cost(amount, units, weight), nil
+}
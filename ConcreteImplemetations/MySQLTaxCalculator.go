@@ -0,0 +1,50 @@
+package concreteimplemetations
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+
+	"online_bookStore/models"
+)
+
+// MySQLTaxCalculator looks rates up in the tax_rules table
+type MySQLTaxCalculator struct {
+	db *sql.DB
+}
+
+// Constructor
+func NewMySQLTaxCalculator(db *sql.DB) *MySQLTaxCalculator {
+	return &MySQLTaxCalculator{
+		db: db,
+	}
+}
+
+// CalculateTax uses the most specific rule for the address: country and
+// state, then country only, then the "*" fallback. No rule means no tax.
+func (c *MySQLTaxCalculator) CalculateTax(ctx context.Context, address models.Address, taxable float64) (float64, error) {
+	query := `
+		SELECT id, country, COALESCE(state, ''), rate
+		FROM tax_rules
+		WHERE (country = ? OR country = '*')
+		  AND (state IS NULL OR state = ?)
+		ORDER BY country = '*', state IS NULL
+		LIMIT 1
+	`
+
+	var rule models.TaxRule
+	err := conn(ctx, c.db).QueryRowContext(ctx, query, address.Country, address.State).Scan(
+		&rule.ID,
+		&rule.Country,
+		&rule.State,
+		&rule.Rate,
+	)
+	if errors.Is(err, sql.ErrNoRows) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, storeError("tax rule", err)
+	}
+
+	return rule.Tax(taxable), nil
+}
@@ -0,0 +1,68 @@
+package concreteimplemetations
+
+import (
+	"context"
+	"database/sql"
+)
+
+type txKey struct{}
+
+type MySQLTransactor struct {
+	db *sql.DB
+}
+
+// Constructor
+func NewMySQLTransactor(db *sql.DB) *MySQLTransactor {
+	return &MySQLTransactor{
+		db: db,
+	}
+}
+
+func (t *MySQLTransactor) WithinTransaction(ctx context.Context, fn func(ctx context.Context) error) error {
+	err := runInTx(ctx, t.db, func(tx *sql.Tx) error {
+		return fn(context.WithValue(ctx, txKey{}, tx))
+	})
+	return storeError("record", err)
+}
+
+// runInTx runs fn in the transaction carried by ctx, or in a new
+// transaction that is committed when fn succeeds
+func runInTx(ctx context.Context, db *sql.DB, fn func(tx *sql.Tx) error) error {
+	if tx, ok := ctx.Value(txKey{}).(*sql.Tx); ok {
+		return fn(tx)
+	}
+
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+
+	defer func() {
+		if p := recover(); p != nil {
+			tx.Rollback()
+			panic(p)
+		}
+	}()
+
+	if err := fn(tx); err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// querier is the part of *sql.DB and *sql.Tx the stores use
+type querier interface {
+	ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error)
+	QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error)
+	QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row
+}
+
+// conn returns the transaction carried by ctx, or db when there is none
+func conn(ctx context.Context, db *sql.DB) querier {
+	if tx, ok := ctx.Value(txKey{}).(*sql.Tx); ok {
+		return tx
+	}
+	return db
+}
@@ -4,12 +4,41 @@ import (
 	"context"
 	"database/sql"
 	"online_bookStore/models"
+	"time"
 )
 
 type MySQLUserStore struct {
 	db *sql.DB
 }
 
+const userColumns = "id, email, password, role, disabled, customer_id, created_at"
+
+// rowScanner is satisfied by both *sql.Row and *sql.Rows
+type rowScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+func scanUser(row rowScanner) (models.User, error) {
+	var user models.User
+	var customerID sql.NullInt64
+
+	err := row.Scan(
+		&user.ID,
+		&user.Email,
+		&user.Password,
+		&user.Role,
+		&user.Disabled,
+		&customerID,
+		&user.CreatedAt,
+	)
+	if err != nil {
+		return user, err
+	}
+
+	user.CustomerID = int(customerID.Int64)
+	return user, nil
+}
+
 func NewMySQLUserStore(db *sql.DB) *MySQLUserStore {
 	return &MySQLUserStore{db: db}
 }
@@ -21,24 +50,13 @@ func (s *MySQLUserStore) GetByEmail(
 ) (models.User, error) {
 
 	query := `
-		SELECT id, email, password, role
+		SELECT ` + userColumns + `
 		FROM users
 		WHERE email = ?
 	`
 
-	var user models.User
-	err := s.db.QueryRowContext(ctx, query, email).Scan(
-		&user.ID,
-		&user.Email,
-		&user.Password,
-		&user.Role,
-	)
-
-	if err != nil {
-		return user, err
-	}
-
-	return user, nil
+	user, err := scanUser(conn(ctx, s.db).QueryRowContext(ctx, query, email))
+	return user, storeError("user", err)
 }
 
 func (s *MySQLUserStore) GetByID(
@@ -47,54 +65,319 @@ func (s *MySQLUserStore) GetByID(
 ) (models.User, error) {
 
 	query := `
-		SELECT id, email, password, role
+		SELECT ` + userColumns + `
 		FROM users
 		WHERE id = ?
 	`
 
-	var user models.User
-	err := s.db.QueryRowContext(ctx, query, id).Scan(
-		&user.ID,
-		&user.Email,
-		&user.Password,
-		&user.Role,
-	)
-
-	if err != nil {
-		return user, err
-	}
-
-	return user, nil
+	user, err := scanUser(conn(ctx, s.db).QueryRowContext(ctx, query, id))
+	return user, storeError("user", err)
 }
 
+// CreateUser stores user.Password as given: callers must pass a hash
+// (see services.HashPassword), never the plaintext password.
 func (s *MySQLUserStore) CreateUser(
 	ctx context.Context,
 	user models.User,
 ) (models.User, error) {
 
 	query := `
-		INSERT INTO users (email, password, role)
-		VALUES (?, ?, ?)
+		INSERT INTO users (email, password, role, disabled, customer_id)
+		VALUES (?, ?, ?, ?, ?)
 	`
 
-	result, err := s.db.ExecContext(
+	result, err := conn(ctx, s.db).ExecContext(
 		ctx,
 		query,
 		user.Email,
 		user.Password,
 		user.Role,
+		user.Disabled,
+		nullableID(user.CustomerID),
 	)
 	if err != nil {
-		return user, err
+		return user, storeError("user", err)
 	}
 
 	id, err := result.LastInsertId()
 	if err != nil {
-		return user, err
+		return user, storeError("user", err)
 	}
 
-	user.ID = int(id)
-	return user, nil
+	return s.GetByID(ctx, int(id))
+}
+
+func (s *MySQLUserStore) GetAllUsers(ctx context.Context) ([]models.User, error) {
+	query := `
+		SELECT ` + userColumns + `
+		FROM users
+		ORDER BY id
+	`
+
+	rows, err := s.db.QueryContext(ctx, query)
+	if err != nil {
+		return nil, storeError("user", err)
+	}
+	defer rows.Close()
+
+	var users []models.User
+	for rows.Next() {
+		user, err := scanUser(rows)
+		if err != nil {
+			return nil, storeError("user", err)
+		}
+		users = append(users, user)
+	}
+
+	return users, rows.Err()
+}
+
+func (s *MySQLUserStore) UpdateRole(ctx context.Context, id int, role string) (models.User, error) {
+	query := `
+		UPDATE users
+		SET role = ?
+		WHERE id = ?
+	`
+
+	if err := s.execOne(ctx, query, role, id); err != nil {
+		return models.User{}, storeError("user", err)
+	}
+
+	return s.GetByID(ctx, id)
+}
+
+func (s *MySQLUserStore) SetDisabled(ctx context.Context, id int, disabled bool) (models.User, error) {
+	query := `
+		UPDATE users
+		SET disabled = ?
+		WHERE id = ?
+	`
+
+	if err := s.execOne(ctx, query, disabled, id); err != nil {
+		return models.User{}, storeError("user", err)
+	}
+
+	return s.GetByID(ctx, id)
+}
+
+// LinkCustomer associates the user with a customer record;
+// customerID 0 removes the association
+func (s *MySQLUserStore) LinkCustomer(ctx context.Context, id int, customerID int) (models.User, error) {
+	query := `
+		UPDATE users
+		SET customer_id = ?
+		WHERE id = ?
+	`
+
+	if err := s.execOne(ctx, query, nullableID(customerID), id); err != nil {
+		return models.User{}, storeError("user", err)
+	}
+
+	return s.GetByID(ctx, id)
+}
+
+func (s *MySQLUserStore) UpdatePassword(ctx context.Context, id int, passwordHash string) error {
+	query := `
+		UPDATE users
+		SET password = ?
+		WHERE id = ?
+	`
+
+	err := s.execOne(ctx, query, passwordHash, id)
+	return storeError("user", err)
+}
+
+// execOne runs an UPDATE that targets a single user and reports
+// sql.ErrNoRows when that user does not exist
+func (s *MySQLUserStore) execOne(ctx context.Context, query string, args ...interface{}) error {
+	result, err := conn(ctx, s.db).ExecContext(ctx, query, args...)
+	if err != nil {
+		return err
+	}
+
+	// MySQL reports 0 affected rows when the value is unchanged,
+	// so confirm the user exists before reporting not found
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rowsAffected == 0 {
+		id := args[len(args)-1]
+		var exists int
+		return conn(ctx, s.db).QueryRowContext(ctx, "SELECT 1 FROM users WHERE id = ?", id).Scan(&exists)
+	}
+
+	return nil
+}
+
+// nullableID maps the zero id to SQL NULL
+func nullableID(id int) interface{} {
+	if id == 0 {
+		return nil
+	}
+	return id
+}
+
+func (s *MySQLUserStore) CreateRefreshToken(ctx context.Context, token models.RefreshToken) (models.RefreshToken, error) {
+	query := `
+		INSERT INTO refresh_tokens (user_id, token_hash, family_id, expires_at)
+		VALUES (?, ?, ?, ?)
+	`
+
+	result, err := s.db.ExecContext(ctx, query, token.UserID, token.TokenHash, token.FamilyID, token.ExpiresAt)
+	if err != nil {
+		return token, storeError("token", err)
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return token, storeError("token", err)
+	}
+
+	token.ID = int(id)
+	return token, nil
+}
+
+func (s *MySQLUserStore) GetRefreshToken(ctx context.Context, tokenHash string) (models.RefreshToken, error) {
+	query := `
+		SELECT id, user_id, token_hash, family_id, expires_at, revoked_at, replaced_by, created_at
+		FROM refresh_tokens
+		WHERE token_hash = ?
+	`
+
+	var token models.RefreshToken
+	var revokedAt sql.NullTime
+	var replacedBy sql.NullInt64
+
+	err := s.db.QueryRowContext(ctx, query, tokenHash).Scan(
+		&token.ID,
+		&token.UserID,
+		&token.TokenHash,
+		&token.FamilyID,
+		&token.ExpiresAt,
+		&revokedAt,
+		&replacedBy,
+		&token.CreatedAt,
+	)
+	if err != nil {
+		return token, storeError("token", err)
+	}
+
+	if revokedAt.Valid {
+		token.RevokedAt = &revokedAt.Time
+	}
+	token.ReplacedBy = int(replacedBy.Int64)
+
+	return token, nil
+}
+
+// RotateRefreshToken revokes the old token and stores its replacement in
+// one transaction. It returns sql.ErrNoRows when the old token was already
+// revoked, which means it is being reused.
+func (s *MySQLUserStore) RotateRefreshToken(ctx context.Context, oldID int, next models.RefreshToken) (models.RefreshToken, error) {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return next, storeError("token", err)
+	}
+	defer tx.Rollback()
+
+	result, err := tx.ExecContext(ctx, `
+		UPDATE refresh_tokens
+		SET revoked_at = ?
+		WHERE id = ? AND revoked_at IS NULL
+	`, time.Now(), oldID)
+	if err != nil {
+		return next, storeError("token", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return next, storeError("token", err)
+	}
+	if rowsAffected == 0 {
+		return next, sql.ErrNoRows
+	}
+
+	result, err = tx.ExecContext(ctx, `
+		INSERT INTO refresh_tokens (user_id, token_hash, family_id, expires_at)
+		VALUES (?, ?, ?, ?)
+	`, next.UserID, next.TokenHash, next.FamilyID, next.ExpiresAt)
+	if err != nil {
+		return next, storeError("token", err)
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return next, storeError("token", err)
+	}
+	next.ID = int(id)
+
+	_, err = tx.ExecContext(ctx, `
+		UPDATE refresh_tokens
+		SET replaced_by = ?
+		WHERE id = ?
+	`, next.ID, oldID)
+	if err != nil {
+		return next, storeError("token", err)
+	}
+
+	return next, tx.Commit()
+}
+
+func (s *MySQLUserStore) RevokeRefreshTokenFamily(ctx context.Context, familyID string) error {
+	query := `
+		UPDATE refresh_tokens
+		SET revoked_at = ?
+		WHERE family_id = ? AND revoked_at IS NULL
+	`
+
+	_, err := s.db.ExecContext(ctx, query, time.Now(), familyID)
+	return storeError("token", err)
 }
 
+func (s *MySQLUserStore) RevokeUserRefreshTokens(ctx context.Context, userID int) error {
+	query := `
+		UPDATE refresh_tokens
+		SET revoked_at = ?
+		WHERE user_id = ? AND revoked_at IS NULL
+	`
+
+	_, err := s.db.ExecContext(ctx, query, time.Now(), userID)
+	return storeError("token", err)
+}
+
+func (s *MySQLUserStore) RevokeAccessToken(ctx context.Context, jti string, expiresAt time.Time) error {
+	query := insertIgnore(s.db) + ` INTO revoked_tokens (jti, expires_at)
+		VALUES (?, ?)
+	`
+
+	_, err := s.db.ExecContext(ctx, query, jti, expiresAt)
+	return storeError("token", err)
+}
 
+func (s *MySQLUserStore) IsAccessTokenRevoked(ctx context.Context, jti string) (bool, error) {
+	query := `
+		SELECT COUNT(*)
+		FROM revoked_tokens
+		WHERE jti = ?
+	`
+
+	var count int
+	if err := s.db.QueryRowContext(ctx, query, jti).Scan(&count); err != nil {
+		return false, storeError("token", err)
+	}
+
+	return count > 0, nil
+}
+
+// DeleteExpiredTokens purges refresh tokens and revocation entries that
+// can no longer be presented
+func (s *MySQLUserStore) DeleteExpiredTokens(ctx context.Context, before time.Time) error {
+	if _, err := s.db.ExecContext(ctx, "DELETE FROM refresh_tokens WHERE expires_at < ?", before); err != nil {
+		return storeError("token", err)
+	}
+
+	_, err := s.db.ExecContext(ctx, "DELETE FROM revoked_tokens WHERE expires_at < ?", before)
+	return storeError("token", err)
+}
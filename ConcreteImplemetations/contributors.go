@@ -0,0 +1,79 @@
+package concreteimplemetations
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+
+	"online_bookStore/models"
+)
+
+// bookContributors returns the contributors of a book in order, with
+// their author details
+func bookContributors(ctx context.Context, q querier, bookID int) ([]models.Contributor, error) {
+	query := `
+		SELECT a.id, a.first_name, a.last_name, a.bio, bc.role
+		FROM book_contributors bc
+		JOIN authors a ON a.id = bc.author_id
+		WHERE bc.book_id = ?
+		ORDER BY bc.position
+	`
+
+	rows, err := q.QueryContext(ctx, query, bookID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	contributors := []models.Contributor{}
+	for rows.Next() {
+		var c models.Contributor
+		if err := rows.Scan(&c.Author.ID, &c.Author.FirstName, &c.Author.LastName, &c.Author.Bio, &c.Role); err != nil {
+			return nil, err
+		}
+		contributors = append(contributors, c)
+	}
+
+	return contributors, rows.Err()
+}
+
+// checkContributorAuthors refuses contributors whose author is missing or
+// soft-deleted, which the foreign key alone lets through; the primary
+// author is always among them
+func checkContributorAuthors(ctx context.Context, tx *sql.Tx, contributors []models.Contributor) error {
+	for _, c := range contributors {
+		var exists int
+		err := tx.QueryRowContext(ctx, "SELECT 1 FROM authors WHERE id = ? AND deleted_at IS NULL", c.Author.ID).Scan(&exists)
+		if errors.Is(err, sql.ErrNoRows) {
+			return &models.ValidationError{
+				Entity:  "book",
+				Fields:  []models.FieldError{{Field: "author_id", Message: "refers to a record that does not exist"}},
+				Message: "author_id refers to a record that does not exist",
+			}
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// setBookContributors replaces the contributors of a book, keeping their
+// order; contributors come from models.NormalizeContributors
+func setBookContributors(ctx context.Context, tx *sql.Tx, bookID int, contributors []models.Contributor) error {
+	if _, err := tx.ExecContext(ctx, "DELETE FROM book_contributors WHERE book_id = ?", bookID); err != nil {
+		return err
+	}
+
+	query := `
+		INSERT INTO book_contributors (book_id, author_id, role, position)
+		VALUES (?, ?, ?, ?)
+	`
+	for position, c := range contributors {
+		if _, err := tx.ExecContext(ctx, query, bookID, c.Author.ID, c.Role, position); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,36 @@
+package concreteimplemetations
+
+import (
+	"database/sql"
+
+	"modernc.org/sqlite"
+)
+
+// The SQL stores run on MySQL and, for development and CI, on an embedded
+// SQLite database (see database.NewDBFromEnv). Both share the schema and
+// the queries; the helpers below spell the few statements whose syntax
+// differs between the two.
+
+// isSQLite reports whether db was opened with the SQLite driver
+func isSQLite(db *sql.DB) bool {
+	_, ok := db.Driver().(*sqlite.Driver)
+	return ok
+}
+
+// forUpdate ends a SELECT that locks the rows it reads until the
+// transaction ends. SQLite has no row locks: its transactions take the
+// database write lock when they begin, which serializes them instead.
+func forUpdate(db *sql.DB) string {
+	if isSQLite(db) {
+		return ""
+	}
+	return " FOR UPDATE"
+}
+
+// insertIgnore starts an INSERT that skips rows whose key already exists
+func insertIgnore(db *sql.DB) string {
+	if isSQLite(db) {
+		return "INSERT OR IGNORE"
+	}
+	return "INSERT IGNORE"
+}
@@ -0,0 +1,96 @@
+package concreteimplemetations
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"online_bookStore/Interfaces"
+	"online_bookStore/models"
+)
+
+// notDeleted is the WHERE condition hiding the soft-deleted rows of the
+// table (or alias) unless ctx asks for them
+func notDeleted(ctx context.Context, table string) string {
+	if interfaces.IncludeDeleted(ctx) {
+		return "TRUE"
+	}
+	return table + ".deleted_at IS NULL"
+}
+
+// softDelete stamps deleted_at on a live row; sql.ErrNoRows when there
+// is none with that id
+func softDelete(ctx context.Context, q querier, table string, id int) error {
+	result, err := q.ExecContext(ctx, "UPDATE "+table+" SET deleted_at = ? WHERE id = ? AND deleted_at IS NULL", time.Now(), id)
+	if err != nil {
+		return err
+	}
+	return requireRow(result)
+}
+
+// restoreDeleted clears deleted_at; sql.ErrNoRows when no deleted row has that id
+func restoreDeleted(ctx context.Context, q querier, table string, id int) error {
+	result, err := q.ExecContext(ctx, "UPDATE "+table+" SET deleted_at = NULL WHERE id = ? AND deleted_at IS NOT NULL", id)
+	if err != nil {
+		return err
+	}
+	return requireRow(result)
+}
+
+func requireRow(result sql.Result) error {
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rows == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
+
+// openOrder matches the orders (alias o) still being fulfilled:
+// models.OrderStatusPending, OrderStatusPaid and OrderStatusShipped
+const openOrder = "o.deleted_at IS NULL AND o.status IN ('pending', 'paid', 'shipped')"
+
+// dependentCheck counts the live rows of one kind referring to a record;
+// query takes the record id as its only argument
+type dependentCheck struct {
+	kind  string
+	query string
+}
+
+// guardedSoftDelete soft-deletes a live row of table unless the checks
+// find records still depending on it, which returns a
+// *models.DependentsError. force skips the checks and runs cascade, when
+// set, in the same transaction.
+func guardedSoftDelete(ctx context.Context, db *sql.DB, entity string, table string, id int, force bool, checks []dependentCheck, cascade func(tx *sql.Tx) error) error {
+	return runInTx(ctx, db, func(tx *sql.Tx) error {
+		var lockedID int
+		err := tx.QueryRowContext(ctx, "SELECT id FROM "+table+" WHERE id = ? AND deleted_at IS NULL"+forUpdate(db), id).Scan(&lockedID)
+		if err != nil {
+			return err
+		}
+
+		if !force {
+			var blockers []models.Blocker
+			for _, check := range checks {
+				var count int
+				if err := tx.QueryRowContext(ctx, check.query, id).Scan(&count); err != nil {
+					return err
+				}
+				if count > 0 {
+					blockers = append(blockers, models.Blocker{Type: check.kind, Count: count})
+				}
+			}
+			if len(blockers) > 0 {
+				return &models.DependentsError{Entity: entity, ID: id, Blockers: blockers}
+			}
+		} else if cascade != nil {
+			if err := cascade(tx); err != nil {
+				return err
+			}
+		}
+
+		return softDelete(ctx, tx, table, id)
+	})
+}
@@ -0,0 +1,164 @@
+package concreteimplemetations
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"net"
+	"regexp"
+	"strings"
+
+	"github.com/go-sql-driver/mysql"
+	"modernc.org/sqlite"
+	sqlite3 "modernc.org/sqlite/lib"
+
+	"online_bookStore/models"
+)
+
+// MySQL server error numbers the stores translate
+const (
+	mysqlDuplicateEntry    = 1062 // UNIQUE key violation
+	mysqlRowReferenced     = 1451 // deleting or updating a row other rows refer to
+	mysqlNoReferencedRow   = 1452 // foreign key pointing to a missing row
+	mysqlTooManyConnection = 1040
+	mysqlLockWaitTimeout   = 1205
+	mysqlDeadlock          = 1213
+)
+
+var (
+	duplicateKey = regexp.MustCompile(`for key '([^']+)'`)
+	foreignKey   = regexp.MustCompile("FOREIGN KEY \\(`([^`]+)`\\)")
+	uniqueColumn = regexp.MustCompile(`UNIQUE constraint failed: ([^ ,]+)`)
+)
+
+func isDuplicateEntry(err error) bool {
+	var mysqlErr *mysql.MySQLError
+	if errors.As(err, &mysqlErr) {
+		return mysqlErr.Number == mysqlDuplicateEntry
+	}
+	var sqliteErr *sqlite.Error
+	return errors.As(err, &sqliteErr) && sqliteErr.Code() == sqlite3.SQLITE_CONSTRAINT_UNIQUE
+}
+
+// storeError translates a driver error of a store working on entity into
+// the models error types: sql.ErrNoRows becomes *models.NotFoundError,
+// duplicate keys and rows still referenced *models.ConflictError, unknown
+// foreign keys *models.ValidationError, and lost connections, timeouts and
+// deadlocks *models.UnavailableError. Other errors, including the ones
+// already translated, are returned as they are.
+func storeError(entity string, err error) error {
+	if err == nil {
+		return nil
+	}
+
+	var (
+		notFound    *models.NotFoundError
+		conflict    *models.ConflictError
+		validation  *models.ValidationError
+		unavailable *models.UnavailableError
+	)
+	if errors.As(err, &notFound) || errors.As(err, &conflict) ||
+		errors.As(err, &validation) || errors.As(err, &unavailable) {
+		return err
+	}
+
+	if errors.Is(err, sql.ErrNoRows) {
+		return &models.NotFoundError{Entity: entity, Err: err}
+	}
+
+	var mysqlErr *mysql.MySQLError
+	if errors.As(err, &mysqlErr) {
+		switch mysqlErr.Number {
+		case mysqlDuplicateEntry:
+			field := keyField(mysqlErr.Message)
+			return &models.ConflictError{
+				Entity:  entity,
+				Field:   field,
+				Message: entity + " with this " + field + " already exists",
+				Err:     err,
+			}
+		case mysqlRowReferenced:
+			return &models.ConflictError{
+				Entity:  entity,
+				Message: entity + " is still referenced by other records",
+				Err:     err,
+			}
+		case mysqlNoReferencedRow:
+			field := "reference"
+			if m := foreignKey.FindStringSubmatch(mysqlErr.Message); m != nil {
+				field = m[1]
+			}
+			return &models.ValidationError{
+				Entity:  entity,
+				Fields:  []models.FieldError{{Field: field, Message: "refers to a record that does not exist"}},
+				Message: field + " refers to a record that does not exist",
+				Err:     err,
+			}
+		case mysqlTooManyConnection, mysqlLockWaitTimeout, mysqlDeadlock:
+			return &models.UnavailableError{Err: err}
+		}
+		return err
+	}
+
+	var sqliteErr *sqlite.Error
+	if errors.As(err, &sqliteErr) {
+		return sqliteError(entity, sqliteErr)
+	}
+
+	var netErr net.Error
+	if errors.Is(err, driver.ErrBadConn) || errors.Is(err, mysql.ErrInvalidConn) ||
+		errors.Is(err, sql.ErrConnDone) || errors.Is(err, context.DeadlineExceeded) ||
+		errors.As(err, &netErr) {
+		return &models.UnavailableError{Err: err}
+	}
+
+	return err
+}
+
+// keyField names the column of a duplicate key message such as
+// "Duplicate entry 'a@b.c' for key 'customers.email'"
+func keyField(message string) string {
+	m := duplicateKey.FindStringSubmatch(message)
+	if m == nil {
+		return "value"
+	}
+	key := m[1]
+	if i := strings.LastIndex(key, "."); i >= 0 {
+		key = key[i+1:]
+	}
+	return key
+}
+
+// sqliteError translates the SQLite errors matching the MySQL ones above.
+// SQLite does not name the column of a failed foreign key, and reports a
+// row still referenced the same way.
+func sqliteError(entity string, err *sqlite.Error) error {
+	switch err.Code() {
+	case sqlite3.SQLITE_CONSTRAINT_UNIQUE, sqlite3.SQLITE_CONSTRAINT_PRIMARYKEY:
+		field := "value"
+		if m := uniqueColumn.FindStringSubmatch(err.Error()); m != nil {
+			field = m[1][strings.LastIndex(m[1], ".")+1:]
+		}
+		return &models.ConflictError{
+			Entity:  entity,
+			Field:   field,
+			Message: entity + " with this " + field + " already exists",
+			Err:     err,
+		}
+	case sqlite3.SQLITE_CONSTRAINT_FOREIGNKEY:
+		return &models.ValidationError{
+			Entity:  entity,
+			Fields:  []models.FieldError{{Field: "reference", Message: "refers to a record that does not exist"}},
+			Message: "reference refers to a record that does not exist",
+			Err:     err,
+		}
+	}
+
+	// the primary result code, without the extended detail
+	switch err.Code() & 0xff {
+	case sqlite3.SQLITE_BUSY, sqlite3.SQLITE_LOCKED:
+		return &models.UnavailableError{Err: err}
+	}
+	return err
+}
@@ -12,98 +12,192 @@ INSERT INTO authors (first_name, last_name, bio) VALUES
 ('Hector', 'Vega', 'Writes travel and memoirs.');
 
 -- books
-INSERT INTO books (title, genres, published_at, price, stock, author_id) VALUES
-('The Quiet Shore', 'Fiction,Drama', '2019-05-14 00:00:00', 14.99, 42, 1),
-('Ethics of Machines', 'Technology,Non-Fiction', '2021-09-21 00:00:00', 29.50, 12, 2),
-('Ashes of the Crown', 'Historical,Mystery', '2018-02-01 00:00:00', 18.75, 7, 3),
-('Signals in the Dark', 'Sci-Fi,Thriller', '2023-11-03 00:00:00', 22.00, 19, 6),
-('Leading with Clarity', 'Business,Leadership', '2020-03-10 00:00:00', 24.00, 15, 4),
-('City of Paper', 'Poetry,Essay', '2017-08-19 00:00:00', 12.50, 30, 5),
-('Starlight Protocol', 'Sci-Fi', '2022-06-12 00:00:00', 19.99, 9, 6),
-('Tiny Atlas', 'Children,Adventure', '2016-04-22 00:00:00', 9.99, 50, 7),
-('Data Stories', 'Technology,Data', '2021-01-05 00:00:00', 27.00, 14, 8),
-('Winter Lines', 'YA,Fiction', '2019-12-02 00:00:00', 15.25, 18, 9),
-('Sunset Roads', 'Travel,Memoir', '2018-10-11 00:00:00', 21.40, 11, 10),
-('Glass Horizon', 'Sci-Fi,Drama', '2024-02-15 00:00:00', 23.60, 13, 6),
-('Team Metrics', 'Business,Data', '2020-07-07 00:00:00', 26.80, 10, 8),
-('Hidden Harbor', 'Mystery,Fiction', '2017-01-29 00:00:00', 16.90, 17, 3),
-('Bright Kite', 'Children,Picture Book', '2015-09-09 00:00:00', 8.75, 60, 7);
+INSERT INTO books (title, published_at, price, stock, weight_grams, author_id) VALUES
+('The Quiet Shore', '2019-05-14 00:00:00', 14.99, 42, 420, 1),
+('Ethics of Machines', '2021-09-21 00:00:00', 29.50, 12, 610, 2),
+('Ashes of the Crown', '2018-02-01 00:00:00', 18.75, 7, 480, 3),
+('Signals in the Dark', '2023-11-03 00:00:00', 22.00, 19, 390, 6),
+('Leading with Clarity', '2020-03-10 00:00:00', 24.00, 15, 530, 4),
+('City of Paper', '2017-08-19 00:00:00', 12.50, 30, 210, 5),
+('Starlight Protocol', '2022-06-12 00:00:00', 19.99, 9, 370, 6),
+('Tiny Atlas', '2016-04-22 00:00:00', 9.99, 50, 300, 7),
+('Data Stories', '2021-01-05 00:00:00', 27.00, 14, 560, 8),
+('Winter Lines', '2019-12-02 00:00:00', 15.25, 18, 400, 9),
+('Sunset Roads', '2018-10-11 00:00:00', 21.40, 11, 450, 10),
+('Glass Horizon', '2024-02-15 00:00:00', 23.60, 13, 440, 6),
+('Team Metrics', '2020-07-07 00:00:00', 26.80, 10, 520, 8),
+('Hidden Harbor', '2017-01-29 00:00:00', 16.90, 17, 380, 3),
+('Bright Kite', '2015-09-09 00:00:00', 8.75, 60, 260, 7);
 
--- addresses
-INSERT INTO addresses (street, city, state, postal_code, country) VALUES
-('1457 Maple Ave', 'Seattle', 'WA', '98109', 'USA'),
-('88 Pine Street', 'Boston', 'MA', '02108', 'USA'),
-('2100 Market St', 'San Francisco', 'CA', '94114', 'USA'),
-('19 River Lane', 'Austin', 'TX', '78701', 'USA'),
-('502 Oak Blvd', 'Denver', 'CO', '80202', 'USA'),
-('73 Hillcrest Rd', 'Portland', 'OR', '97205', 'USA'),
-('900 Lake Dr', 'Chicago', 'IL', '60611', 'USA'),
-('12 Rose Ct', 'Miami', 'FL', '33130', 'USA'),
-('600 Elm St', 'Raleigh', 'NC', '27601', 'USA'),
-('33 Sunset Ave', 'Phoenix', 'AZ', '85004', 'USA'),
-('410 Birch Pkwy', 'Nashville', 'TN', '37203', 'USA'),
-('5 Harbor Way', 'San Diego', 'CA', '92101', 'USA');
+-- genres
+INSERT INTO genres (name) VALUES
+('Adventure'),
+('Business'),
+('Children'),
+('Data'),
+('Drama'),
+('Essay'),
+('Fiction'),
+('Historical'),
+('Leadership'),
+('Memoir'),
+('Mystery'),
+('Non-Fiction'),
+('Picture Book'),
+('Poetry'),
+('Sci-Fi'),
+('Technology'),
+('Thriller'),
+('Travel'),
+('YA');
+
+-- book genres
+INSERT INTO book_genres (book_id, genre_id)
+SELECT bg.book_id, g.id
+FROM (
+    SELECT 1 AS book_id, 'Fiction' AS genre UNION ALL
+    SELECT 1, 'Drama' UNION ALL
+    SELECT 2, 'Technology' UNION ALL
+    SELECT 2, 'Non-Fiction' UNION ALL
+    SELECT 3, 'Historical' UNION ALL
+    SELECT 3, 'Mystery' UNION ALL
+    SELECT 4, 'Sci-Fi' UNION ALL
+    SELECT 4, 'Thriller' UNION ALL
+    SELECT 5, 'Business' UNION ALL
+    SELECT 5, 'Leadership' UNION ALL
+    SELECT 6, 'Poetry' UNION ALL
+    SELECT 6, 'Essay' UNION ALL
+    SELECT 7, 'Sci-Fi' UNION ALL
+    SELECT 8, 'Children' UNION ALL
+    SELECT 8, 'Adventure' UNION ALL
+    SELECT 9, 'Technology' UNION ALL
+    SELECT 9, 'Data' UNION ALL
+    SELECT 10, 'YA' UNION ALL
+    SELECT 10, 'Fiction' UNION ALL
+    SELECT 11, 'Travel' UNION ALL
+    SELECT 11, 'Memoir' UNION ALL
+    SELECT 12, 'Sci-Fi' UNION ALL
+    SELECT 12, 'Drama' UNION ALL
+    SELECT 13, 'Business' UNION ALL
+    SELECT 13, 'Data' UNION ALL
+    SELECT 14, 'Mystery' UNION ALL
+    SELECT 14, 'Fiction' UNION ALL
+    SELECT 15, 'Children' UNION ALL
+    SELECT 15, 'Picture Book'
+) bg
+JOIN genres g ON g.name = bg.genre;
+
+-- book contributors: each book's author comes first
+INSERT INTO book_contributors (book_id, author_id, role, position)
+SELECT id, author_id, 'author', 0 FROM books;
 
 -- customers
-INSERT INTO customers (name, email, address_id) VALUES
-('Caroline Reed', 'caroline.reed@example.com', 1),
-('Marcus Hill', 'marcus.hill@example.com', 2),
-('Aisha Patel', 'aisha.patel@example.com', 3),
-('Liam Chen', 'liam.chen@example.com', 4),
-('Sofia Alvarez', 'sofia.alvarez@example.com', 5),
-('Noah Bennett', 'noah.bennett@example.com', 6),
-('Ivy Sanders', 'ivy.sanders@example.com', 7),
-('Ethan Park', 'ethan.park@example.com', 8),
-('Zara Coleman', 'zara.coleman@example.com', 9),
-('Miguel Santos', 'miguel.santos@example.com', 10),
-('Olivia Grant', 'olivia.grant@example.com', 11),
-('Jackson Lee', 'jackson.lee@example.com', 12);
+INSERT INTO customers (name, email) VALUES
+('Caroline Reed', 'caroline.reed@example.com'),
+('Marcus Hill', 'marcus.hill@example.com'),
+('Aisha Patel', 'aisha.patel@example.com'),
+('Liam Chen', 'liam.chen@example.com'),
+('Sofia Alvarez', 'sofia.alvarez@example.com'),
+('Noah Bennett', 'noah.bennett@example.com'),
+('Ivy Sanders', 'ivy.sanders@example.com'),
+('Ethan Park', 'ethan.park@example.com'),
+('Zara Coleman', 'zara.coleman@example.com'),
+('Miguel Santos', 'miguel.santos@example.com'),
+('Olivia Grant', 'olivia.grant@example.com'),
+('Jackson Lee', 'jackson.lee@example.com');
+
+-- customer addresses
+INSERT INTO customer_addresses (customer_id, label, street, city, state, postal_code, country, is_default_shipping, is_default_billing) VALUES
+(1, 'home', '1457 Maple Ave', 'Seattle', 'WA', '98109', 'USA', TRUE, TRUE),
+(2, 'home', '88 Pine Street', 'Boston', 'MA', '02108', 'USA', TRUE, TRUE),
+(3, 'home', '2100 Market St', 'San Francisco', 'CA', '94114', 'USA', TRUE, TRUE),
+(4, 'home', '19 River Lane', 'Austin', 'TX', '78701', 'USA', TRUE, TRUE),
+(5, 'home', '502 Oak Blvd', 'Denver', 'CO', '80202', 'USA', TRUE, TRUE),
+(6, 'home', '73 Hillcrest Rd', 'Portland', 'OR', '97205', 'USA', TRUE, TRUE),
+(7, 'home', '900 Lake Dr', 'Chicago', 'IL', '60611', 'USA', TRUE, TRUE),
+(8, 'home', '12 Rose Ct', 'Miami', 'FL', '33130', 'USA', TRUE, TRUE),
+(9, 'home', '600 Elm St', 'Raleigh', 'NC', '27601', 'USA', TRUE, TRUE),
+(10, 'home', '33 Sunset Ave', 'Phoenix', 'AZ', '85004', 'USA', TRUE, TRUE),
+(11, 'home', '410 Birch Pkwy', 'Nashville', 'TN', '37203', 'USA', TRUE, TRUE),
+(12, 'home', '5 Harbor Way', 'San Diego', 'CA', '92101', 'USA', TRUE, TRUE),
+(1, 'work', '400 Fairview Ave N', 'Seattle', 'WA', '98109', 'USA', FALSE, FALSE);
+
+-- tax rules (country '*' = everywhere else)
+INSERT INTO tax_rules (country, state, rate) VALUES
+('USA', NULL, 0.0500),
+('USA', 'WA', 0.0650),
+('USA', 'CA', 0.0725),
+('USA', 'TX', 0.0625),
+('USA', 'OR', 0.0000);
+
+-- shipping rules
+INSERT INTO shipping_rules (country, state, base_fee, per_item_fee, per_kg_fee, free_shipping_threshold) VALUES
+('USA', NULL, 3.99, 0.50, 1.00, 50.00),
+('*', NULL, 12.99, 1.00, 4.00, NULL);
+
+-- coupons
+INSERT INTO coupons (code, type, value, buy_quantity, free_quantity, genre, min_order_value, usage_limit) VALUES
+('WELCOME10', 'percentage', 10.00, 0, 0, NULL, 0.00, 0),
+('SAVE5', 'fixed', 5.00, 0, 0, NULL, 30.00, 100),
+('FICTION3FOR2', 'buy_x_get_y', 0.00, 2, 1, 'Fiction', 0.00, 0);
 
 -- orders
-INSERT INTO orders (customer_id, total_price, status) VALUES
-(1, 29.98, 'PAID'),
-(2, 29.50, 'SHIPPED'),
-(3, 18.75, 'PENDING'),
-(4, 46.00, 'DELIVERED'),
-(5, 22.00, 'CANCELED'),
-(6, 34.99, 'PAID'),
-(7, 39.98, 'DELIVERED'),
-(8, 12.50, 'PAID'),
-(9, 43.80, 'SHIPPED'),
-(10, 21.40, 'DELIVERED'),
-(11, 24.00, 'PENDING'),
-(12, 32.15, 'PAID'),
-(1, 27.00, 'PAID'),
-(2, 16.90, 'DELIVERED'),
-(3, 9.99, 'PAID'),
-(4, 52.75, 'SHIPPED'),
-(5, 23.60, 'PAID'),
-(6, 26.80, 'DELIVERED');
+INSERT INTO orders (customer_id, subtotal, total_price, status) VALUES
+(1, 29.98, 29.98, 'paid'),
+(2, 29.50, 29.50, 'shipped'),
+(3, 18.75, 18.75, 'pending'),
+(4, 46.00, 46.00, 'delivered'),
+(5, 22.00, 22.00, 'cancelled'),
+(6, 34.99, 34.99, 'paid'),
+(7, 39.98, 39.98, 'delivered'),
+(8, 12.50, 12.50, 'paid'),
+(9, 43.80, 43.80, 'shipped'),
+(10, 21.40, 21.40, 'delivered'),
+(11, 24.00, 24.00, 'pending'),
+(12, 32.15, 32.15, 'paid'),
+(1, 27.00, 27.00, 'paid'),
+(2, 16.90, 16.90, 'delivered'),
+(3, 9.99, 9.99, 'paid'),
+(4, 52.75, 52.75, 'shipped'),
+(5, 23.60, 23.60, 'paid'),
+(6, 26.80, 26.80, 'delivered');
 
 -- order_items
-INSERT INTO order_items (order_id, book_id, quantity) VALUES
-(1, 1, 2),    -- 2 x 14.99 = 29.98
-(2, 2, 1),    -- 29.50
-(3, 3, 1),    -- 18.75
-(4, 5, 1),    -- 24.00
-(4, 8, 1),    -- 9.99
-(4, 15, 1),   -- 8.75  total 42.74? wait
-(5, 4, 1),    -- 22.00
-(6, 7, 1),    -- 19.99
-(6, 1, 1),    -- 14.99  total 34.98
-(7, 12, 1),   -- 23.60
-(7, 14, 1),   -- 16.90  total 40.50
-(8, 6, 1),    -- 12.50
-(9, 13, 1),   -- 26.80
-(9, 10, 1),   -- 15.25  total 42.05
-(10, 11, 1),  -- 21.40
-(11, 5, 1),   -- 24.00
-(12, 1, 1),   -- 14.99
-(12, 10, 1),  -- 15.25  total 30.24
-(13, 9, 1),   -- 27.00
-(14, 14, 1),  -- 16.90
-(15, 8, 1),   -- 9.99
-(16, 3, 1),   -- 18.75
-(16, 2, 1),   -- 29.50  total 48.25
-(17, 12, 1),  -- 23.60
-(18, 13, 1);  -- 26.80
+INSERT INTO order_items (order_id, book_id, quantity, unit_price) VALUES
+(1, 1, 2, 14.99),    -- 2 x 14.99 = 29.98
+(2, 2, 1, 29.50),    -- 29.50
+(3, 3, 1, 18.75),    -- 18.75
+(4, 5, 1, 24.00),    -- 24.00
+(4, 8, 1, 9.99),    -- 9.99
+(4, 15, 1, 8.75),   -- 8.75  total 42.74? wait
+(5, 4, 1, 22.00),    -- 22.00
+(6, 7, 1, 19.99),    -- 19.99
+(6, 1, 1, 14.99),    -- 14.99  total 34.98
+(7, 12, 1, 23.60),   -- 23.60
+(7, 14, 1, 16.90),   -- 16.90  total 40.50
+(8, 6, 1, 12.50),    -- 12.50
+(9, 13, 1, 26.80),   -- 26.80
+(9, 10, 1, 15.25),   -- 15.25  total 42.05
+(10, 11, 1, 21.40),  -- 21.40
+(11, 5, 1, 24.00),   -- 24.00
+(12, 1, 1, 14.99),   -- 14.99
+(12, 10, 1, 15.25),  -- 15.25  total 30.24
+(13, 9, 1, 27.00),   -- 27.00
+(14, 14, 1, 16.90),  -- 16.90
+(15, 8, 1, 9.99),   -- 9.99
+(16, 3, 1, 18.75),   -- 18.75
+(16, 2, 1, 29.50),   -- 29.50  total 48.25
+(17, 12, 1, 23.60),  -- 23.60
+(18, 13, 1, 26.80);  -- 26.80
+
+-- order address snapshots (seed orders shipped to the customer's address)
+INSERT INTO order_addresses (order_id, kind, street, city, state, postal_code, country)
+SELECT o.id, k.kind, a.street, a.city, a.state, a.postal_code, a.country
+FROM orders o
+JOIN customer_addresses a ON a.customer_id = o.customer_id AND a.is_default_shipping
+CROSS JOIN (SELECT 'shipping' AS kind UNION ALL SELECT 'billing') k;
+
+-- users (bcrypt hashed; admin password: Admin1234, shopper password: Shopper123)
+INSERT INTO users (email, password, role, customer_id) VALUES
+('admin@example.com', '$2a$10$ui.e51beQKKbHhboG1F7Pur7X43.TfbJWmPRyE0oty4ijI0p8K612', 'admin', NULL),
+('caroline.reed@example.com', '$2a$10$r2qTF3ZNji/.r8GfPxUiYOeZmF7Mq0738uzjN7rp1Pz1rg6Ha41zq', 'user', 1);
@@ -2,15 +2,35 @@ package database
 
 
 import (
+	"context"
 	"database/sql"
+	_ "embed"
 	"fmt"
 	"log"
 	"os"
 
 	_ "github.com/go-sql-driver/mysql"
+	"modernc.org/sqlite"
 )
 
-func NewMySQLDBFromEnv() *sql.DB {
+//go:embed data.sql
+var sampleData string
+
+// NewDBFromEnv connects to the database DB_DRIVER names: "mysql" (the
+// default) or "sqlite", an embedded database for development and CI
+func NewDBFromEnv() *sql.DB {
+	switch driver := os.Getenv("DB_DRIVER"); driver {
+	case "", "mysql":
+		return newMySQLDBFromEnv()
+	case "sqlite":
+		return newSQLiteDBFromEnv()
+	default:
+		log.Fatalf("unknown DB_DRIVER %q (want mysql or sqlite)", driver)
+		return nil
+	}
+}
+
+func newMySQLDBFromEnv() *sql.DB {
 	user := os.Getenv("DB_USER")
 	password := os.Getenv("DB_PASSWORD")
 	host := os.Getenv("DB_HOST")
@@ -37,3 +57,46 @@ func NewMySQLDBFromEnv() *sql.DB {
 
 	return db
 }
+
+// newSQLiteDBFromEnv opens the SQLite file DB_PATH (bookstore.db by
+// default). A new file is migrated and gets the sample data.
+func newSQLiteDBFromEnv() *sql.DB {
+	path := os.Getenv("DB_PATH")
+	if path == "" {
+		path = "bookstore.db"
+	}
+
+	// SQLite leaves foreign keys off unless asked; immediate transactions
+	// take the write lock when they begin, standing in for MySQL's row locks
+	dsn := "file:" + path +
+		"?_pragma=foreign_keys(1)&_pragma=busy_timeout(5000)&_pragma=journal_mode(WAL)" +
+		"&_txlock=immediate&_time_format=sqlite"
+
+	db := sql.OpenDB(utcConnector{dsn: dsn, driver: &sqlite.Driver{}})
+
+	if err := db.Ping(); err != nil {
+		log.Fatal("failed to connect to database:", err)
+	}
+
+	var tables int
+	if err := db.QueryRow("SELECT COUNT(*) FROM sqlite_master WHERE type = 'table'").Scan(&tables); err != nil {
+		log.Fatal("failed to read database schema:", err)
+	}
+
+	if tables == 0 {
+		applied, err := Migrate(context.Background(), db)
+		if err != nil {
+			log.Fatal("failed to create database schema:", err)
+		}
+
+		// another instance may have created the schema meanwhile
+		if len(applied) > 0 && applied[0].Version == 1 {
+			if _, err := db.Exec(sampleData); err != nil {
+				log.Fatal("failed to load sample data:", err)
+			}
+			log.Printf("Created SQLite database %s with the sample data", path)
+		}
+	}
+
+	return db
+}
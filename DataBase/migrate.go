@@ -0,0 +1,365 @@
+package database
+
+import (
+	"context"
+	"crypto/sha256"
+	"database/sql"
+	"embed"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io/fs"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"modernc.org/sqlite"
+)
+
+// migrationFiles holds migrations/<dialect>/NNNN_name.up.sql and
+// NNNN_name.down.sql. New versions go to both MySQL and SQLite; 0002 to
+// 0016 are MySQL only, as they bring databases built from an older
+// schema.sql up to the schema SQLite support started with.
+// Applied versions are recorded in schema_migrations with the checksum of
+// their up file, so an edited migration is caught instead of skipped.
+//
+//go:embed migrations
+var migrationFiles embed.FS
+
+// migrationLock names the MySQL advisory lock held while migrating, so
+// instances starting together apply each migration once
+const migrationLock = "online_bookstore.schema_migrations"
+
+// Migration is one schema version, read from its up and down files
+type Migration struct {
+	Version  int
+	Name     string
+	Up       string
+	Down     string
+	Checksum string
+}
+
+// MigrationState is a known migration and when it was applied, if it was
+type MigrationState struct {
+	Version   int
+	Name      string
+	AppliedAt *time.Time
+}
+
+// querier is what migrations run on: a connection holding the MySQL lock
+// or a SQLite transaction
+type querier interface {
+	ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error)
+	QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error)
+}
+
+func isSQLite(db *sql.DB) bool {
+	_, ok := db.Driver().(*sqlite.Driver)
+	return ok
+}
+
+// Migrations returns the migrations for db's dialect, oldest first
+func Migrations(db *sql.DB) ([]Migration, error) {
+	dir := "migrations/mysql"
+	if isSQLite(db) {
+		dir = "migrations/sqlite"
+	}
+
+	entries, err := fs.ReadDir(migrationFiles, dir)
+	if err != nil {
+		return nil, err
+	}
+
+	byVersion := make(map[int]*Migration)
+	for _, entry := range entries {
+		name, direction, ok := strings.Cut(strings.TrimSuffix(entry.Name(), ".sql"), ".")
+		if !ok || (direction != "up" && direction != "down") {
+			return nil, fmt.Errorf("migration file %s: want NNNN_name.up.sql or NNNN_name.down.sql", entry.Name())
+		}
+
+		number, label, _ := strings.Cut(name, "_")
+		version, err := strconv.Atoi(number)
+		if err != nil || version <= 0 {
+			return nil, fmt.Errorf("migration file %s: invalid version %q", entry.Name(), number)
+		}
+
+		content, err := fs.ReadFile(migrationFiles, path.Join(dir, entry.Name()))
+		if err != nil {
+			return nil, err
+		}
+
+		m, ok := byVersion[version]
+		if !ok {
+			m = &Migration{Version: version, Name: label}
+			byVersion[version] = m
+		}
+		if m.Name != label {
+			return nil, fmt.Errorf("migration %d has files named %q and %q", version, m.Name, label)
+		}
+
+		if direction == "up" {
+			m.Up = string(content)
+			sum := sha256.Sum256(content)
+			m.Checksum = hex.EncodeToString(sum[:])
+		} else {
+			m.Down = string(content)
+		}
+	}
+
+	migrations := make([]Migration, 0, len(byVersion))
+	for _, m := range byVersion {
+		if m.Up == "" {
+			return nil, fmt.Errorf("migration %d (%s) has no up file", m.Version, m.Name)
+		}
+		migrations = append(migrations, *m)
+	}
+	sort.Slice(migrations, func(i, j int) bool {
+		return migrations[i].Version < migrations[j].Version
+	})
+
+	return migrations, nil
+}
+
+// Migrate applies every pending migration, oldest first. It refuses to run
+// when an applied migration was edited or is unknown to this build.
+func Migrate(ctx context.Context, db *sql.DB) ([]Migration, error) {
+	migrations, err := Migrations(db)
+	if err != nil {
+		return nil, err
+	}
+
+	var applied []Migration
+	err = withMigrationLock(ctx, db, func(q querier) error {
+		done, err := appliedMigrations(ctx, q, migrations)
+		if err != nil {
+			return err
+		}
+
+		for _, m := range migrations {
+			if _, ok := done[m.Version]; ok {
+				continue
+			}
+
+			if err := runScript(ctx, q, db, m.Up); err != nil {
+				return fmt.Errorf("migration %d (%s): %w", m.Version, m.Name, err)
+			}
+
+			_, err := q.ExecContext(ctx,
+				"INSERT INTO schema_migrations (version, name, checksum, applied_at) VALUES (?, ?, ?, ?)",
+				m.Version, m.Name, m.Checksum, time.Now().UTC(),
+			)
+			if err != nil {
+				return fmt.Errorf("recording migration %d (%s): %w", m.Version, m.Name, err)
+			}
+
+			applied = append(applied, m)
+		}
+		return nil
+	})
+
+	return applied, err
+}
+
+// MigrateDown reverts the last steps applied migrations, newest first
+func MigrateDown(ctx context.Context, db *sql.DB, steps int) ([]Migration, error) {
+	migrations, err := Migrations(db)
+	if err != nil {
+		return nil, err
+	}
+
+	var reverted []Migration
+	err = withMigrationLock(ctx, db, func(q querier) error {
+		done, err := appliedMigrations(ctx, q, migrations)
+		if err != nil {
+			return err
+		}
+
+		for i := len(migrations) - 1; i >= 0 && len(reverted) < steps; i-- {
+			m := migrations[i]
+			if _, ok := done[m.Version]; !ok {
+				continue
+			}
+			if m.Down == "" {
+				return fmt.Errorf("migration %d (%s) has no down file", m.Version, m.Name)
+			}
+
+			if err := runScript(ctx, q, db, m.Down); err != nil {
+				return fmt.Errorf("reverting migration %d (%s): %w", m.Version, m.Name, err)
+			}
+
+			if _, err := q.ExecContext(ctx, "DELETE FROM schema_migrations WHERE version = ?", m.Version); err != nil {
+				return fmt.Errorf("recording revert of migration %d (%s): %w", m.Version, m.Name, err)
+			}
+
+			reverted = append(reverted, m)
+		}
+		return nil
+	})
+
+	return reverted, err
+}
+
+// MigrationStatus lists every known migration with its applied time
+func MigrationStatus(ctx context.Context, db *sql.DB) ([]MigrationState, error) {
+	migrations, err := Migrations(db)
+	if err != nil {
+		return nil, err
+	}
+
+	var states []MigrationState
+	err = withMigrationLock(ctx, db, func(q querier) error {
+		done, err := appliedMigrations(ctx, q, migrations)
+		if err != nil {
+			return err
+		}
+
+		for _, m := range migrations {
+			state := MigrationState{Version: m.Version, Name: m.Name}
+			if appliedAt, ok := done[m.Version]; ok {
+				state.AppliedAt = &appliedAt
+			}
+			states = append(states, state)
+		}
+		return nil
+	})
+
+	return states, err
+}
+
+// withMigrationLock runs fn so that no other instance migrates meanwhile:
+// under a MySQL advisory lock (DDL commits implicitly there, so there is
+// nothing to roll back), or inside one SQLite write transaction, which
+// also undoes a failed migration
+func withMigrationLock(ctx context.Context, db *sql.DB, fn func(q querier) error) error {
+	if isSQLite(db) {
+		// _txlock=immediate: the write lock is taken as the transaction begins
+		tx, err := db.BeginTx(ctx, nil)
+		if err != nil {
+			return err
+		}
+		defer tx.Rollback()
+
+		if err := fn(tx); err != nil {
+			return err
+		}
+		return tx.Commit()
+	}
+
+	// the lock belongs to the session, so everything runs on one connection
+	conn, err := db.Conn(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	var locked sql.NullInt64
+	if err := conn.QueryRowContext(ctx, "SELECT GET_LOCK(?, 60)", migrationLock).Scan(&locked); err != nil {
+		return err
+	}
+	if !locked.Valid || locked.Int64 != 1 {
+		return errors.New("timed out waiting for another instance to finish migrating")
+	}
+	defer conn.ExecContext(context.Background(), "SELECT RELEASE_LOCK(?)", migrationLock)
+
+	return fn(conn)
+}
+
+// appliedMigrations creates schema_migrations if needed and returns when
+// each recorded version was applied, checking it against the known ones
+func appliedMigrations(ctx context.Context, q querier, migrations []Migration) (map[int]time.Time, error) {
+	_, err := q.ExecContext(ctx, `
+		CREATE TABLE IF NOT EXISTS schema_migrations (
+			version INT PRIMARY KEY,
+			name VARCHAR(255) NOT NULL,
+			checksum CHAR(64) NOT NULL,
+			applied_at DATETIME NOT NULL
+		)
+	`)
+	if err != nil {
+		return nil, err
+	}
+
+	known := make(map[int]Migration, len(migrations))
+	for _, m := range migrations {
+		known[m.Version] = m
+	}
+
+	rows, err := q.QueryContext(ctx, "SELECT version, name, checksum, applied_at FROM schema_migrations ORDER BY version")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	done := make(map[int]time.Time)
+	for rows.Next() {
+		var (
+			version   int
+			name      string
+			checksum  string
+			appliedAt time.Time
+		)
+		if err := rows.Scan(&version, &name, &checksum, &appliedAt); err != nil {
+			return nil, err
+		}
+
+		m, ok := known[version]
+		if !ok {
+			return nil, fmt.Errorf("migration %d (%s) is applied but unknown to this build", version, name)
+		}
+		if m.Checksum != checksum {
+			return nil, fmt.Errorf("migration %d (%s) was changed after it was applied; add a new migration instead", version, name)
+		}
+
+		done[version] = appliedAt
+	}
+
+	return done, rows.Err()
+}
+
+// runScript executes a migration file. SQLite takes the whole script at
+// once; the MySQL driver only runs one statement per call.
+func runScript(ctx context.Context, q querier, db *sql.DB, script string) error {
+	if isSQLite(db) {
+		_, err := q.ExecContext(ctx, script)
+		return err
+	}
+
+	for _, stmt := range statements(script) {
+		if _, err := q.ExecContext(ctx, stmt); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// statements splits a MySQL script on the semicolons that end a line,
+// dropping comment-only lines; migrations must not put one mid-line
+func statements(script string) []string {
+	var (
+		stmts   []string
+		current strings.Builder
+	)
+
+	for _, line := range strings.Split(script, "\n") {
+		trimmed := strings.TrimSpace(line)
+		if trimmed == "" || strings.HasPrefix(trimmed, "--") {
+			continue
+		}
+
+		current.WriteString(line)
+		current.WriteString("\n")
+
+		if strings.HasSuffix(trimmed, ";") {
+			stmts = append(stmts, strings.TrimSuffix(strings.TrimSpace(current.String()), ";"))
+			current.Reset()
+		}
+	}
+
+	if rest := strings.TrimSpace(current.String()); rest != "" {
+		stmts = append(stmts, rest)
+	}
+
+	return stmts
+}
@@ -0,0 +1,9 @@
+-- Drops every table of the initial schema, dependents first.
+
+DROP TABLE IF EXISTS users;
+DROP TABLE IF EXISTS order_items;
+DROP TABLE IF EXISTS orders;
+DROP TABLE IF EXISTS customers;
+DROP TABLE IF EXISTS addresses;
+DROP TABLE IF EXISTS books;
+DROP TABLE IF EXISTS authors;
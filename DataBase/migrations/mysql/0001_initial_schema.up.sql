@@ -1,4 +1,9 @@
-CREATE TABLE authors (
+-- The schema first published as DataBase/schema.sql. IF NOT EXISTS lets a
+-- database created by hand from schema.sql adopt it, whichever version of
+-- the file it came from: the migrations after this one look up what such
+-- a database already has and only add what is missing.
+
+CREATE TABLE IF NOT EXISTS authors (
     id INT AUTO_INCREMENT PRIMARY KEY,
     first_name VARCHAR(100) NOT NULL,
     last_name VARCHAR(100) NOT NULL,
@@ -6,7 +11,7 @@ CREATE TABLE authors (
 );
 
  
-CREATE TABLE books (
+CREATE TABLE IF NOT EXISTS books (
     id INT AUTO_INCREMENT PRIMARY KEY,
     title VARCHAR(255) NOT NULL,
     genres TEXT,
@@ -23,7 +28,7 @@ CREATE TABLE books (
 
 
 
-CREATE TABLE addresses (
+CREATE TABLE IF NOT EXISTS addresses (
     id INT AUTO_INCREMENT PRIMARY KEY,
     street VARCHAR(255) NOT NULL,
     city VARCHAR(100) NOT NULL,
@@ -37,7 +42,7 @@ CREATE TABLE addresses (
 );
 
 
-CREATE TABLE customers (
+CREATE TABLE IF NOT EXISTS customers (
     id INT AUTO_INCREMENT PRIMARY KEY,
     name VARCHAR(150) NOT NULL,
     email VARCHAR(150) NOT NULL UNIQUE,
@@ -52,7 +57,7 @@ CREATE TABLE customers (
 
 
 
-CREATE TABLE orders (
+CREATE TABLE IF NOT EXISTS orders (
     id INT AUTO_INCREMENT PRIMARY KEY,
     customer_id INT NOT NULL,
     total_price DECIMAL(10, 2) NOT NULL,
@@ -66,7 +71,7 @@ CREATE TABLE orders (
 );
 
 
-CREATE TABLE order_items (
+CREATE TABLE IF NOT EXISTS order_items (
     id INT AUTO_INCREMENT PRIMARY KEY,
     order_id INT NOT NULL,
     book_id INT NOT NULL,
@@ -83,7 +88,7 @@ CREATE TABLE order_items (
         ON DELETE CASCADE
 );
 
-CREATE TABLE users (
+CREATE TABLE IF NOT EXISTS users (
     id INT AUTO_INCREMENT PRIMARY KEY,
     email VARCHAR(150) UNIQUE NOT NULL,
     password VARCHAR(255) NOT NULL,
@@ -0,0 +1,19 @@
+-- Users can be disabled, and record when they registered.
+--
+-- MySQL has no ADD COLUMN IF NOT EXISTS: @missing looks the column up, and
+-- the ALTER runs as a prepared statement that is a no-op (DO 0) when a
+-- database built from a later schema.sql has it already.
+
+SET @missing = (
+    SELECT COUNT(*) = 0 FROM information_schema.columns
+    WHERE table_schema = DATABASE() AND table_name = 'users' AND column_name = 'disabled'
+);
+SET @ddl = IF(@missing,
+    'ALTER TABLE users
+        ADD COLUMN disabled BOOLEAN NOT NULL DEFAULT FALSE,
+        ADD COLUMN created_at DATETIME DEFAULT CURRENT_TIMESTAMP',
+    'DO 0'
+);
+PREPARE ddl FROM @ddl;
+EXECUTE ddl;
+DEALLOCATE PREPARE ddl;
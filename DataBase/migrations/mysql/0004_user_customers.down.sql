@@ -0,0 +1,2 @@
+ALTER TABLE users DROP FOREIGN KEY fk_users_customer;
+ALTER TABLE users DROP COLUMN customer_id;
@@ -0,0 +1,18 @@
+-- A user account can own one customer profile.
+
+SET @missing = (
+    SELECT COUNT(*) = 0 FROM information_schema.columns
+    WHERE table_schema = DATABASE() AND table_name = 'users' AND column_name = 'customer_id'
+);
+SET @ddl = IF(@missing,
+    'ALTER TABLE users
+        ADD COLUMN customer_id INT NULL UNIQUE AFTER disabled,
+        ADD CONSTRAINT fk_users_customer
+            FOREIGN KEY (customer_id)
+            REFERENCES customers(id)
+            ON DELETE SET NULL',
+    'DO 0'
+);
+PREPARE ddl FROM @ddl;
+EXECUTE ddl;
+DEALLOCATE PREPARE ddl;
@@ -0,0 +1 @@
+ALTER TABLE order_items DROP COLUMN unit_price;
@@ -0,0 +1,20 @@
+-- Order items keep the unit price they were sold at. Items ordered before
+-- the column existed take the book's price at upgrade time, the best
+-- record there is of what they cost.
+
+SET @missing = (
+    SELECT COUNT(*) = 0 FROM information_schema.columns
+    WHERE table_schema = DATABASE() AND table_name = 'order_items' AND column_name = 'unit_price'
+);
+SET @ddl = IF(@missing,
+    'ALTER TABLE order_items ADD COLUMN unit_price DECIMAL(10, 2) NOT NULL AFTER quantity',
+    'DO 0'
+);
+PREPARE ddl FROM @ddl;
+EXECUTE ddl;
+DEALLOCATE PREPARE ddl;
+
+UPDATE order_items oi
+JOIN books b ON b.id = oi.book_id
+SET oi.unit_price = b.price
+WHERE @missing;
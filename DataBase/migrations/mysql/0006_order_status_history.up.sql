@@ -0,0 +1,20 @@
+-- Every order status change is recorded with who made it.
+
+CREATE TABLE IF NOT EXISTS order_status_history (
+    id INT AUTO_INCREMENT PRIMARY KEY,
+    order_id INT NOT NULL,
+    from_status VARCHAR(50) NULL,
+    to_status VARCHAR(50) NOT NULL,
+    changed_by INT NULL,
+    changed_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+
+    CONSTRAINT fk_status_history_order
+        FOREIGN KEY (order_id)
+        REFERENCES orders(id)
+        ON DELETE CASCADE,
+
+    CONSTRAINT fk_status_history_user
+        FOREIGN KEY (changed_by)
+        REFERENCES users(id)
+        ON DELETE SET NULL
+);
@@ -0,0 +1,15 @@
+-- Responses to POST requests sent with an Idempotency-Key, kept for replay.
+
+CREATE TABLE IF NOT EXISTS idempotency_keys (
+    user_id INT NOT NULL,
+    idem_key VARCHAR(255) NOT NULL,
+    request_hash CHAR(64) NOT NULL,
+    status_code INT NULL,
+    content_type VARCHAR(100) NULL,
+    response_body MEDIUMBLOB NULL,
+    created_at DATETIME NOT NULL,
+    expires_at DATETIME NOT NULL,
+
+    PRIMARY KEY (user_id, idem_key),
+    INDEX idx_idempotency_expires (expires_at)
+);
@@ -0,0 +1,8 @@
+ALTER TABLE orders DROP FOREIGN KEY fk_orders_coupon;
+ALTER TABLE orders
+    DROP COLUMN coupon_code,
+    DROP COLUMN coupon_id,
+    DROP COLUMN discount,
+    DROP COLUMN subtotal;
+
+DROP TABLE IF EXISTS coupons;
@@ -0,0 +1,48 @@
+-- Coupons, and the subtotal and discount of the orders they apply to.
+-- Orders placed before coupons existed had no discount, so their subtotal
+-- is their total.
+
+CREATE TABLE IF NOT EXISTS coupons (
+    id INT AUTO_INCREMENT PRIMARY KEY,
+    code VARCHAR(50) NOT NULL UNIQUE,
+    type VARCHAR(20) NOT NULL,
+    value DECIMAL(10, 2) NOT NULL DEFAULT 0,
+    buy_quantity INT NOT NULL DEFAULT 0,
+    free_quantity INT NOT NULL DEFAULT 0,
+    genre VARCHAR(100) NULL,
+    author_id INT NULL,
+    min_order_value DECIMAL(10, 2) NOT NULL DEFAULT 0,
+    usage_limit INT NOT NULL DEFAULT 0,
+    used_count INT NOT NULL DEFAULT 0,
+    valid_from DATETIME NULL,
+    valid_until DATETIME NULL,
+    active BOOLEAN NOT NULL DEFAULT TRUE,
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+
+    CONSTRAINT fk_coupons_author
+        FOREIGN KEY (author_id)
+        REFERENCES authors(id)
+        ON DELETE CASCADE
+);
+
+SET @missing = (
+    SELECT COUNT(*) = 0 FROM information_schema.columns
+    WHERE table_schema = DATABASE() AND table_name = 'orders' AND column_name = 'subtotal'
+);
+SET @ddl = IF(@missing,
+    'ALTER TABLE orders
+        ADD COLUMN subtotal DECIMAL(10, 2) NOT NULL DEFAULT 0 AFTER customer_id,
+        ADD COLUMN discount DECIMAL(10, 2) NOT NULL DEFAULT 0 AFTER subtotal,
+        ADD COLUMN coupon_id INT NULL AFTER discount,
+        ADD COLUMN coupon_code VARCHAR(50) NULL AFTER coupon_id,
+        ADD CONSTRAINT fk_orders_coupon
+            FOREIGN KEY (coupon_id)
+            REFERENCES coupons(id)
+            ON DELETE SET NULL',
+    'DO 0'
+);
+PREPARE ddl FROM @ddl;
+EXECUTE ddl;
+DEALLOCATE PREPARE ddl;
+
+UPDATE orders SET subtotal = total_price WHERE @missing;
@@ -0,0 +1,8 @@
+ALTER TABLE orders
+    DROP COLUMN shipping,
+    DROP COLUMN tax;
+
+DROP TABLE IF EXISTS shipping_rules;
+DROP TABLE IF EXISTS tax_rules;
+
+ALTER TABLE books DROP COLUMN weight_grams;
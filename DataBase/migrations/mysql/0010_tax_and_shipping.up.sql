@@ -0,0 +1,62 @@
+-- Tax and shipping rules, book weights, and the tax and shipping charged
+-- on each order. Shipping was free before the rules existed: a database
+-- that already sells books gets a free fallback rule to keep it so until
+-- real rules are added, and orders already placed keep 0 tax and shipping.
+-- A new, empty database gets no rule.
+
+SET @missing = (
+    SELECT COUNT(*) = 0 FROM information_schema.columns
+    WHERE table_schema = DATABASE() AND table_name = 'books' AND column_name = 'weight_grams'
+);
+SET @ddl = IF(@missing,
+    'ALTER TABLE books ADD COLUMN weight_grams INT NOT NULL DEFAULT 0 AFTER stock',
+    'DO 0'
+);
+PREPARE ddl FROM @ddl;
+EXECUTE ddl;
+DEALLOCATE PREPARE ddl;
+
+-- country '*' is the fallback rule, a NULL state covers the whole country
+CREATE TABLE IF NOT EXISTS tax_rules (
+    id INT AUTO_INCREMENT PRIMARY KEY,
+    country VARCHAR(100) NOT NULL,
+    state VARCHAR(100) NULL,
+    rate DECIMAL(6, 4) NOT NULL,
+
+    UNIQUE KEY unique_tax_rule (country, state)
+);
+
+SET @missing = (
+    SELECT COUNT(*) = 0 FROM information_schema.tables
+    WHERE table_schema = DATABASE() AND table_name = 'shipping_rules'
+);
+
+CREATE TABLE IF NOT EXISTS shipping_rules (
+    id INT AUTO_INCREMENT PRIMARY KEY,
+    country VARCHAR(100) NOT NULL,
+    state VARCHAR(100) NULL,
+    base_fee DECIMAL(10, 2) NOT NULL DEFAULT 0,
+    per_item_fee DECIMAL(10, 2) NOT NULL DEFAULT 0,
+    per_kg_fee DECIMAL(10, 2) NOT NULL DEFAULT 0,
+    free_shipping_threshold DECIMAL(10, 2) NULL,
+
+    UNIQUE KEY unique_shipping_rule (country, state)
+);
+
+INSERT INTO shipping_rules (country)
+SELECT '*' FROM DUAL
+WHERE @missing AND EXISTS (SELECT 1 FROM books);
+
+SET @missing = (
+    SELECT COUNT(*) = 0 FROM information_schema.columns
+    WHERE table_schema = DATABASE() AND table_name = 'orders' AND column_name = 'tax'
+);
+SET @ddl = IF(@missing,
+    'ALTER TABLE orders
+        ADD COLUMN tax DECIMAL(10, 2) NOT NULL DEFAULT 0 AFTER coupon_code,
+        ADD COLUMN shipping DECIMAL(10, 2) NOT NULL DEFAULT 0 AFTER tax',
+    'DO 0'
+);
+PREPARE ddl FROM @ddl;
+EXECUTE ddl;
+DEALLOCATE PREPARE ddl;
@@ -0,0 +1,40 @@
+-- Orders keep copies of their shipping and billing addresses. Orders
+-- placed before take both from the customer's address, which is still
+-- the addresses row customers.address_id points to at this version.
+
+SET @missing = (
+    SELECT COUNT(*) = 0 FROM information_schema.tables
+    WHERE table_schema = DATABASE() AND table_name = 'order_addresses'
+);
+
+-- immutable copies of the addresses an order was placed with
+CREATE TABLE IF NOT EXISTS order_addresses (
+    order_id INT NOT NULL,
+    kind VARCHAR(20) NOT NULL,
+    street VARCHAR(255) NOT NULL,
+    city VARCHAR(100) NOT NULL,
+    state VARCHAR(100) NOT NULL,
+    postal_code VARCHAR(20) NOT NULL,
+    country VARCHAR(100) NOT NULL,
+
+    PRIMARY KEY (order_id, kind),
+
+    CONSTRAINT fk_order_addresses_order
+        FOREIGN KEY (order_id)
+        REFERENCES orders(id)
+        ON DELETE CASCADE
+);
+
+-- prepared, because a later schema has no customers.address_id to parse
+SET @dml = IF(@missing,
+    'INSERT INTO order_addresses (order_id, kind, street, city, state, postal_code, country)
+     SELECT o.id, k.kind, a.street, a.city, a.state, a.postal_code, a.country
+     FROM orders o
+     JOIN customers c ON c.id = o.customer_id
+     JOIN addresses a ON a.id = c.address_id
+     CROSS JOIN (SELECT ''shipping'' AS kind UNION ALL SELECT ''billing'') k',
+    'DO 0'
+);
+PREPARE dml FROM @dml;
+EXECUTE dml;
+DEALLOCATE PREPARE dml;
@@ -0,0 +1,36 @@
+-- Puts each customer's default shipping address back into the shared
+-- addresses table. Customers without a saved address get a NULL
+-- address_id; their other addresses are lost.
+
+CREATE TABLE addresses (
+    id INT AUTO_INCREMENT PRIMARY KEY,
+    street VARCHAR(255) NOT NULL,
+    city VARCHAR(100) NOT NULL,
+    state VARCHAR(100) NOT NULL,
+    postal_code VARCHAR(20) NOT NULL,
+    country VARCHAR(100) NOT NULL,
+
+    UNIQUE KEY unique_address (
+        street, city, state, postal_code, country
+    )
+);
+
+INSERT IGNORE INTO addresses (street, city, state, postal_code, country)
+SELECT street, city, state, postal_code, country
+FROM customer_addresses
+WHERE is_default_shipping;
+
+ALTER TABLE customers
+    ADD COLUMN address_id INT NULL,
+    ADD CONSTRAINT fk_customer_address
+        FOREIGN KEY (address_id)
+        REFERENCES addresses(id)
+        ON DELETE CASCADE;
+
+UPDATE customers c
+JOIN customer_addresses ca ON ca.customer_id = c.id AND ca.is_default_shipping
+JOIN addresses a ON a.street = ca.street AND a.city = ca.city AND a.state = ca.state
+    AND a.postal_code = ca.postal_code AND a.country = ca.country
+SET c.address_id = a.id;
+
+DROP TABLE IF EXISTS customer_addresses;
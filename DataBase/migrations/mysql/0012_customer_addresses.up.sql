@@ -0,0 +1,54 @@
+-- Customers keep several saved addresses instead of pointing to one shared
+-- addresses row. Each customer's address becomes their "home" address and
+-- default for shipping and billing, then the old table goes.
+
+-- saved addresses; each customer has exactly one default shipping and one
+-- default billing address (the store keeps the flags consistent)
+CREATE TABLE IF NOT EXISTS customer_addresses (
+    id INT AUTO_INCREMENT PRIMARY KEY,
+    customer_id INT NOT NULL,
+    label VARCHAR(50) NOT NULL DEFAULT 'home',
+    street VARCHAR(255) NOT NULL,
+    city VARCHAR(100) NOT NULL,
+    state VARCHAR(100) NOT NULL,
+    postal_code VARCHAR(20) NOT NULL,
+    country VARCHAR(100) NOT NULL,
+    is_default_shipping BOOLEAN NOT NULL DEFAULT FALSE,
+    is_default_billing BOOLEAN NOT NULL DEFAULT FALSE,
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+
+    INDEX idx_customer_addresses_customer (customer_id),
+
+    CONSTRAINT fk_customer_addresses_customer
+        FOREIGN KEY (customer_id)
+        REFERENCES customers(id)
+        ON DELETE CASCADE
+);
+
+SET @legacy = (
+    SELECT COUNT(*) > 0 FROM information_schema.columns
+    WHERE table_schema = DATABASE() AND table_name = 'customers' AND column_name = 'address_id'
+);
+
+SET @dml = IF(@legacy,
+    'INSERT INTO customer_addresses (customer_id, street, city, state, postal_code, country, is_default_shipping, is_default_billing)
+     SELECT c.id, a.street, a.city, a.state, a.postal_code, a.country, TRUE, TRUE
+     FROM customers c
+     JOIN addresses a ON a.id = c.address_id',
+    'DO 0'
+);
+PREPARE dml FROM @dml;
+EXECUTE dml;
+DEALLOCATE PREPARE dml;
+
+SET @ddl = IF(@legacy, 'ALTER TABLE customers DROP FOREIGN KEY fk_customer_address', 'DO 0');
+PREPARE ddl FROM @ddl;
+EXECUTE ddl;
+DEALLOCATE PREPARE ddl;
+
+SET @ddl = IF(@legacy, 'ALTER TABLE customers DROP COLUMN address_id', 'DO 0');
+PREPARE ddl FROM @ddl;
+EXECUTE ddl;
+DEALLOCATE PREPARE ddl;
+
+DROP TABLE IF EXISTS addresses;
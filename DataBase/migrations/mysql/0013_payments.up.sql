@@ -0,0 +1,22 @@
+-- Payment attempts of orders through the payment gateway.
+
+CREATE TABLE IF NOT EXISTS payments (
+    id INT AUTO_INCREMENT PRIMARY KEY,
+    order_id INT NOT NULL,
+    amount DECIMAL(10, 2) NOT NULL,
+    status VARCHAR(20) NOT NULL,
+    gateway VARCHAR(50) NOT NULL,
+    authorization_id VARCHAR(100) NULL,
+    capture_id VARCHAR(100) NULL,
+    refund_id VARCHAR(100) NULL,
+    gateway_error VARCHAR(255) NULL,
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    updated_at DATETIME DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+
+    INDEX idx_payments_order (order_id),
+
+    CONSTRAINT fk_payments_order
+        FOREIGN KEY (order_id)
+        REFERENCES orders(id)
+        ON DELETE CASCADE
+);
@@ -0,0 +1,3 @@
+DROP TABLE IF EXISTS refunds;
+DROP TABLE IF EXISTS order_return_items;
+DROP TABLE IF EXISTS order_returns;
@@ -0,0 +1,72 @@
+-- Returns of delivered order items and the refunds paid for them.
+
+CREATE TABLE IF NOT EXISTS order_returns (
+    id INT AUTO_INCREMENT PRIMARY KEY,
+    order_id INT NOT NULL,
+    customer_id INT NOT NULL,
+    status VARCHAR(20) NOT NULL,
+    reason VARCHAR(500) NOT NULL DEFAULT '',
+    reviewed_by INT NULL,
+    review_note VARCHAR(500) NULL,
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    reviewed_at DATETIME NULL,
+
+    INDEX idx_order_returns_customer (customer_id),
+    INDEX idx_order_returns_status (status),
+
+    CONSTRAINT fk_order_returns_order
+        FOREIGN KEY (order_id)
+        REFERENCES orders(id)
+        ON DELETE CASCADE,
+
+    CONSTRAINT fk_order_returns_customer
+        FOREIGN KEY (customer_id)
+        REFERENCES customers(id)
+        ON DELETE CASCADE
+);
+
+CREATE TABLE IF NOT EXISTS order_return_items (
+    id INT AUTO_INCREMENT PRIMARY KEY,
+    return_id INT NOT NULL,
+    order_item_id INT NOT NULL,
+    book_id INT NOT NULL,
+    quantity INT NOT NULL,
+    unit_price DECIMAL(10, 2) NOT NULL,
+
+    CONSTRAINT fk_order_return_items_return
+        FOREIGN KEY (return_id)
+        REFERENCES order_returns(id)
+        ON DELETE CASCADE,
+
+    CONSTRAINT fk_order_return_items_item
+        FOREIGN KEY (order_item_id)
+        REFERENCES order_items(id)
+        ON DELETE CASCADE
+);
+
+CREATE TABLE IF NOT EXISTS refunds (
+    id INT AUTO_INCREMENT PRIMARY KEY,
+    order_id INT NOT NULL,
+    return_id INT NULL,
+    payment_id INT NULL,
+    amount DECIMAL(10, 2) NOT NULL,
+    gateway_refund_id VARCHAR(100) NULL,
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+
+    INDEX idx_refunds_created (created_at),
+
+    CONSTRAINT fk_refunds_order
+        FOREIGN KEY (order_id)
+        REFERENCES orders(id)
+        ON DELETE CASCADE,
+
+    CONSTRAINT fk_refunds_return
+        FOREIGN KEY (return_id)
+        REFERENCES order_returns(id)
+        ON DELETE SET NULL,
+
+    CONSTRAINT fk_refunds_payment
+        FOREIGN KEY (payment_id)
+        REFERENCES payments(id)
+        ON DELETE SET NULL
+);
@@ -0,0 +1,4 @@
+ALTER TABLE orders DROP COLUMN deleted_at;
+ALTER TABLE customers DROP COLUMN deleted_at;
+ALTER TABLE books DROP COLUMN deleted_at;
+ALTER TABLE authors DROP COLUMN deleted_at;
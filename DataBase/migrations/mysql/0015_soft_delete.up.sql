@@ -0,0 +1,50 @@
+-- Soft deletes: authors, books, customers and orders get a deleted_at that
+-- is set instead of removing the row.
+
+SET @missing = (
+    SELECT COUNT(*) = 0 FROM information_schema.columns
+    WHERE table_schema = DATABASE() AND table_name = 'authors' AND column_name = 'deleted_at'
+);
+SET @ddl = IF(@missing,
+    'ALTER TABLE authors ADD COLUMN deleted_at DATETIME NULL AFTER bio',
+    'DO 0'
+);
+PREPARE ddl FROM @ddl;
+EXECUTE ddl;
+DEALLOCATE PREPARE ddl;
+
+SET @missing = (
+    SELECT COUNT(*) = 0 FROM information_schema.columns
+    WHERE table_schema = DATABASE() AND table_name = 'books' AND column_name = 'deleted_at'
+);
+SET @ddl = IF(@missing,
+    'ALTER TABLE books ADD COLUMN deleted_at DATETIME NULL AFTER weight_grams',
+    'DO 0'
+);
+PREPARE ddl FROM @ddl;
+EXECUTE ddl;
+DEALLOCATE PREPARE ddl;
+
+SET @missing = (
+    SELECT COUNT(*) = 0 FROM information_schema.columns
+    WHERE table_schema = DATABASE() AND table_name = 'customers' AND column_name = 'deleted_at'
+);
+SET @ddl = IF(@missing,
+    'ALTER TABLE customers ADD COLUMN deleted_at DATETIME NULL AFTER created_at',
+    'DO 0'
+);
+PREPARE ddl FROM @ddl;
+EXECUTE ddl;
+DEALLOCATE PREPARE ddl;
+
+SET @missing = (
+    SELECT COUNT(*) = 0 FROM information_schema.columns
+    WHERE table_schema = DATABASE() AND table_name = 'orders' AND column_name = 'deleted_at'
+);
+SET @ddl = IF(@missing,
+    'ALTER TABLE orders ADD COLUMN deleted_at DATETIME NULL AFTER status',
+    'DO 0'
+);
+PREPARE ddl FROM @ddl;
+EXECUTE ddl;
+DEALLOCATE PREPARE ddl;
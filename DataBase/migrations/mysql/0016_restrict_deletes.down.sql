@@ -0,0 +1,17 @@
+ALTER TABLE order_items DROP FOREIGN KEY fk_order_items_book;
+ALTER TABLE order_items ADD CONSTRAINT fk_order_items_book
+    FOREIGN KEY (book_id)
+    REFERENCES books(id)
+    ON DELETE CASCADE;
+
+ALTER TABLE orders DROP FOREIGN KEY fk_orders_customer;
+ALTER TABLE orders ADD CONSTRAINT fk_orders_customer
+    FOREIGN KEY (customer_id)
+    REFERENCES customers(id)
+    ON DELETE CASCADE;
+
+ALTER TABLE books DROP FOREIGN KEY fk_books_author;
+ALTER TABLE books ADD CONSTRAINT fk_books_author
+    FOREIGN KEY (author_id)
+    REFERENCES authors(id)
+    ON DELETE CASCADE;
@@ -0,0 +1,61 @@
+-- Deleting an author, a customer or a book in the database no longer
+-- wipes the books, orders or order items pointing to it. MySQL cannot
+-- change what a foreign key does on delete, so each one that still
+-- cascades is dropped and added again.
+
+SET @cascades = (
+    SELECT COUNT(*) > 0 FROM information_schema.referential_constraints
+    WHERE constraint_schema = DATABASE() AND constraint_name = 'fk_books_author' AND delete_rule = 'CASCADE'
+);
+SET @ddl = IF(@cascades, 'ALTER TABLE books DROP FOREIGN KEY fk_books_author', 'DO 0');
+PREPARE ddl FROM @ddl;
+EXECUTE ddl;
+DEALLOCATE PREPARE ddl;
+SET @ddl = IF(@cascades,
+    'ALTER TABLE books ADD CONSTRAINT fk_books_author
+        FOREIGN KEY (author_id)
+        REFERENCES authors(id)
+        ON DELETE RESTRICT',
+    'DO 0'
+);
+PREPARE ddl FROM @ddl;
+EXECUTE ddl;
+DEALLOCATE PREPARE ddl;
+
+SET @cascades = (
+    SELECT COUNT(*) > 0 FROM information_schema.referential_constraints
+    WHERE constraint_schema = DATABASE() AND constraint_name = 'fk_orders_customer' AND delete_rule = 'CASCADE'
+);
+SET @ddl = IF(@cascades, 'ALTER TABLE orders DROP FOREIGN KEY fk_orders_customer', 'DO 0');
+PREPARE ddl FROM @ddl;
+EXECUTE ddl;
+DEALLOCATE PREPARE ddl;
+SET @ddl = IF(@cascades,
+    'ALTER TABLE orders ADD CONSTRAINT fk_orders_customer
+        FOREIGN KEY (customer_id)
+        REFERENCES customers(id)
+        ON DELETE RESTRICT',
+    'DO 0'
+);
+PREPARE ddl FROM @ddl;
+EXECUTE ddl;
+DEALLOCATE PREPARE ddl;
+
+SET @cascades = (
+    SELECT COUNT(*) > 0 FROM information_schema.referential_constraints
+    WHERE constraint_schema = DATABASE() AND constraint_name = 'fk_order_items_book' AND delete_rule = 'CASCADE'
+);
+SET @ddl = IF(@cascades, 'ALTER TABLE order_items DROP FOREIGN KEY fk_order_items_book', 'DO 0');
+PREPARE ddl FROM @ddl;
+EXECUTE ddl;
+DEALLOCATE PREPARE ddl;
+SET @ddl = IF(@cascades,
+    'ALTER TABLE order_items ADD CONSTRAINT fk_order_items_book
+        FOREIGN KEY (book_id)
+        REFERENCES books(id)
+        ON DELETE RESTRICT',
+    'DO 0'
+);
+PREPARE ddl FROM @ddl;
+EXECUTE ddl;
+DEALLOCATE PREPARE ddl;
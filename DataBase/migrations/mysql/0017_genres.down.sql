@@ -0,0 +1,14 @@
+-- Puts the genres back into books.genres as JSON arrays.
+
+ALTER TABLE books ADD COLUMN genres TEXT AFTER title;
+
+UPDATE books b
+SET genres = (
+    SELECT COALESCE(JSON_ARRAYAGG(g.name), JSON_ARRAY())
+    FROM book_genres bg
+    JOIN genres g ON g.id = bg.genre_id
+    WHERE bg.book_id = b.id
+);
+
+DROP TABLE IF EXISTS book_genres;
+DROP TABLE IF EXISTS genres;
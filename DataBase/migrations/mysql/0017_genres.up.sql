@@ -0,0 +1,60 @@
+-- Genres move from the books.genres text column into their own tables.
+-- The column held a JSON array for books created through the API and
+-- comma-separated text for rows loaded from data.sql; both are converted.
+
+CREATE TABLE IF NOT EXISTS genres (
+    id INT AUTO_INCREMENT PRIMARY KEY,
+    -- unique regardless of case, like every comparison on it
+    name VARCHAR(100) NOT NULL,
+    UNIQUE KEY uq_genres_name (name)
+);
+
+CREATE TABLE IF NOT EXISTS book_genres (
+    book_id INT NOT NULL,
+    genre_id INT NOT NULL,
+    PRIMARY KEY (book_id, genre_id),
+    INDEX idx_book_genres_genre (genre_id),
+    CONSTRAINT fk_book_genres_book
+        FOREIGN KEY (book_id)
+        REFERENCES books(id)
+        ON DELETE CASCADE,
+    CONSTRAINT fk_book_genres_genre
+        FOREIGN KEY (genre_id)
+        REFERENCES genres(id)
+        ON DELETE CASCADE
+);
+
+CREATE TEMPORARY TABLE legacy_book_genres (
+    book_id INT NOT NULL,
+    name VARCHAR(100) NOT NULL
+);
+
+INSERT INTO legacy_book_genres (book_id, name)
+SELECT b.id, TRIM(j.name)
+FROM books b,
+    JSON_TABLE(IF(JSON_VALID(b.genres), b.genres, '[]'), '$[*]' COLUMNS (name VARCHAR(100) PATH '$')) j
+WHERE JSON_VALID(b.genres) AND j.name IS NOT NULL;
+
+INSERT INTO legacy_book_genres (book_id, name)
+WITH RECURSIVE split (book_id, name, rest) AS (
+    SELECT id, CAST('' AS CHAR(100)), CAST(CONCAT(genres, ',') AS CHAR(10000))
+    FROM books
+    WHERE genres IS NOT NULL AND NOT JSON_VALID(genres)
+    UNION ALL
+    SELECT book_id, TRIM(SUBSTRING_INDEX(rest, ',', 1)), SUBSTRING(rest, LOCATE(',', rest) + 1)
+    FROM split
+    WHERE rest <> ''
+)
+SELECT book_id, name FROM split;
+
+INSERT IGNORE INTO genres (name)
+SELECT DISTINCT name FROM legacy_book_genres WHERE name <> '';
+
+INSERT IGNORE INTO book_genres (book_id, genre_id)
+SELECT DISTINCT l.book_id, g.id
+FROM legacy_book_genres l
+JOIN genres g ON g.name = l.name;
+
+DROP TEMPORARY TABLE legacy_book_genres;
+
+ALTER TABLE books DROP COLUMN genres;
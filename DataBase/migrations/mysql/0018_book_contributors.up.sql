@@ -0,0 +1,26 @@
+-- Books can have several contributors, each with a role, listed in order.
+-- books.author_id stays as the primary author and is always the first
+-- contributor with the author role.
+
+CREATE TABLE IF NOT EXISTS book_contributors (
+    book_id INT NOT NULL,
+    author_id INT NOT NULL,
+    role VARCHAR(20) NOT NULL,
+    -- 0-based order of the contributor on the book
+    position INT NOT NULL,
+    PRIMARY KEY (book_id, author_id, role),
+    INDEX idx_book_contributors_author (author_id),
+    CONSTRAINT chk_book_contributors_role
+        CHECK (role IN ('author', 'editor', 'translator', 'illustrator')),
+    CONSTRAINT fk_book_contributors_book
+        FOREIGN KEY (book_id)
+        REFERENCES books(id)
+        ON DELETE CASCADE,
+    CONSTRAINT fk_book_contributors_author
+        FOREIGN KEY (author_id)
+        REFERENCES authors(id)
+        ON DELETE RESTRICT
+);
+
+INSERT IGNORE INTO book_contributors (book_id, author_id, role, position)
+SELECT id, author_id, 'author', 0 FROM books;
@@ -0,0 +1,6 @@
+-- Refunds go back to a single amount.
+
+ALTER TABLE refunds
+    DROP COLUMN shipping,
+    DROP COLUMN tax,
+    DROP COLUMN merchandise;
@@ -0,0 +1,10 @@
+-- Refunds keep how much of them went back on merchandise, tax and
+-- shipping, so reports can take each off its own figure. Earlier refunds
+-- are counted as merchandise.
+
+ALTER TABLE refunds
+    ADD COLUMN merchandise DECIMAL(10, 2) NOT NULL DEFAULT 0 AFTER amount,
+    ADD COLUMN tax DECIMAL(10, 2) NOT NULL DEFAULT 0 AFTER merchandise,
+    ADD COLUMN shipping DECIMAL(10, 2) NOT NULL DEFAULT 0 AFTER tax;
+
+UPDATE refunds SET merchandise = amount;
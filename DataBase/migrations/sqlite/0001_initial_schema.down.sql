@@ -0,0 +1,23 @@
+-- Drops every table of the initial schema, dependents first.
+
+DROP TABLE IF EXISTS idempotency_keys;
+DROP TABLE IF EXISTS cart_items;
+DROP TABLE IF EXISTS carts;
+DROP TABLE IF EXISTS order_status_history;
+DROP TABLE IF EXISTS revoked_tokens;
+DROP TABLE IF EXISTS refresh_tokens;
+DROP TABLE IF EXISTS users;
+DROP TABLE IF EXISTS refunds;
+DROP TABLE IF EXISTS order_return_items;
+DROP TABLE IF EXISTS order_returns;
+DROP TABLE IF EXISTS payments;
+DROP TABLE IF EXISTS order_addresses;
+DROP TABLE IF EXISTS order_items;
+DROP TABLE IF EXISTS orders;
+DROP TABLE IF EXISTS coupons;
+DROP TABLE IF EXISTS shipping_rules;
+DROP TABLE IF EXISTS tax_rules;
+DROP TABLE IF EXISTS customer_addresses;
+DROP TABLE IF EXISTS customers;
+DROP TABLE IF EXISTS books;
+DROP TABLE IF EXISTS authors;
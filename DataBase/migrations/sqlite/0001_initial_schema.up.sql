@@ -0,0 +1,380 @@
+-- Schema of SQLite databases (DB_DRIVER=sqlite). SQLite support started with
+-- the MySQL schema as of migrations/mysql/0016, so the MySQL upgrades 0002 to
+-- 0016 have no twin here; later migrations do, as the stores run the same
+-- queries on both. Timestamps are stored as
+-- text ("2006-01-02 15:04:05.000+00:00"), which sorts and compares like a
+-- DATETIME column only while every value is in UTC; the server writes its
+-- own times in the local zone, so run it with TZ=UTC.
+
+CREATE TABLE IF NOT EXISTS authors (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    first_name VARCHAR(100) NOT NULL,
+    last_name VARCHAR(100) NOT NULL,
+    bio TEXT,
+    -- soft delete: set instead of removing the row
+    deleted_at DATETIME NULL
+);
+
+ 
+CREATE TABLE IF NOT EXISTS books (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    title VARCHAR(255) NOT NULL,
+    genres TEXT,
+    published_at DATETIME NOT NULL,
+    price DECIMAL(10, 2) NOT NULL,
+    stock INT NOT NULL,
+    weight_grams INT NOT NULL DEFAULT 0,
+    deleted_at DATETIME NULL,
+
+    author_id INT NOT NULL,
+    CONSTRAINT fk_books_author
+        FOREIGN KEY (author_id)
+        REFERENCES authors(id)
+        ON DELETE RESTRICT
+);
+
+
+
+CREATE TABLE IF NOT EXISTS customers (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    name VARCHAR(150) NOT NULL,
+    email VARCHAR(150) NOT NULL UNIQUE COLLATE NOCASE,
+    created_at DATETIME DEFAULT (strftime('%Y-%m-%d %H:%M:%f+00:00', 'now')),
+    deleted_at DATETIME NULL
+);
+
+
+-- saved addresses; each customer has exactly one default shipping and one
+-- default billing address (the store keeps the flags consistent)
+CREATE TABLE IF NOT EXISTS customer_addresses (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    customer_id INT NOT NULL,
+    label VARCHAR(50) NOT NULL DEFAULT 'home',
+    street VARCHAR(255) NOT NULL,
+    city VARCHAR(100) NOT NULL,
+    state VARCHAR(100) NOT NULL,
+    postal_code VARCHAR(20) NOT NULL,
+    country VARCHAR(100) NOT NULL,
+    is_default_shipping BOOLEAN NOT NULL DEFAULT FALSE,
+    is_default_billing BOOLEAN NOT NULL DEFAULT FALSE,
+    created_at DATETIME DEFAULT (strftime('%Y-%m-%d %H:%M:%f+00:00', 'now')),
+
+    CONSTRAINT fk_customer_addresses_customer
+        FOREIGN KEY (customer_id)
+        REFERENCES customers(id)
+        ON DELETE CASCADE
+);
+CREATE INDEX IF NOT EXISTS idx_customer_addresses_customer ON customer_addresses (customer_id);
+
+
+
+-- country '*' is the fallback rule, a NULL state covers the whole country
+CREATE TABLE IF NOT EXISTS tax_rules (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    country VARCHAR(100) NOT NULL,
+    state VARCHAR(100) NULL,
+    rate DECIMAL(6, 4) NOT NULL,
+
+    UNIQUE (country, state)
+);
+
+CREATE TABLE IF NOT EXISTS shipping_rules (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    country VARCHAR(100) NOT NULL,
+    state VARCHAR(100) NULL,
+    base_fee DECIMAL(10, 2) NOT NULL DEFAULT 0,
+    per_item_fee DECIMAL(10, 2) NOT NULL DEFAULT 0,
+    per_kg_fee DECIMAL(10, 2) NOT NULL DEFAULT 0,
+    free_shipping_threshold DECIMAL(10, 2) NULL,
+
+    UNIQUE (country, state)
+);
+
+
+CREATE TABLE IF NOT EXISTS coupons (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    code VARCHAR(50) NOT NULL UNIQUE,
+    type VARCHAR(20) NOT NULL,
+    value DECIMAL(10, 2) NOT NULL DEFAULT 0,
+    buy_quantity INT NOT NULL DEFAULT 0,
+    free_quantity INT NOT NULL DEFAULT 0,
+    genre VARCHAR(100) NULL,
+    author_id INT NULL,
+    min_order_value DECIMAL(10, 2) NOT NULL DEFAULT 0,
+    usage_limit INT NOT NULL DEFAULT 0,
+    used_count INT NOT NULL DEFAULT 0,
+    valid_from DATETIME NULL,
+    valid_until DATETIME NULL,
+    active BOOLEAN NOT NULL DEFAULT TRUE,
+    created_at DATETIME DEFAULT (strftime('%Y-%m-%d %H:%M:%f+00:00', 'now')),
+
+    CONSTRAINT fk_coupons_author
+        FOREIGN KEY (author_id)
+        REFERENCES authors(id)
+        ON DELETE CASCADE
+);
+
+
+CREATE TABLE IF NOT EXISTS orders (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    customer_id INT NOT NULL,
+    subtotal DECIMAL(10, 2) NOT NULL DEFAULT 0,
+    discount DECIMAL(10, 2) NOT NULL DEFAULT 0,
+    coupon_id INT NULL,
+    coupon_code VARCHAR(50) NULL,
+    tax DECIMAL(10, 2) NOT NULL DEFAULT 0,
+    shipping DECIMAL(10, 2) NOT NULL DEFAULT 0,
+    total_price DECIMAL(10, 2) NOT NULL,
+    created_at DATETIME DEFAULT (strftime('%Y-%m-%d %H:%M:%f+00:00', 'now')),
+    status VARCHAR(50) NOT NULL,
+    deleted_at DATETIME NULL,
+
+    CONSTRAINT fk_orders_customer
+        FOREIGN KEY (customer_id)
+        REFERENCES customers(id)
+        ON DELETE RESTRICT,
+
+    CONSTRAINT fk_orders_coupon
+        FOREIGN KEY (coupon_id)
+        REFERENCES coupons(id)
+        ON DELETE SET NULL
+);
+
+
+CREATE TABLE IF NOT EXISTS order_items (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    order_id INT NOT NULL,
+    book_id INT NOT NULL,
+    quantity INT NOT NULL,
+    unit_price DECIMAL(10, 2) NOT NULL,
+
+    CONSTRAINT fk_order_items_order
+        FOREIGN KEY (order_id)
+        REFERENCES orders(id)
+        ON DELETE CASCADE,
+
+    CONSTRAINT fk_order_items_book
+        FOREIGN KEY (book_id)
+        REFERENCES books(id)
+        ON DELETE RESTRICT
+);
+
+-- immutable copies of the addresses an order was placed with
+CREATE TABLE IF NOT EXISTS order_addresses (
+    order_id INT NOT NULL,
+    kind VARCHAR(20) NOT NULL,
+    street VARCHAR(255) NOT NULL,
+    city VARCHAR(100) NOT NULL,
+    state VARCHAR(100) NOT NULL,
+    postal_code VARCHAR(20) NOT NULL,
+    country VARCHAR(100) NOT NULL,
+
+    PRIMARY KEY (order_id, kind),
+
+    CONSTRAINT fk_order_addresses_order
+        FOREIGN KEY (order_id)
+        REFERENCES orders(id)
+        ON DELETE CASCADE
+);
+
+CREATE TABLE IF NOT EXISTS payments (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    order_id INT NOT NULL,
+    amount DECIMAL(10, 2) NOT NULL,
+    status VARCHAR(20) NOT NULL,
+    gateway VARCHAR(50) NOT NULL,
+    authorization_id VARCHAR(100) NULL,
+    capture_id VARCHAR(100) NULL,
+    refund_id VARCHAR(100) NULL,
+    gateway_error VARCHAR(255) NULL,
+    created_at DATETIME DEFAULT (strftime('%Y-%m-%d %H:%M:%f+00:00', 'now')),
+    updated_at DATETIME DEFAULT (strftime('%Y-%m-%d %H:%M:%f+00:00', 'now')),
+
+    CONSTRAINT fk_payments_order
+        FOREIGN KEY (order_id)
+        REFERENCES orders(id)
+        ON DELETE CASCADE
+);
+CREATE INDEX IF NOT EXISTS idx_payments_order ON payments (order_id);
+
+-- MySQL's ON UPDATE CURRENT_TIMESTAMP
+CREATE TRIGGER IF NOT EXISTS payments_updated_at
+AFTER UPDATE ON payments
+FOR EACH ROW
+BEGIN
+    UPDATE payments
+    SET updated_at = strftime('%Y-%m-%d %H:%M:%f+00:00', 'now')
+    WHERE id = NEW.id;
+END;
+
+CREATE TABLE IF NOT EXISTS order_returns (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    order_id INT NOT NULL,
+    customer_id INT NOT NULL,
+    status VARCHAR(20) NOT NULL,
+    reason VARCHAR(500) NOT NULL DEFAULT '',
+    reviewed_by INT NULL,
+    review_note VARCHAR(500) NULL,
+    created_at DATETIME DEFAULT (strftime('%Y-%m-%d %H:%M:%f+00:00', 'now')),
+    reviewed_at DATETIME NULL,
+
+    CONSTRAINT fk_order_returns_order
+        FOREIGN KEY (order_id)
+        REFERENCES orders(id)
+        ON DELETE CASCADE,
+
+    CONSTRAINT fk_order_returns_customer
+        FOREIGN KEY (customer_id)
+        REFERENCES customers(id)
+        ON DELETE CASCADE
+);
+CREATE INDEX IF NOT EXISTS idx_order_returns_customer ON order_returns (customer_id);
+CREATE INDEX IF NOT EXISTS idx_order_returns_status ON order_returns (status);
+
+CREATE TABLE IF NOT EXISTS order_return_items (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    return_id INT NOT NULL,
+    order_item_id INT NOT NULL,
+    book_id INT NOT NULL,
+    quantity INT NOT NULL,
+    unit_price DECIMAL(10, 2) NOT NULL,
+
+    CONSTRAINT fk_order_return_items_return
+        FOREIGN KEY (return_id)
+        REFERENCES order_returns(id)
+        ON DELETE CASCADE,
+
+    CONSTRAINT fk_order_return_items_item
+        FOREIGN KEY (order_item_id)
+        REFERENCES order_items(id)
+        ON DELETE CASCADE
+);
+
+CREATE TABLE IF NOT EXISTS refunds (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    order_id INT NOT NULL,
+    return_id INT NULL,
+    payment_id INT NULL,
+    amount DECIMAL(10, 2) NOT NULL,
+    gateway_refund_id VARCHAR(100) NULL,
+    created_at DATETIME DEFAULT (strftime('%Y-%m-%d %H:%M:%f+00:00', 'now')),
+
+    CONSTRAINT fk_refunds_order
+        FOREIGN KEY (order_id)
+        REFERENCES orders(id)
+        ON DELETE CASCADE,
+
+    CONSTRAINT fk_refunds_return
+        FOREIGN KEY (return_id)
+        REFERENCES order_returns(id)
+        ON DELETE SET NULL,
+
+    CONSTRAINT fk_refunds_payment
+        FOREIGN KEY (payment_id)
+        REFERENCES payments(id)
+        ON DELETE SET NULL
+);
+CREATE INDEX IF NOT EXISTS idx_refunds_created ON refunds (created_at);
+
+CREATE TABLE IF NOT EXISTS users (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    email VARCHAR(150) UNIQUE NOT NULL COLLATE NOCASE,
+    password VARCHAR(255) NOT NULL,
+    role VARCHAR(50) NOT NULL,
+    disabled BOOLEAN NOT NULL DEFAULT FALSE,
+    customer_id INT NULL UNIQUE,
+    created_at DATETIME DEFAULT (strftime('%Y-%m-%d %H:%M:%f+00:00', 'now')),
+
+    CONSTRAINT fk_users_customer
+        FOREIGN KEY (customer_id)
+        REFERENCES customers(id)
+        ON DELETE SET NULL
+);
+
+CREATE TABLE IF NOT EXISTS refresh_tokens (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    user_id INT NOT NULL,
+    token_hash CHAR(64) NOT NULL UNIQUE,
+    family_id VARCHAR(64) NOT NULL,
+    expires_at DATETIME NOT NULL,
+    revoked_at DATETIME NULL,
+    replaced_by INT NULL,
+    created_at DATETIME DEFAULT (strftime('%Y-%m-%d %H:%M:%f+00:00', 'now')),
+
+    CONSTRAINT fk_refresh_tokens_user
+        FOREIGN KEY (user_id)
+        REFERENCES users(id)
+        ON DELETE CASCADE
+);
+CREATE INDEX IF NOT EXISTS idx_refresh_tokens_family ON refresh_tokens (family_id);
+
+CREATE TABLE IF NOT EXISTS revoked_tokens (
+    jti VARCHAR(64) PRIMARY KEY,
+    expires_at DATETIME NOT NULL
+);
+
+CREATE TABLE IF NOT EXISTS order_status_history (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    order_id INT NOT NULL,
+    from_status VARCHAR(50) NULL,
+    to_status VARCHAR(50) NOT NULL,
+    changed_by INT NULL,
+    changed_at DATETIME DEFAULT (strftime('%Y-%m-%d %H:%M:%f+00:00', 'now')),
+
+    CONSTRAINT fk_status_history_order
+        FOREIGN KEY (order_id)
+        REFERENCES orders(id)
+        ON DELETE CASCADE,
+
+    CONSTRAINT fk_status_history_user
+        FOREIGN KEY (changed_by)
+        REFERENCES users(id)
+        ON DELETE SET NULL
+);
+
+CREATE TABLE IF NOT EXISTS carts (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    customer_id INT NOT NULL UNIQUE,
+    created_at DATETIME DEFAULT (strftime('%Y-%m-%d %H:%M:%f+00:00', 'now')),
+    updated_at DATETIME DEFAULT (strftime('%Y-%m-%d %H:%M:%f+00:00', 'now')),
+    expires_at DATETIME NOT NULL,
+
+    CONSTRAINT fk_carts_customer
+        FOREIGN KEY (customer_id)
+        REFERENCES customers(id)
+        ON DELETE CASCADE
+);
+CREATE INDEX IF NOT EXISTS idx_carts_expires ON carts (expires_at);
+
+CREATE TABLE IF NOT EXISTS cart_items (
+    cart_id INT NOT NULL,
+    book_id INT NOT NULL,
+    quantity INT NOT NULL,
+    added_at DATETIME DEFAULT (strftime('%Y-%m-%d %H:%M:%f+00:00', 'now')),
+
+    PRIMARY KEY (cart_id, book_id),
+
+    CONSTRAINT fk_cart_items_cart
+        FOREIGN KEY (cart_id)
+        REFERENCES carts(id)
+        ON DELETE CASCADE,
+
+    CONSTRAINT fk_cart_items_book
+        FOREIGN KEY (book_id)
+        REFERENCES books(id)
+        ON DELETE CASCADE
+);
+
+CREATE TABLE IF NOT EXISTS idempotency_keys (
+    user_id INT NOT NULL,
+    idem_key VARCHAR(255) NOT NULL,
+    request_hash CHAR(64) NOT NULL,
+    status_code INT NULL,
+    content_type VARCHAR(100) NULL,
+    response_body BLOB NULL,
+    created_at DATETIME NOT NULL,
+    expires_at DATETIME NOT NULL,
+
+    PRIMARY KEY (user_id, idem_key)
+);
+CREATE INDEX IF NOT EXISTS idx_idempotency_expires ON idempotency_keys (expires_at);
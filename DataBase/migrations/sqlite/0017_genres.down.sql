@@ -0,0 +1,14 @@
+-- Puts the genres back into books.genres as JSON arrays.
+
+ALTER TABLE books ADD COLUMN genres TEXT;
+
+UPDATE books
+SET genres = (
+    SELECT json_group_array(g.name)
+    FROM book_genres bg
+    JOIN genres g ON g.id = bg.genre_id
+    WHERE bg.book_id = books.id
+);
+
+DROP TABLE IF EXISTS book_genres;
+DROP TABLE IF EXISTS genres;
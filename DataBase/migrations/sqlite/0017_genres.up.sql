@@ -0,0 +1,59 @@
+-- SQLite version of migrations/mysql/0017_genres.up.sql: genres move from
+-- the books.genres text column (a JSON array, or comma-separated text for
+-- rows loaded from data.sql) into their own tables.
+
+CREATE TABLE IF NOT EXISTS genres (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    -- unique regardless of case, like every comparison on it
+    name VARCHAR(100) NOT NULL COLLATE NOCASE UNIQUE
+);
+
+CREATE TABLE IF NOT EXISTS book_genres (
+    book_id INTEGER NOT NULL,
+    genre_id INTEGER NOT NULL,
+    PRIMARY KEY (book_id, genre_id),
+    CONSTRAINT fk_book_genres_book
+        FOREIGN KEY (book_id)
+        REFERENCES books(id)
+        ON DELETE CASCADE,
+    CONSTRAINT fk_book_genres_genre
+        FOREIGN KEY (genre_id)
+        REFERENCES genres(id)
+        ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS idx_book_genres_genre ON book_genres (genre_id);
+
+CREATE TEMP TABLE legacy_book_genres (
+    book_id INTEGER NOT NULL,
+    name TEXT NOT NULL
+);
+
+INSERT INTO legacy_book_genres (book_id, name)
+SELECT b.id, TRIM(j.value)
+FROM books b, json_each(CASE WHEN json_valid(b.genres) THEN b.genres ELSE '[]' END) j
+WHERE j.type = 'text';
+
+INSERT INTO legacy_book_genres (book_id, name)
+WITH RECURSIVE split (book_id, name, rest) AS (
+    SELECT id, '', genres || ','
+    FROM books
+    WHERE genres IS NOT NULL AND NOT json_valid(genres)
+    UNION ALL
+    SELECT book_id, TRIM(substr(rest, 1, instr(rest, ',') - 1)), substr(rest, instr(rest, ',') + 1)
+    FROM split
+    WHERE rest <> ''
+)
+SELECT book_id, name FROM split;
+
+INSERT OR IGNORE INTO genres (name)
+SELECT DISTINCT name FROM legacy_book_genres WHERE name <> '';
+
+INSERT OR IGNORE INTO book_genres (book_id, genre_id)
+SELECT DISTINCT l.book_id, g.id
+FROM legacy_book_genres l
+JOIN genres g ON g.name = l.name;
+
+DROP TABLE legacy_book_genres;
+
+ALTER TABLE books DROP COLUMN genres;
@@ -0,0 +1,3 @@
+-- books.author_id still holds every book's primary author.
+
+DROP TABLE IF EXISTS book_contributors;
@@ -0,0 +1,27 @@
+-- SQLite version of migrations/mysql/0018_book_contributors.up.sql: books
+-- can have several contributors, each with a role, listed in order;
+-- books.author_id stays as the primary author.
+
+CREATE TABLE IF NOT EXISTS book_contributors (
+    book_id INTEGER NOT NULL,
+    author_id INTEGER NOT NULL,
+    role VARCHAR(20) NOT NULL,
+    -- 0-based order of the contributor on the book
+    position INTEGER NOT NULL,
+    PRIMARY KEY (book_id, author_id, role),
+    CONSTRAINT chk_book_contributors_role
+        CHECK (role IN ('author', 'editor', 'translator', 'illustrator')),
+    CONSTRAINT fk_book_contributors_book
+        FOREIGN KEY (book_id)
+        REFERENCES books(id)
+        ON DELETE CASCADE,
+    CONSTRAINT fk_book_contributors_author
+        FOREIGN KEY (author_id)
+        REFERENCES authors(id)
+        ON DELETE RESTRICT
+);
+
+CREATE INDEX IF NOT EXISTS idx_book_contributors_author ON book_contributors (author_id);
+
+INSERT OR IGNORE INTO book_contributors (book_id, author_id, role, position)
+SELECT id, author_id, 'author', 0 FROM books;
@@ -0,0 +1,5 @@
+-- Refunds go back to a single amount.
+
+ALTER TABLE refunds DROP COLUMN shipping;
+ALTER TABLE refunds DROP COLUMN tax;
+ALTER TABLE refunds DROP COLUMN merchandise;
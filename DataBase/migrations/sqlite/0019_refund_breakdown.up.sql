@@ -0,0 +1,9 @@
+-- SQLite version of migrations/mysql/0019_refund_breakdown.up.sql: refunds
+-- keep how much of them went back on merchandise, tax and shipping;
+-- earlier refunds are counted as merchandise.
+
+ALTER TABLE refunds ADD COLUMN merchandise DECIMAL(10, 2) NOT NULL DEFAULT 0;
+ALTER TABLE refunds ADD COLUMN tax DECIMAL(10, 2) NOT NULL DEFAULT 0;
+ALTER TABLE refunds ADD COLUMN shipping DECIMAL(10, 2) NOT NULL DEFAULT 0;
+
+UPDATE refunds SET merchandise = amount;
@@ -0,0 +1,63 @@
+package database
+
+import (
+	"context"
+	"database/sql/driver"
+	"time"
+
+	"modernc.org/sqlite"
+)
+
+// utcConnector opens SQLite connections that store every time argument in
+// UTC, as the MySQL driver does with its default loc. SQLite compares
+// timestamps as text, so times written with the server's offset would not
+// order against the CURRENT_TIMESTAMP defaults or each other.
+type utcConnector struct {
+	dsn    string
+	driver *sqlite.Driver
+}
+
+func (c utcConnector) Connect(ctx context.Context) (driver.Conn, error) {
+	conn, err := c.driver.Open(c.dsn)
+	if err != nil {
+		return nil, err
+	}
+	return utcConn{conn.(sqliteConn)}, nil
+}
+
+// Driver is the SQLite driver itself, which the stores check for to pick
+// the SQLite dialect
+func (c utcConnector) Driver() driver.Driver {
+	return c.driver
+}
+
+// sqliteConn is the part of the SQLite driver's connection that
+// database/sql uses
+type sqliteConn interface {
+	driver.Conn
+	driver.ConnBeginTx
+	driver.ConnPrepareContext
+	driver.ExecerContext
+	driver.QueryerContext
+	driver.Pinger
+	driver.SessionResetter
+	driver.Validator
+}
+
+type utcConn struct {
+	sqliteConn
+}
+
+// CheckNamedValue converts arguments like database/sql does by default,
+// then moves times to UTC
+func (c utcConn) CheckNamedValue(nv *driver.NamedValue) error {
+	value, err := driver.DefaultParameterConverter.ConvertValue(nv.Value)
+	if err != nil {
+		return err
+	}
+	if t, ok := value.(time.Time); ok {
+		value = t.UTC()
+	}
+	nv.Value = value
+	return nil
+}
@@ -0,0 +1,245 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"online_bookStore/models"
+	"online_bookStore/services"
+)
+
+type AuthHandler struct {
+	authService *services.AuthService
+	userService *services.UserService
+}
+
+func NewAuthHandler(authService *services.AuthService, userService *services.UserService) *AuthHandler {
+	return &AuthHandler{
+		authService: authService,
+		userService: userService,
+	}
+}
+
+// /auth/login
+func (h *AuthHandler) LoginHandler(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodPost:
+		h.login(w, r)
+	default:
+		WriteError(w, r, http.StatusMethodNotAllowed, "method not allowed")
+	}
+}
+
+func (h *AuthHandler) login(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithTimeout(r.Context(), 3*time.Second)
+	defer cancel()
+
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		log.Printf("ERROR reading login body: %v", err)
+		WriteError(w, r, http.StatusBadRequest, "failed to read request body")
+		return
+	}
+
+	var req models.LoginRequest
+	if err := json.Unmarshal(body, &req); err != nil {
+		log.Printf("ERROR unmarshalling login request: %v", err)
+		writeJSONError(w, r, err)
+		return
+	}
+
+	if req.Email == "" || req.Password == "" {
+		WriteError(w, r, http.StatusBadRequest, "email and password are required")
+		return
+	}
+
+	loginResp, err := h.authService.Login(ctx, req.Email, req.Password)
+	if errors.Is(err, services.ErrInvalidCredentials) {
+		log.Printf("LOGIN FAILED email=%s", req.Email)
+		WriteError(w, r, http.StatusUnauthorized, "invalid credentials")
+		return
+	}
+	if errors.Is(err, services.ErrUserDisabled) {
+		log.Printf("LOGIN REJECTED disabled account email=%s", req.Email)
+		WriteError(w, r, http.StatusForbidden, "account is disabled")
+		return
+	}
+	if err != nil {
+		writeStoreError(w, r, err, "failed to log in")
+		return
+	}
+
+	// significant business event
+	log.Printf("LOGIN SUCCEEDED email=%s", req.Email)
+
+	resp, err := json.Marshal(loginResp)
+	if err != nil {
+		log.Printf("ERROR serializing login response: %v", err)
+		WriteError(w, r, http.StatusInternalServerError, "failed to serialize token")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Write(resp)
+}
+
+// /auth/register
+func (h *AuthHandler) RegisterHandler(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodPost:
+		h.register(w, r)
+	default:
+		WriteError(w, r, http.StatusMethodNotAllowed, "method not allowed")
+	}
+}
+
+func (h *AuthHandler) register(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithTimeout(r.Context(), 3*time.Second)
+	defer cancel()
+
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		log.Printf("ERROR reading register body: %v", err)
+		WriteError(w, r, http.StatusBadRequest, "failed to read request body")
+		return
+	}
+
+	var req models.RegisterRequest
+	if err := json.Unmarshal(body, &req); err != nil {
+		log.Printf("ERROR unmarshalling register request: %v", err)
+		writeJSONError(w, r, err)
+		return
+	}
+
+	user, err := h.userService.Register(ctx, req)
+	if err != nil {
+		writeStoreError(w, r, err, "failed to register user")
+		return
+	}
+
+	// significant business event
+	log.Printf("USER REGISTERED id=%d email=%s", user.ID, user.Email)
+
+	resp, err := json.Marshal(user)
+	if err != nil {
+		log.Printf("ERROR serializing registered user: %v", err)
+		WriteError(w, r, http.StatusInternalServerError, "failed to serialize user")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	w.Write(resp)
+}
+
+// /auth/refresh
+func (h *AuthHandler) RefreshHandler(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodPost:
+		h.refresh(w, r)
+	default:
+		WriteError(w, r, http.StatusMethodNotAllowed, "method not allowed")
+	}
+}
+
+func (h *AuthHandler) refresh(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithTimeout(r.Context(), 3*time.Second)
+	defer cancel()
+
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		log.Printf("ERROR reading refresh body: %v", err)
+		WriteError(w, r, http.StatusBadRequest, "failed to read request body")
+		return
+	}
+
+	var req models.RefreshRequest
+	if err := json.Unmarshal(body, &req); err != nil {
+		log.Printf("ERROR unmarshalling refresh request: %v", err)
+		writeJSONError(w, r, err)
+		return
+	}
+
+	if req.RefreshToken == "" {
+		WriteError(w, r, http.StatusBadRequest, "refresh_token is required")
+		return
+	}
+
+	tokens, err := h.authService.Refresh(ctx, req.RefreshToken)
+	switch {
+	case errors.Is(err, services.ErrRefreshTokenReused):
+		log.Printf("REFRESH TOKEN REUSE detected")
+		WriteError(w, r, http.StatusUnauthorized, "refresh token has already been used")
+		return
+	case errors.Is(err, services.ErrInvalidToken):
+		WriteError(w, r, http.StatusUnauthorized, "invalid or expired refresh token")
+		return
+	case errors.Is(err, services.ErrUserDisabled):
+		WriteError(w, r, http.StatusForbidden, "account is disabled")
+		return
+	case err != nil:
+		writeStoreError(w, r, err, "failed to refresh token")
+		return
+	}
+
+	resp, err := json.Marshal(tokens)
+	if err != nil {
+		log.Printf("ERROR serializing refresh response: %v", err)
+		WriteError(w, r, http.StatusInternalServerError, "failed to serialize token")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Write(resp)
+}
+
+// /auth/logout
+func (h *AuthHandler) LogoutHandler(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodPost:
+		h.logout(w, r)
+	default:
+		WriteError(w, r, http.StatusMethodNotAllowed, "method not allowed")
+	}
+}
+
+func (h *AuthHandler) logout(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithTimeout(r.Context(), 3*time.Second)
+	defer cancel()
+
+	// the body is optional: without a refresh token only the access token is revoked
+	var req models.RefreshRequest
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		log.Printf("ERROR reading logout body: %v", err)
+		WriteError(w, r, http.StatusBadRequest, "failed to read request body")
+		return
+	}
+	if len(body) > 0 {
+		if err := json.Unmarshal(body, &req); err != nil {
+			log.Printf("ERROR unmarshalling logout request: %v", err)
+			writeJSONError(w, r, err)
+			return
+		}
+	}
+
+	accessToken := strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")
+
+	if err := h.authService.Logout(ctx, accessToken, req.RefreshToken); err != nil {
+		writeStoreError(w, r, err, "failed to log out")
+		return
+	}
+
+	// significant business event
+	if user, ok := UserFromContext(r.Context()); ok {
+		log.Printf("LOGOUT user=%d", user.ID)
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
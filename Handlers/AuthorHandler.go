@@ -34,7 +34,7 @@ func (h *AuthorHandler) AuthorsHandler(w http.ResponseWriter, r *http.Request) {
 	case http.MethodPost:
 		h.createAuthor(w, r)
 	default:
-		WriteError(w, http.StatusMethodNotAllowed, "method not allowed")
+		WriteError(w, r, http.StatusMethodNotAllowed, "method not allowed")
 	}
 }
 
@@ -42,20 +42,19 @@ func (h *AuthorHandler) AuthorsHandler(w http.ResponseWriter, r *http.Request) {
 	GET /authors
 */
 func (h *AuthorHandler) getAuthors(w http.ResponseWriter, r *http.Request) {
-	ctx, cancel := context.WithTimeout(r.Context(), 3*time.Second)
+	ctx, cancel := context.WithTimeout(readContext(r), 3*time.Second)
 	defer cancel()
 
 	authors, err := h.AuthorStore.GetAllAuthors(ctx)
 	if err != nil {
-		log.Printf("ERROR fetching authors: %v", err)
-		WriteError(w, http.StatusInternalServerError, "failed to fetch authors")
+		writeStoreError(w, r, err, "failed to fetch authors")
 		return
 	}
 
 	resp, err := json.Marshal(authors)
 	if err != nil {
 		log.Printf("ERROR serializing authors: %v", err)
-		WriteError(w, http.StatusInternalServerError, "failed to serialize authors")
+		WriteError(w, r, http.StatusInternalServerError, "failed to serialize authors")
 		return
 	}
 
@@ -64,9 +63,18 @@ func (h *AuthorHandler) getAuthors(w http.ResponseWriter, r *http.Request) {
 }
 
 /*
-	ROUTE: /authors/{id}
+	ROUTE: /authors/{id} and /authors/{id}/restore
 */
 func (h *AuthorHandler) AuthorsByIDHandler(w http.ResponseWriter, r *http.Request) {
+	if strings.HasSuffix(r.URL.Path, "/restore") {
+		if r.Method != http.MethodPost {
+			WriteError(w, r, http.StatusMethodNotAllowed, "method not allowed")
+			return
+		}
+		h.restoreAuthor(w, r)
+		return
+	}
+
 	switch r.Method {
 	case http.MethodGet:
 		h.getAuthorByID(w, r)
@@ -75,7 +83,7 @@ func (h *AuthorHandler) AuthorsByIDHandler(w http.ResponseWriter, r *http.Reques
 	case http.MethodDelete:
 		h.deleteAuthor(w, r)
 	default:
-		WriteError(w, http.StatusMethodNotAllowed, "method not allowed")
+		WriteError(w, r, http.StatusMethodNotAllowed, "method not allowed")
 	}
 }
 
@@ -83,26 +91,25 @@ func (h *AuthorHandler) AuthorsByIDHandler(w http.ResponseWriter, r *http.Reques
 	GET /authors/{id}
 */
 func (h *AuthorHandler) getAuthorByID(w http.ResponseWriter, r *http.Request) {
-	ctx, cancel := context.WithTimeout(r.Context(), 3*time.Second)
+	ctx, cancel := context.WithTimeout(readContext(r), 3*time.Second)
 	defer cancel()
 
 	id, err := parseID(r.URL.Path, "/authors/")
 	if err != nil {
-		WriteError(w, http.StatusBadRequest, "invalid author id")
+		WriteError(w, r, http.StatusBadRequest, "invalid author id")
 		return
 	}
 
 	author, err := h.AuthorStore.GetAuthor(ctx, id)
 	if err != nil {
-		log.Printf("ERROR fetching author %d: %v", id, err)
-		WriteError(w, http.StatusNotFound, "author not found")
+		writeStoreError(w, r, err, "failed to fetch author")
 		return
 	}
 
 	resp, err := json.Marshal(author)
 	if err != nil {
 		log.Printf("ERROR serializing author %d: %v", id, err)
-		WriteError(w, http.StatusInternalServerError, "failed to serialize author")
+		WriteError(w, r, http.StatusInternalServerError, "failed to serialize author")
 		return
 	}
 
@@ -119,35 +126,34 @@ func (h *AuthorHandler) updateAuthor(w http.ResponseWriter, r *http.Request) {
 
 	id, err := parseID(r.URL.Path, "/authors/")
 	if err != nil {
-		WriteError(w, http.StatusBadRequest, "invalid author id")
+		WriteError(w, r, http.StatusBadRequest, "invalid author id")
 		return
 	}
 
 	body, err := io.ReadAll(r.Body)
 	if err != nil {
 		log.Printf("ERROR reading update author body: %v", err)
-		WriteError(w, http.StatusBadRequest, "invalid request body")
+		WriteError(w, r, http.StatusBadRequest, "invalid request body")
 		return
 	}
 
 	var author models.Author
 	if err := json.Unmarshal(body, &author); err != nil {
 		log.Printf("ERROR unmarshalling author %d: %v", id, err)
-		WriteError(w, http.StatusBadRequest, "invalid author payload")
+		writeJSONError(w, r, err)
 		return
 	}
 
 	updatedAuthor, err := h.AuthorStore.UpdateAuthor(ctx, id, author)
 	if err != nil {
-		log.Printf("ERROR updating author %d: %v", id, err)
-		WriteError(w, http.StatusNotFound, "author not found")
+		writeStoreError(w, r, err, "failed to update author")
 		return
 	}
 
 	resp, err := json.Marshal(updatedAuthor)
 	if err != nil {
 		log.Printf("ERROR serializing updated author %d: %v", id, err)
-		WriteError(w, http.StatusInternalServerError, "failed to serialize author")
+		WriteError(w, r, http.StatusInternalServerError, "failed to serialize author")
 		return
 	}
 
@@ -165,21 +171,20 @@ func (h *AuthorHandler) createAuthor(w http.ResponseWriter, r *http.Request) {
 	body, err := io.ReadAll(r.Body)
 	if err != nil {
 		log.Printf("ERROR reading create author body: %v", err)
-		WriteError(w, http.StatusBadRequest, "invalid request body")
+		WriteError(w, r, http.StatusBadRequest, "invalid request body")
 		return
 	}
 
 	var author models.Author
 	if err := json.Unmarshal(body, &author); err != nil {
 		log.Printf("ERROR unmarshalling author: %v", err)
-		WriteError(w, http.StatusBadRequest, "invalid author payload")
+		writeJSONError(w, r, err)
 		return
 	}
 
 	createdAuthor, err := h.AuthorStore.CreateAuthor(ctx, author)
 	if err != nil {
-		log.Printf("ERROR creating author: %v", err)
-		WriteError(w, http.StatusInternalServerError, "failed to create author")
+		writeStoreError(w, r, err, "failed to create author")
 		return
 	}
 
@@ -194,7 +199,7 @@ func (h *AuthorHandler) createAuthor(w http.ResponseWriter, r *http.Request) {
 	resp, err := json.Marshal(createdAuthor)
 	if err != nil {
 		log.Printf("ERROR serializing created author: %v", err)
-		WriteError(w, http.StatusInternalServerError, "failed to serialize author")
+		WriteError(w, r, http.StatusInternalServerError, "failed to serialize author")
 		return
 	}
 
@@ -212,22 +217,56 @@ func (h *AuthorHandler) deleteAuthor(w http.ResponseWriter, r *http.Request) {
 
 	id, err := parseID(r.URL.Path, "/authors/")
 	if err != nil {
-		WriteError(w, http.StatusBadRequest, "invalid author id")
+		WriteError(w, r, http.StatusBadRequest, "invalid author id")
 		return
 	}
 
-	if err := h.AuthorStore.DeleteAuthor(ctx, id); err != nil {
-		log.Printf("ERROR deleting author %d: %v", id, err)
-		WriteError(w, http.StatusNotFound, "author not found")
+	force := r.URL.Query().Get("force") == "true"
+
+	if err := h.AuthorStore.DeleteAuthor(ctx, id, force); err != nil {
+		writeStoreError(w, r, err, "failed to delete author")
 		return
 	}
 
 	//  significant business log
-	log.Printf("AUTHOR DELETED id=%d", id)
+	log.Printf("AUTHOR DELETED id=%d force=%t", id, force)
 
 	w.WriteHeader(http.StatusNoContent)
 }
 
+/*
+	POST /authors/{id}/restore
+*/
+func (h *AuthorHandler) restoreAuthor(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithTimeout(r.Context(), 3*time.Second)
+	defer cancel()
+
+	id, err := parseID(strings.TrimSuffix(r.URL.Path, "/restore"), "/authors/")
+	if err != nil {
+		WriteError(w, r, http.StatusBadRequest, "invalid author id")
+		return
+	}
+
+	author, err := h.AuthorStore.RestoreAuthor(ctx, id)
+	if err != nil {
+		writeStoreError(w, r, err, "failed to restore author")
+		return
+	}
+
+	//  significant business log
+	log.Printf("AUTHOR RESTORED id=%d", id)
+
+	resp, err := json.Marshal(author)
+	if err != nil {
+		log.Printf("ERROR serializing author %d: %v", id, err)
+		WriteError(w, r, http.StatusInternalServerError, "failed to serialize author")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Write(resp)
+}
+
 /*
 	HELPER: parse ID from URL
 */
@@ -37,7 +37,7 @@ func (h *BookHandler) BooksHandler(w http.ResponseWriter, r *http.Request) {
 }
 
 func (h *BookHandler) getBooks(w http.ResponseWriter, r *http.Request) {
-	ctx, cancel := context.WithTimeout(r.Context(), 3*time.Second)
+	ctx, cancel := context.WithTimeout(readContext(r), 3*time.Second)
 	defer cancel()
 
 	// read query parameters
@@ -59,15 +59,14 @@ func (h *BookHandler) getBooks(w http.ResponseWriter, r *http.Request) {
 
 	books, err := h.bookStore.SearchBooks(ctx, criteria)
 	if err != nil {
-		log.Printf("ERROR fetching books: %v", err)
-		WriteError(w, http.StatusInternalServerError, "failed to fetch books")
+		writeStoreError(w, r, err, "failed to fetch books")
 		return
 	}
 
 	resp, err := json.Marshal(books)
 	if err != nil {
 		log.Printf("ERROR serializing books: %v", err)
-		WriteError(w, http.StatusInternalServerError, "failed to serialize books")
+		WriteError(w, r, http.StatusInternalServerError, "failed to serialize books")
 		return
 	}
 
@@ -82,7 +81,7 @@ func (h *BookHandler) createBook(w http.ResponseWriter, r *http.Request) {
 	body, err := io.ReadAll(r.Body)
 	if err != nil {
 		log.Printf("ERROR reading create book body: %v", err)
-		WriteError(w, http.StatusBadRequest, "failed to read request body")
+		WriteError(w, r, http.StatusBadRequest, "failed to read request body")
 		return
 	}
 
@@ -90,14 +89,13 @@ func (h *BookHandler) createBook(w http.ResponseWriter, r *http.Request) {
 	err = json.Unmarshal(body, &book)
 	if err != nil {
 		log.Printf("ERROR unmarshalling book: %v", err)
-		WriteError(w, http.StatusBadRequest, "invalid JSON body")
+		writeJSONError(w, r, err)
 		return
 	}
 
 	createdBook, err := h.bookStore.CreateBook(ctx, book)
 	if err != nil {
-		log.Printf("ERROR creating book: %v", err)
-		WriteError(w, http.StatusInternalServerError, "failed to create book")
+		writeStoreError(w, r, err, "failed to create book")
 		return
 	}
 
@@ -107,7 +105,7 @@ func (h *BookHandler) createBook(w http.ResponseWriter, r *http.Request) {
 	resp, err := json.Marshal(createdBook)
 	if err != nil {
 		log.Printf("ERROR serializing created book: %v", err)
-		WriteError(w, http.StatusInternalServerError, "failed to serialize book")
+		WriteError(w, r, http.StatusInternalServerError, "failed to serialize book")
 		return
 	}
 
@@ -116,8 +114,17 @@ func (h *BookHandler) createBook(w http.ResponseWriter, r *http.Request) {
 	w.Write(resp)
 }
 
-// /books/{id}
+// /books/{id} and /books/{id}/restore
 func (h *BookHandler) BookByIDHandler(w http.ResponseWriter, r *http.Request) {
+	if strings.HasSuffix(r.URL.Path, "/restore") {
+		if r.Method != http.MethodPost {
+			WriteError(w, r, http.StatusMethodNotAllowed, "method not allowed")
+			return
+		}
+		h.restoreBook(w, r)
+		return
+	}
+
 	switch r.Method {
 	case http.MethodGet:
 		h.getBookByID(w, r)
@@ -131,27 +138,26 @@ func (h *BookHandler) BookByIDHandler(w http.ResponseWriter, r *http.Request) {
 }
 
 func (h *BookHandler) getBookByID(w http.ResponseWriter, r *http.Request) {
-	ctx, cancel := context.WithTimeout(r.Context(), 3*time.Second)
+	ctx, cancel := context.WithTimeout(readContext(r), 3*time.Second)
 	defer cancel()
 
 	idStr := strings.TrimPrefix(r.URL.Path, "/books/")
 	id, err := strconv.Atoi(idStr)
 	if err != nil {
-		WriteError(w, http.StatusBadRequest, "invalid book id")
+		WriteError(w, r, http.StatusBadRequest, "invalid book id")
 		return
 	}
 
 	book, err := h.bookStore.GetBook(ctx, id)
 	if err != nil {
-		log.Printf("ERROR fetching book %d: %v", id, err)
-		WriteError(w, http.StatusNotFound, "book not found")
+		writeStoreError(w, r, err, "failed to fetch book")
 		return
 	}
 
 	resp, err := json.Marshal(book)
 	if err != nil {
 		log.Printf("ERROR serializing book %d: %v", id, err)
-		WriteError(w, http.StatusInternalServerError, "failed to serialize book")
+		WriteError(w, r, http.StatusInternalServerError, "failed to serialize book")
 		return
 	}
 
@@ -166,14 +172,14 @@ func (h *BookHandler) updateBook(w http.ResponseWriter, r *http.Request) {
 	idStr := strings.TrimPrefix(r.URL.Path, "/books/")
 	id, err := strconv.Atoi(idStr)
 	if err != nil {
-		WriteError(w, http.StatusBadRequest, "invalid book id")
+		WriteError(w, r, http.StatusBadRequest, "invalid book id")
 		return
 	}
 
 	body, err := io.ReadAll(r.Body)
 	if err != nil {
 		log.Printf("ERROR reading update book body: %v", err)
-		WriteError(w, http.StatusBadRequest, "failed to read request body")
+		WriteError(w, r, http.StatusBadRequest, "failed to read request body")
 		return
 	}
 
@@ -181,14 +187,13 @@ func (h *BookHandler) updateBook(w http.ResponseWriter, r *http.Request) {
 	err = json.Unmarshal(body, &book)
 	if err != nil {
 		log.Printf("ERROR unmarshalling book %d: %v", id, err)
-		WriteError(w, http.StatusBadRequest, "invalid JSON body")
+		writeJSONError(w, r, err)
 		return
 	}
 
 	updatedBook, err := h.bookStore.UpdateBook(ctx, id, book)
 	if err != nil {
-		log.Printf("ERROR updating book %d: %v", id, err)
-		WriteError(w, http.StatusNotFound, "book not found")
+		writeStoreError(w, r, err, "failed to update book")
 		return
 	}
 
@@ -198,7 +203,7 @@ func (h *BookHandler) updateBook(w http.ResponseWriter, r *http.Request) {
 	resp, err := json.Marshal(updatedBook)
 	if err != nil {
 		log.Printf("ERROR serializing updated book %d: %v", id, err)
-		WriteError(w, http.StatusInternalServerError, "failed to serialize book")
+		WriteError(w, r, http.StatusInternalServerError, "failed to serialize book")
 		return
 	}
 
@@ -213,19 +218,52 @@ func (h *BookHandler) deleteBook(w http.ResponseWriter, r *http.Request) {
 	idStr := strings.TrimPrefix(r.URL.Path, "/books/")
 	id, err := strconv.Atoi(idStr)
 	if err != nil {
-		WriteError(w, http.StatusBadRequest, "invalid book id")
+		WriteError(w, r, http.StatusBadRequest, "invalid book id")
 		return
 	}
 
-	err = h.bookStore.DeleteBook(ctx, id)
+	force := r.URL.Query().Get("force") == "true"
+
+	err = h.bookStore.DeleteBook(ctx, id, force)
 	if err != nil {
-		log.Printf("ERROR deleting book %d: %v", id, err)
-		WriteError(w, http.StatusNotFound, "book not found")
+		writeStoreError(w, r, err, "failed to delete book")
 		return
 	}
 
 	// significant business event
-	log.Printf("BOOK DELETED id=%d", id)
+	log.Printf("BOOK DELETED id=%d force=%t", id, force)
 
 	w.WriteHeader(http.StatusNoContent)
 }
+
+// POST /books/{id}/restore
+func (h *BookHandler) restoreBook(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithTimeout(r.Context(), 3*time.Second)
+	defer cancel()
+
+	idStr := strings.TrimSuffix(strings.TrimPrefix(r.URL.Path, "/books/"), "/restore")
+	id, err := strconv.Atoi(idStr)
+	if err != nil {
+		WriteError(w, r, http.StatusBadRequest, "invalid book id")
+		return
+	}
+
+	book, err := h.bookStore.RestoreBook(ctx, id)
+	if err != nil {
+		writeStoreError(w, r, err, "failed to restore book")
+		return
+	}
+
+	// significant business event
+	log.Printf("BOOK RESTORED id=%d", id)
+
+	resp, err := json.Marshal(book)
+	if err != nil {
+		log.Printf("ERROR serializing book %d: %v", id, err)
+		WriteError(w, r, http.StatusInternalServerError, "failed to serialize book")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Write(resp)
+}
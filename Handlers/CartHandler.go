@@ -0,0 +1,231 @@
+package handlers
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"io"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"online_bookStore/models"
+	"online_bookStore/services"
+)
+
+type CartHandler struct {
+	cartService *services.CartService
+}
+
+func NewCartHandler(cartService *services.CartService) *CartHandler {
+	return &CartHandler{
+		cartService: cartService,
+	}
+}
+
+// /cart, /cart/items, /cart/items/{book_id} and /cart/checkout
+func (h *CartHandler) CartRouter(w http.ResponseWriter, r *http.Request) {
+	user, _ := UserFromContext(r.Context())
+	if user.CustomerID == 0 {
+		WriteError(w, r, http.StatusForbidden, "no customer profile is linked to this account")
+		return
+	}
+
+	path := r.URL.Path
+	switch {
+	case path == "/cart" && r.Method == http.MethodGet:
+		h.getCart(w, r, user.CustomerID)
+	case path == "/cart" && r.Method == http.MethodDelete:
+		h.clearCart(w, r, user.CustomerID)
+	case path == "/cart/items" && r.Method == http.MethodPost:
+		h.addItem(w, r, user.CustomerID)
+	case strings.HasPrefix(path, "/cart/items/") && r.Method == http.MethodPut:
+		h.updateItem(w, r, user.CustomerID)
+	case strings.HasPrefix(path, "/cart/items/") && r.Method == http.MethodDelete:
+		h.removeItem(w, r, user.CustomerID)
+	case path == "/cart/checkout" && r.Method == http.MethodPost:
+		h.checkout(w, r, user.CustomerID)
+	case path == "/cart", path == "/cart/items", path == "/cart/checkout", strings.HasPrefix(path, "/cart/items/"):
+		WriteError(w, r, http.StatusMethodNotAllowed, "method not allowed")
+	default:
+		WriteError(w, r, http.StatusNotFound, "not found")
+	}
+}
+
+func (h *CartHandler) getCart(w http.ResponseWriter, r *http.Request, customerID int) {
+	ctx, cancel := context.WithTimeout(r.Context(), 3*time.Second)
+	defer cancel()
+
+	cart, err := h.cartService.GetCart(ctx, customerID)
+	if err != nil {
+		writeStoreError(w, r, err, "failed to fetch cart")
+		return
+	}
+
+	writeCart(w, r, http.StatusOK, cart)
+}
+
+func (h *CartHandler) clearCart(w http.ResponseWriter, r *http.Request, customerID int) {
+	ctx, cancel := context.WithTimeout(r.Context(), 3*time.Second)
+	defer cancel()
+
+	if err := h.cartService.ClearCart(ctx, customerID); err != nil {
+		writeStoreError(w, r, err, "failed to clear cart")
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (h *CartHandler) addItem(w http.ResponseWriter, r *http.Request, customerID int) {
+	ctx, cancel := context.WithTimeout(r.Context(), 3*time.Second)
+	defer cancel()
+
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		log.Printf("ERROR reading cart item body: %v", err)
+		WriteError(w, r, http.StatusBadRequest, "failed to read request body")
+		return
+	}
+
+	var req models.CartItemRequest
+	if err := json.Unmarshal(body, &req); err != nil {
+		log.Printf("ERROR unmarshalling cart item: %v", err)
+		writeJSONError(w, r, err)
+		return
+	}
+
+	cart, err := h.cartService.AddItem(ctx, customerID, req.BookID, req.Quantity)
+	if err != nil {
+		writeCartError(w, r, err, "failed to add item to cart")
+		return
+	}
+
+	writeCart(w, r, http.StatusOK, cart)
+}
+
+func (h *CartHandler) updateItem(w http.ResponseWriter, r *http.Request, customerID int) {
+	ctx, cancel := context.WithTimeout(r.Context(), 3*time.Second)
+	defer cancel()
+
+	bookID, err := strconv.Atoi(strings.TrimPrefix(r.URL.Path, "/cart/items/"))
+	if err != nil {
+		WriteError(w, r, http.StatusBadRequest, "invalid book id")
+		return
+	}
+
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		log.Printf("ERROR reading cart item body: %v", err)
+		WriteError(w, r, http.StatusBadRequest, "failed to read request body")
+		return
+	}
+
+	var req models.CartItemRequest
+	if err := json.Unmarshal(body, &req); err != nil {
+		log.Printf("ERROR unmarshalling cart item: %v", err)
+		writeJSONError(w, r, err)
+		return
+	}
+
+	cart, err := h.cartService.SetItemQuantity(ctx, customerID, bookID, req.Quantity)
+	if err != nil {
+		writeCartError(w, r, err, "failed to update cart item")
+		return
+	}
+
+	writeCart(w, r, http.StatusOK, cart)
+}
+
+func (h *CartHandler) removeItem(w http.ResponseWriter, r *http.Request, customerID int) {
+	ctx, cancel := context.WithTimeout(r.Context(), 3*time.Second)
+	defer cancel()
+
+	bookID, err := strconv.Atoi(strings.TrimPrefix(r.URL.Path, "/cart/items/"))
+	if err != nil {
+		WriteError(w, r, http.StatusBadRequest, "invalid book id")
+		return
+	}
+
+	cart, err := h.cartService.RemoveItem(ctx, customerID, bookID)
+	if err != nil {
+		writeCartError(w, r, err, "failed to remove cart item")
+		return
+	}
+
+	writeCart(w, r, http.StatusOK, cart)
+}
+
+func (h *CartHandler) checkout(w http.ResponseWriter, r *http.Request, customerID int) {
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		log.Printf("ERROR reading checkout body: %v", err)
+		WriteError(w, r, http.StatusBadRequest, "failed to read request body")
+		return
+	}
+
+	// the body is optional
+	var req models.CheckoutRequest
+	if len(body) > 0 {
+		if err := json.Unmarshal(body, &req); err != nil {
+			log.Printf("ERROR unmarshalling checkout request: %v", err)
+			writeJSONError(w, r, err)
+			return
+		}
+	}
+
+	order, err := h.cartService.Checkout(ctx, customerID, req)
+	if err != nil {
+		writeCartError(w, r, err, "failed to check out cart")
+		return
+	}
+
+	// significant business event
+	log.Printf(
+		"CART CHECKED OUT order=%d customer=%d total=%.2f coupon=%s",
+		order.ID,
+		customerID,
+		order.TotalPrice,
+		order.CouponCode,
+	)
+
+	resp, err := json.Marshal(order)
+	if err != nil {
+		log.Printf("ERROR serializing order %d: %v", order.ID, err)
+		WriteError(w, r, http.StatusInternalServerError, "failed to serialize order")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	w.Write(resp)
+}
+
+func writeCart(w http.ResponseWriter, r *http.Request, status int, cart models.Cart) {
+	resp, err := json.Marshal(cart)
+	if err != nil {
+		log.Printf("ERROR serializing cart of customer %d: %v", cart.CustomerID, err)
+		WriteError(w, r, http.StatusInternalServerError, "failed to serialize cart")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	w.Write(resp)
+}
+
+// maps cart and checkout errors to HTTP responses; a missing row is
+// the book the request names not being in the cart
+func writeCartError(w http.ResponseWriter, r *http.Request, err error, fallback string) {
+	if errors.Is(err, sql.ErrNoRows) {
+		writeErrorCode(w, r, http.StatusNotFound, CodeNotFound, "book is not in the cart")
+		return
+	}
+	writeStoreError(w, r, err, fallback)
+}
@@ -0,0 +1,201 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"log"
+	"net/http"
+	"time"
+
+	"online_bookStore/Interfaces"
+	"online_bookStore/models"
+)
+
+type CouponHandler struct {
+	CouponStore interfaces.CouponStore
+}
+
+func NewCouponHandler(couponStore interfaces.CouponStore) *CouponHandler {
+	return &CouponHandler{
+		CouponStore: couponStore,
+	}
+}
+
+/*
+	ROUTE: /coupons
+*/
+func (h *CouponHandler) CouponsHandler(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		h.getCoupons(w, r)
+	case http.MethodPost:
+		h.createCoupon(w, r)
+	default:
+		WriteError(w, r, http.StatusMethodNotAllowed, "method not allowed")
+	}
+}
+
+/*
+	ROUTE: /coupons/{id}
+*/
+func (h *CouponHandler) CouponByIDHandler(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		h.getCouponByID(w, r)
+	case http.MethodPut:
+		h.updateCoupon(w, r)
+	case http.MethodDelete:
+		h.deleteCoupon(w, r)
+	default:
+		WriteError(w, r, http.StatusMethodNotAllowed, "method not allowed")
+	}
+}
+
+/*
+	GET /coupons
+*/
+func (h *CouponHandler) getCoupons(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithTimeout(r.Context(), 3*time.Second)
+	defer cancel()
+
+	coupons, err := h.CouponStore.GetAllCoupons(ctx)
+	if err != nil {
+		writeStoreError(w, r, err, "failed to fetch coupons")
+		return
+	}
+
+	writeCoupon(w, r, http.StatusOK, coupons)
+}
+
+/*
+	GET /coupons/{id}
+*/
+func (h *CouponHandler) getCouponByID(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithTimeout(r.Context(), 3*time.Second)
+	defer cancel()
+
+	id, err := parseID(r.URL.Path, "/coupons/")
+	if err != nil {
+		WriteError(w, r, http.StatusBadRequest, "invalid coupon id")
+		return
+	}
+
+	coupon, err := h.CouponStore.GetCoupon(ctx, id)
+	if err != nil {
+		writeStoreError(w, r, err, "failed to fetch coupon")
+		return
+	}
+
+	writeCoupon(w, r, http.StatusOK, coupon)
+}
+
+/*
+	POST /coupons
+*/
+func (h *CouponHandler) createCoupon(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithTimeout(r.Context(), 3*time.Second)
+	defer cancel()
+
+	coupon, ok := readCoupon(w, r)
+	if !ok {
+		return
+	}
+
+	created, err := h.CouponStore.CreateCoupon(ctx, coupon)
+	if err != nil {
+		writeStoreError(w, r, err, "failed to create coupon")
+		return
+	}
+
+	//  significant business log
+	log.Printf("COUPON CREATED id=%d code=%s type=%s", created.ID, created.Code, created.Type)
+
+	writeCoupon(w, r, http.StatusCreated, created)
+}
+
+/*
+	PUT /coupons/{id}
+*/
+func (h *CouponHandler) updateCoupon(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithTimeout(r.Context(), 3*time.Second)
+	defer cancel()
+
+	id, err := parseID(r.URL.Path, "/coupons/")
+	if err != nil {
+		WriteError(w, r, http.StatusBadRequest, "invalid coupon id")
+		return
+	}
+
+	coupon, ok := readCoupon(w, r)
+	if !ok {
+		return
+	}
+
+	updated, err := h.CouponStore.UpdateCoupon(ctx, id, coupon)
+	if err != nil {
+		writeStoreError(w, r, err, "failed to update coupon")
+		return
+	}
+
+	//  significant business log
+	log.Printf("COUPON UPDATED id=%d code=%s active=%t", id, updated.Code, updated.Active)
+
+	writeCoupon(w, r, http.StatusOK, updated)
+}
+
+/*
+	DELETE /coupons/{id}
+*/
+func (h *CouponHandler) deleteCoupon(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithTimeout(r.Context(), 3*time.Second)
+	defer cancel()
+
+	id, err := parseID(r.URL.Path, "/coupons/")
+	if err != nil {
+		WriteError(w, r, http.StatusBadRequest, "invalid coupon id")
+		return
+	}
+
+	if err := h.CouponStore.DeleteCoupon(ctx, id); err != nil {
+		writeStoreError(w, r, err, "failed to delete coupon")
+		return
+	}
+
+	//  significant business log
+	log.Printf("COUPON DELETED id=%d", id)
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// new coupons are active unless the payload says otherwise
+func readCoupon(w http.ResponseWriter, r *http.Request) (models.Coupon, bool) {
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		log.Printf("ERROR reading coupon body: %v", err)
+		WriteError(w, r, http.StatusBadRequest, "invalid request body")
+		return models.Coupon{}, false
+	}
+
+	coupon := models.Coupon{Active: true}
+	if err := json.Unmarshal(body, &coupon); err != nil {
+		log.Printf("ERROR unmarshalling coupon: %v", err)
+		writeJSONError(w, r, err)
+		return models.Coupon{}, false
+	}
+
+	return coupon, true
+}
+
+func writeCoupon(w http.ResponseWriter, r *http.Request, status int, v interface{}) {
+	resp, err := json.Marshal(v)
+	if err != nil {
+		log.Printf("ERROR serializing coupon: %v", err)
+		WriteError(w, r, http.StatusInternalServerError, "failed to serialize coupon")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	w.Write(resp)
+}
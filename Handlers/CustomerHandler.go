@@ -37,20 +37,19 @@ func (h *CustomerHandler) CustomersHandler(w http.ResponseWriter, r *http.Reques
 }
 
 func (h *CustomerHandler) getCustomers(w http.ResponseWriter, r *http.Request) {
-	ctx, cancel := context.WithTimeout(r.Context(), 3*time.Second)
+	ctx, cancel := context.WithTimeout(readContext(r), 3*time.Second)
 	defer cancel()
 
 	customers, err := h.CustomerStore.GetAllCustomers(ctx)
 	if err != nil {
-		log.Printf("ERROR fetching customers: %v", err)
-		WriteError(w, http.StatusInternalServerError, "failed to fetch customers")
+		writeStoreError(w, r, err, "failed to fetch customers")
 		return
 	}
 
 	resp, err := json.Marshal(customers)
 	if err != nil {
 		log.Printf("ERROR serializing customers: %v", err)
-		WriteError(w, http.StatusInternalServerError, "failed to serialize customers")
+		WriteError(w, r, http.StatusInternalServerError, "failed to serialize customers")
 		return
 	}
 
@@ -58,8 +57,22 @@ func (h *CustomerHandler) getCustomers(w http.ResponseWriter, r *http.Request) {
 	w.Write(resp)
 }
 
-// /customers/{id}
+// /customers/{id}, /customers/{id}/restore and /customers/{id}/addresses[/{addressID}]
 func (h *CustomerHandler) CustomersByIDHandler(w http.ResponseWriter, r *http.Request) {
+	if strings.Contains(strings.TrimPrefix(r.URL.Path, "/customers/"), "/addresses") {
+		h.addressesRouter(w, r)
+		return
+	}
+
+	if strings.HasSuffix(r.URL.Path, "/restore") {
+		if r.Method != http.MethodPost {
+			WriteError(w, r, http.StatusMethodNotAllowed, "method not allowed")
+			return
+		}
+		h.restoreCustomer(w, r)
+		return
+	}
+
 	switch r.Method {
 	case http.MethodGet:
 		h.getCustomerByID(w, r)
@@ -73,27 +86,31 @@ func (h *CustomerHandler) CustomersByIDHandler(w http.ResponseWriter, r *http.Re
 }
 
 func (h *CustomerHandler) getCustomerByID(w http.ResponseWriter, r *http.Request) {
-	ctx, cancel := context.WithTimeout(r.Context(), 3*time.Second)
+	ctx, cancel := context.WithTimeout(readContext(r), 3*time.Second)
 	defer cancel()
 
 	idStr := strings.TrimPrefix(r.URL.Path, "/customers/")
 	id, err := strconv.Atoi(idStr)
 	if err != nil {
-		WriteError(w, http.StatusBadRequest, "invalid customer id")
+		WriteError(w, r, http.StatusBadRequest, "invalid customer id")
+		return
+	}
+
+	if !canAccessCustomer(r, id) {
+		WriteError(w, r, http.StatusForbidden, "access to this customer is not allowed")
 		return
 	}
 
 	customer, err := h.CustomerStore.GetCustomer(ctx, id)
 	if err != nil {
-		log.Printf("ERROR fetching customer %d: %v", id, err)
-		WriteError(w, http.StatusNotFound, "customer not found")
+		writeStoreError(w, r, err, "failed to fetch customer")
 		return
 	}
 
 	resp, err := json.Marshal(customer)
 	if err != nil {
 		log.Printf("ERROR serializing customer %d: %v", id, err)
-		WriteError(w, http.StatusInternalServerError, "failed to serialize customer")
+		WriteError(w, r, http.StatusInternalServerError, "failed to serialize customer")
 		return
 	}
 
@@ -108,14 +125,19 @@ func (h *CustomerHandler) updateCustomer(w http.ResponseWriter, r *http.Request)
 	idStr := strings.TrimPrefix(r.URL.Path, "/customers/")
 	id, err := strconv.Atoi(idStr)
 	if err != nil {
-		WriteError(w, http.StatusBadRequest, "invalid customer id")
+		WriteError(w, r, http.StatusBadRequest, "invalid customer id")
+		return
+	}
+
+	if !canAccessCustomer(r, id) {
+		WriteError(w, r, http.StatusForbidden, "access to this customer is not allowed")
 		return
 	}
 
 	body, err := io.ReadAll(r.Body)
 	if err != nil {
 		log.Printf("ERROR reading update customer body: %v", err)
-		WriteError(w, http.StatusBadRequest, "failed to read request body")
+		WriteError(w, r, http.StatusBadRequest, "failed to read request body")
 		return
 	}
 
@@ -123,14 +145,13 @@ func (h *CustomerHandler) updateCustomer(w http.ResponseWriter, r *http.Request)
 	err = json.Unmarshal(body, &customer)
 	if err != nil {
 		log.Printf("ERROR unmarshalling customer %d: %v", id, err)
-		WriteError(w, http.StatusBadRequest, "invalid JSON body")
+		writeJSONError(w, r, err)
 		return
 	}
 
 	updatedCustomer, err := h.CustomerStore.UpdateCustomer(ctx, id, customer)
 	if err != nil {
-		log.Printf("ERROR updating customer %d: %v", id, err)
-		WriteError(w, http.StatusNotFound, "customer not found")
+		writeStoreError(w, r, err, "failed to update customer")
 		return
 	}
 
@@ -140,7 +161,7 @@ func (h *CustomerHandler) updateCustomer(w http.ResponseWriter, r *http.Request)
 	resp, err := json.Marshal(updatedCustomer)
 	if err != nil {
 		log.Printf("ERROR serializing updated customer %d: %v", id, err)
-		WriteError(w, http.StatusInternalServerError, "failed to serialize customer")
+		WriteError(w, r, http.StatusInternalServerError, "failed to serialize customer")
 		return
 	}
 
@@ -155,7 +176,7 @@ func (h *CustomerHandler) createCustomer(w http.ResponseWriter, r *http.Request)
 	body, err := io.ReadAll(r.Body)
 	if err != nil {
 		log.Printf("ERROR reading create customer body: %v", err)
-		WriteError(w, http.StatusBadRequest, "failed to read request body")
+		WriteError(w, r, http.StatusBadRequest, "failed to read request body")
 		return
 	}
 
@@ -163,14 +184,13 @@ func (h *CustomerHandler) createCustomer(w http.ResponseWriter, r *http.Request)
 	err = json.Unmarshal(body, &customer)
 	if err != nil {
 		log.Printf("ERROR unmarshalling customer: %v", err)
-		WriteError(w, http.StatusBadRequest, "invalid JSON body")
+		writeJSONError(w, r, err)
 		return
 	}
 
 	createdCustomer, err := h.CustomerStore.CreateCustomer(ctx, customer)
 	if err != nil {
-		log.Printf("ERROR creating customer: %v", err)
-		WriteError(w, http.StatusInternalServerError, "failed to create customer")
+		writeStoreError(w, r, err, "failed to create customer")
 		return
 	}
 
@@ -180,7 +200,7 @@ func (h *CustomerHandler) createCustomer(w http.ResponseWriter, r *http.Request)
 	resp, err := json.Marshal(createdCustomer)
 	if err != nil {
 		log.Printf("ERROR serializing created customer: %v", err)
-		WriteError(w, http.StatusInternalServerError, "failed to serialize customer")
+		WriteError(w, r, http.StatusInternalServerError, "failed to serialize customer")
 		return
 	}
 
@@ -193,22 +213,228 @@ func (h *CustomerHandler) deleteCustomer(w http.ResponseWriter, r *http.Request)
 	ctx, cancel := context.WithTimeout(r.Context(), 3*time.Second)
 	defer cancel()
 
+	if !isAdmin(r) {
+		WriteError(w, r, http.StatusForbidden, "admin role required")
+		return
+	}
+
 	idStr := strings.TrimPrefix(r.URL.Path, "/customers/")
 	id, err := strconv.Atoi(idStr)
 	if err != nil {
-		WriteError(w, http.StatusBadRequest, "invalid customer id")
+		WriteError(w, r, http.StatusBadRequest, "invalid customer id")
 		return
 	}
 
-	err = h.CustomerStore.DeleteCustomer(ctx, id)
+	force := r.URL.Query().Get("force") == "true"
+
+	err = h.CustomerStore.DeleteCustomer(ctx, id, force)
 	if err != nil {
-		log.Printf("ERROR deleting customer %d: %v", id, err)
-		WriteError(w, http.StatusNotFound, "customer not found")
+		writeStoreError(w, r, err, "failed to delete customer")
 		return
 	}
 
 	// significant business event
-	log.Printf("CUSTOMER DELETED id=%d", id)
+	log.Printf("CUSTOMER DELETED id=%d force=%t", id, force)
 
 	w.WriteHeader(http.StatusNoContent)
 }
+
+// POST /customers/{id}/restore
+func (h *CustomerHandler) restoreCustomer(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithTimeout(r.Context(), 3*time.Second)
+	defer cancel()
+
+	if !isAdmin(r) {
+		WriteError(w, r, http.StatusForbidden, "admin role required")
+		return
+	}
+
+	idStr := strings.TrimSuffix(strings.TrimPrefix(r.URL.Path, "/customers/"), "/restore")
+	id, err := strconv.Atoi(idStr)
+	if err != nil {
+		WriteError(w, r, http.StatusBadRequest, "invalid customer id")
+		return
+	}
+
+	customer, err := h.CustomerStore.RestoreCustomer(ctx, id)
+	if err != nil {
+		writeStoreError(w, r, err, "failed to restore customer")
+		return
+	}
+
+	// significant business event
+	log.Printf("CUSTOMER RESTORED id=%d", id)
+
+	resp, err := json.Marshal(customer)
+	if err != nil {
+		log.Printf("ERROR serializing customer %d: %v", id, err)
+		WriteError(w, r, http.StatusInternalServerError, "failed to serialize customer")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Write(resp)
+}
+
+// /customers/{id}/addresses and /customers/{id}/addresses/{addressID}
+func (h *CustomerHandler) addressesRouter(w http.ResponseWriter, r *http.Request) {
+	parts := strings.Split(strings.Trim(strings.TrimPrefix(r.URL.Path, "/customers/"), "/"), "/")
+	if len(parts) < 2 || len(parts) > 3 || parts[1] != "addresses" {
+		WriteError(w, r, http.StatusNotFound, "not found")
+		return
+	}
+
+	customerID, err := strconv.Atoi(parts[0])
+	if err != nil {
+		WriteError(w, r, http.StatusBadRequest, "invalid customer id")
+		return
+	}
+
+	if !canAccessCustomer(r, customerID) {
+		WriteError(w, r, http.StatusForbidden, "access to this customer is not allowed")
+		return
+	}
+
+	if len(parts) == 2 {
+		switch r.Method {
+		case http.MethodGet:
+			h.getAddresses(w, r, customerID)
+		case http.MethodPost:
+			h.addAddress(w, r, customerID)
+		default:
+			WriteError(w, r, http.StatusMethodNotAllowed, "method not allowed")
+		}
+		return
+	}
+
+	addressID, err := strconv.Atoi(parts[2])
+	if err != nil {
+		WriteError(w, r, http.StatusBadRequest, "invalid address id")
+		return
+	}
+
+	switch r.Method {
+	case http.MethodGet:
+		h.getAddress(w, r, customerID, addressID)
+	case http.MethodPut:
+		h.updateAddress(w, r, customerID, addressID)
+	case http.MethodDelete:
+		h.deleteAddress(w, r, customerID, addressID)
+	default:
+		WriteError(w, r, http.StatusMethodNotAllowed, "method not allowed")
+	}
+}
+
+func (h *CustomerHandler) getAddresses(w http.ResponseWriter, r *http.Request, customerID int) {
+	ctx, cancel := context.WithTimeout(r.Context(), 3*time.Second)
+	defer cancel()
+
+	addresses, err := h.CustomerStore.GetAddresses(ctx, customerID)
+	if err != nil {
+		writeStoreError(w, r, err, "failed to fetch addresses")
+		return
+	}
+
+	writeAddress(w, r, http.StatusOK, addresses)
+}
+
+func (h *CustomerHandler) getAddress(w http.ResponseWriter, r *http.Request, customerID int, addressID int) {
+	ctx, cancel := context.WithTimeout(r.Context(), 3*time.Second)
+	defer cancel()
+
+	address, err := h.CustomerStore.GetAddress(ctx, customerID, addressID)
+	if err != nil {
+		writeStoreError(w, r, err, "failed to fetch address")
+		return
+	}
+
+	writeAddress(w, r, http.StatusOK, address)
+}
+
+func (h *CustomerHandler) addAddress(w http.ResponseWriter, r *http.Request, customerID int) {
+	ctx, cancel := context.WithTimeout(r.Context(), 3*time.Second)
+	defer cancel()
+
+	address, ok := readCustomerAddress(w, r)
+	if !ok {
+		return
+	}
+
+	created, err := h.CustomerStore.AddAddress(ctx, customerID, address)
+	if err != nil {
+		writeStoreError(w, r, err, "failed to add address")
+		return
+	}
+
+	// significant business event
+	log.Printf("ADDRESS ADDED customer=%d id=%d label=%s", customerID, created.ID, created.Label)
+
+	writeAddress(w, r, http.StatusCreated, created)
+}
+
+func (h *CustomerHandler) updateAddress(w http.ResponseWriter, r *http.Request, customerID int, addressID int) {
+	ctx, cancel := context.WithTimeout(r.Context(), 3*time.Second)
+	defer cancel()
+
+	address, ok := readCustomerAddress(w, r)
+	if !ok {
+		return
+	}
+
+	updated, err := h.CustomerStore.UpdateAddress(ctx, customerID, addressID, address)
+	if err != nil {
+		writeStoreError(w, r, err, "failed to update address")
+		return
+	}
+
+	// significant business event
+	log.Printf("ADDRESS UPDATED customer=%d id=%d", customerID, addressID)
+
+	writeAddress(w, r, http.StatusOK, updated)
+}
+
+func (h *CustomerHandler) deleteAddress(w http.ResponseWriter, r *http.Request, customerID int, addressID int) {
+	ctx, cancel := context.WithTimeout(r.Context(), 3*time.Second)
+	defer cancel()
+
+	if err := h.CustomerStore.DeleteAddress(ctx, customerID, addressID); err != nil {
+		writeStoreError(w, r, err, "failed to delete address")
+		return
+	}
+
+	// significant business event
+	log.Printf("ADDRESS DELETED customer=%d id=%d", customerID, addressID)
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func readCustomerAddress(w http.ResponseWriter, r *http.Request) (models.CustomerAddress, bool) {
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		log.Printf("ERROR reading address body: %v", err)
+		WriteError(w, r, http.StatusBadRequest, "failed to read request body")
+		return models.CustomerAddress{}, false
+	}
+
+	var address models.CustomerAddress
+	if err := json.Unmarshal(body, &address); err != nil {
+		log.Printf("ERROR unmarshalling address: %v", err)
+		writeJSONError(w, r, err)
+		return models.CustomerAddress{}, false
+	}
+
+	return address, true
+}
+
+func writeAddress(w http.ResponseWriter, r *http.Request, status int, v interface{}) {
+	resp, err := json.Marshal(v)
+	if err != nil {
+		log.Printf("ERROR serializing address: %v", err)
+		WriteError(w, r, http.StatusInternalServerError, "failed to serialize address")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	w.Write(resp)
+}
@@ -1,17 +1,302 @@
 package handlers
 import (
+	"database/sql"
 	"encoding/json"
+	"errors"
+	"log"
 	"net/http"
+	"strings"
+
+	"online_bookStore/models"
+	"online_bookStore/services"
 )
+// ErrorResponse is the legacy error body, still sent to clients that ask
+// for application/json only
 type ErrorResponse struct {
 	Error string `json:"error"`
+	Code  string `json:"code,omitempty"`
+}
+
+// Problem is an RFC 7807 problem details body. Errors lists the offending
+// fields of a validation failure; BookIDs and Blockers carry the details of
+// insufficient stock and of deletes refused because of dependent records.
+type Problem struct {
+	Type      string              `json:"type"`
+	Title     string              `json:"title"`
+	Status    int                 `json:"status"`
+	Detail    string              `json:"detail,omitempty"`
+	Instance  string              `json:"instance,omitempty"`
+	Code      string              `json:"code,omitempty"`
+	RequestID string              `json:"request_id,omitempty"`
+	Errors    []models.FieldError `json:"errors,omitempty"`
+	BookIDs   []int               `json:"book_ids,omitempty"`
+	Blockers  []models.Blocker    `json:"blockers,omitempty"`
 }
 
-func WriteError(w http.ResponseWriter, status int,message string){
-	w.Header().Set("Content-Type","application/json")
-	w.WriteHeader(status)
-	json.NewEncoder(w).Encode(ErrorResponse{
-       Error : message,
+const problemContentType = "application/problem+json"
+
+func WriteError(w http.ResponseWriter, r *http.Request, status int,message string){
+	writeErrorCode(w, r, status, statusCode(status), message)
+}
+
+func writeErrorCode(w http.ResponseWriter, r *http.Request, status int, code string, message string) {
+	writeProblem(w, r, Problem{
+		Status: status,
+		Code:   code,
+		Detail: message,
 	})
+}
+
+// writeProblem fills in the type, title, instance and request ID of p and
+// sends it as application/problem+json, or in the legacy shape of
+// ErrorResponse, InsufficientStockResponse or DependentsResponse when the
+// client only accepts application/json
+func writeProblem(w http.ResponseWriter, r *http.Request, p Problem) {
+	if p.Code == "" {
+		p.Code = statusCode(p.Status)
+	}
+	p.Type = "about:blank"
+	if p.Code != "" {
+		p.Type = "/problems/" + p.Code
+	}
+	p.Title = http.StatusText(p.Status)
+	p.Instance = r.URL.Path
+	p.RequestID = RequestIDFromContext(r.Context())
+
+	if !wantsProblem(r) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(p.Status)
+		json.NewEncoder(w).Encode(legacyError(p))
+		return
+	}
+
+	w.Header().Set("Content-Type", problemContentType)
+	w.WriteHeader(p.Status)
+	json.NewEncoder(w).Encode(p)
+}
+
+// wantsProblem reports whether the client accepts problem details: it does
+// unless every media range of its Accept header is plain application/json.
+// Headers such as "application/json, text/plain, */*" that HTTP libraries
+// send by default get problem details too.
+func wantsProblem(r *http.Request) bool {
+	accept := r.Header.Get("Accept")
+	if accept == "" {
+		return true
+	}
+	for _, mediaRange := range strings.Split(accept, ",") {
+		mediaType, _, _ := strings.Cut(mediaRange, ";")
+		if !strings.EqualFold(strings.TrimSpace(mediaType), "application/json") {
+			return true
+		}
+	}
+	return false
+}
+
+func legacyError(p Problem) interface{} {
+	switch {
+	case p.Blockers != nil:
+		return DependentsResponse{Error: p.Detail, Code: p.Code, Blockers: p.Blockers}
+	case p.BookIDs != nil:
+		return InsufficientStockResponse{Error: p.Detail, Code: p.Code, BookIDs: p.BookIDs}
+	}
+	return ErrorResponse{Error: p.Detail, Code: p.Code}
+}
+
+type InsufficientStockResponse struct {
+	Error   string `json:"error"`
+	Code    string `json:"code,omitempty"`
+	BookIDs []int  `json:"book_ids"`
+}
 
-}
\ No newline at end of file
+type DependentsResponse struct {
+	Error    string           `json:"error"`
+	Code     string           `json:"code,omitempty"`
+	Blockers []models.Blocker `json:"blockers"`
+}
+
+// Machine-readable error codes sent next to the message. Errors without
+// a specific code get the one of their status.
+const (
+	CodeBadRequest        = "bad_request"
+	CodeValidation        = "validation_failed"
+	CodeUnauthorized      = "unauthorized"
+	CodeForbidden         = "forbidden"
+	CodeNotFound          = "not_found"
+	CodeMethodNotAllowed  = "method_not_allowed"
+	CodeConflict          = "conflict"
+	CodeInsufficientStock = "insufficient_stock"
+	CodeHasDependents     = "has_dependents"
+	CodeInvalidTransition = "invalid_transition"
+	CodeUnprocessable     = "unprocessable"
+	CodeTooManyRequests   = "too_many_requests"
+	CodeInternal          = "internal_error"
+	CodeGateway           = "payment_gateway_error"
+	CodeUnavailable       = "unavailable"
+)
+
+func statusCode(status int) string {
+	switch status {
+	case http.StatusBadRequest:
+		return CodeBadRequest
+	case http.StatusUnauthorized:
+		return CodeUnauthorized
+	case http.StatusForbidden:
+		return CodeForbidden
+	case http.StatusNotFound:
+		return CodeNotFound
+	case http.StatusMethodNotAllowed:
+		return CodeMethodNotAllowed
+	case http.StatusConflict:
+		return CodeConflict
+	case http.StatusUnprocessableEntity:
+		return CodeUnprocessable
+	case http.StatusTooManyRequests:
+		return CodeTooManyRequests
+	case http.StatusBadGateway:
+		return CodeGateway
+	case http.StatusServiceUnavailable:
+		return CodeUnavailable
+	}
+	if status >= 500 {
+		return CodeInternal
+	}
+	return ""
+}
+
+// sentinelErrors gives the status and code of the errors the stores and
+// services return by value
+var sentinelErrors = []struct {
+	err    error
+	status int
+	code   string
+}{
+	{models.ErrEmptyOrder, http.StatusBadRequest, "empty_order"},
+	{models.ErrInvalidQuantity, http.StatusBadRequest, "invalid_quantity"},
+	{models.ErrUnknownBook, http.StatusBadRequest, "unknown_book"},
+	{models.ErrInvalidGenre, http.StatusBadRequest, "invalid_genre"},
+	{models.ErrInvalidContributor, http.StatusBadRequest, "invalid_contributor"},
+	{models.ErrUnknownCustomer, http.StatusBadRequest, "unknown_customer"},
+	{models.ErrUnknownStatus, http.StatusBadRequest, "unknown_status"},
+	{models.ErrEmptyCart, http.StatusBadRequest, "empty_cart"},
+	{models.ErrInvalidAddress, http.StatusBadRequest, "invalid_address"},
+	{models.ErrUnknownAddress, http.StatusBadRequest, "unknown_address"},
+	{models.ErrInvalidCoupon, http.StatusBadRequest, "invalid_coupon"},
+	{models.ErrMissingToken, http.StatusBadRequest, "missing_token"},
+	{models.ErrInvalidReturn, http.StatusBadRequest, "invalid_return"},
+	{models.ErrInvalidRefund, http.StatusBadRequest, "invalid_refund"},
+	{services.ErrWeakPassword, http.StatusBadRequest, "weak_password"},
+	{services.ErrInvalidRole, http.StatusBadRequest, "invalid_role"},
+	{services.ErrInvalidEmail, http.StatusBadRequest, "invalid_email"},
+	{services.ErrUnknownCustomer, http.StatusBadRequest, "unknown_customer"},
+	{services.ErrSelfLockout, http.StatusForbidden, "self_lockout"},
+	{models.ErrLastAddress, http.StatusConflict, "last_address"},
+	{models.ErrCouponCodeTaken, http.StatusConflict, "coupon_code_taken"},
+	{services.ErrEmailTaken, http.StatusConflict, "email_taken"},
+	{models.ErrOrderNotPayable, http.StatusConflict, "order_not_payable"},
+	{models.ErrOrderNotReturnable, http.StatusConflict, "order_not_returnable"},
+	{models.ErrOrderNotDeletable, http.StatusConflict, "order_not_deletable"},
+	{models.ErrReturnReviewed, http.StatusConflict, "return_reviewed"},
+	{models.ErrTotalMismatch, http.StatusUnprocessableEntity, "total_mismatch"},
+	{models.ErrUnknownCoupon, http.StatusUnprocessableEntity, "unknown_coupon"},
+	{models.ErrCouponNotApplicable, http.StatusUnprocessableEntity, "coupon_not_applicable"},
+	{models.ErrNoShippingRate, http.StatusUnprocessableEntity, "no_shipping_rate"},
+	{models.ErrRefundTooLarge, http.StatusUnprocessableEntity, "refund_too_large"},
+}
+
+// errorStatus maps an error of the stores and services to its HTTP status
+// and error code; 500 for the errors it does not know
+func errorStatus(err error) (int, string) {
+	var (
+		notFound      *models.NotFoundError
+		conflict      *models.ConflictError
+		validation    *models.ValidationError
+		unavailable   *models.UnavailableError
+		dependentsErr *models.DependentsError
+		stockErr      *models.InsufficientStockError
+		transitionErr *models.InvalidTransitionError
+		gatewayErr    *models.GatewayError
+	)
+	switch {
+	case errors.As(err, &unavailable):
+		return http.StatusServiceUnavailable, CodeUnavailable
+	case errors.As(err, &conflict):
+		return http.StatusConflict, CodeConflict
+	case errors.As(err, &dependentsErr):
+		return http.StatusConflict, CodeHasDependents
+	case errors.As(err, &stockErr):
+		return http.StatusConflict, CodeInsufficientStock
+	case errors.As(err, &transitionErr):
+		return http.StatusConflict, CodeInvalidTransition
+	case errors.As(err, &gatewayErr):
+		return http.StatusBadGateway, CodeGateway
+	}
+
+	// validation errors wrapping a sentinel keep the code of the sentinel
+	for _, s := range sentinelErrors {
+		if errors.Is(err, s.err) {
+			return s.status, s.code
+		}
+	}
+	if errors.As(err, &validation) {
+		return http.StatusBadRequest, CodeValidation
+	}
+
+	if errors.As(err, &notFound) || errors.Is(err, sql.ErrNoRows) {
+		return http.StatusNotFound, CodeNotFound
+	}
+	return http.StatusInternalServerError, CodeInternal
+}
+
+// writeStoreError answers err with the status and code of errorStatus,
+// listing the offending fields of validation errors and conflicts.
+// Server-side failures are logged and answered with fallback so no
+// internal detail leaks.
+func writeStoreError(w http.ResponseWriter, r *http.Request, err error, fallback string) {
+	status, code := errorStatus(err)
+	p := Problem{Status: status, Code: code, Detail: err.Error()}
+
+	var (
+		notFound      *models.NotFoundError
+		validation    *models.ValidationError
+		conflict      *models.ConflictError
+		dependentsErr *models.DependentsError
+		stockErr      *models.InsufficientStockError
+	)
+	switch {
+	case status >= 500 && status != http.StatusBadGateway:
+		log.Printf("ERROR %s: %v", fallback, err)
+		p.Detail = fallback
+		if status == http.StatusServiceUnavailable {
+			w.Header().Set("Retry-After", "1")
+			p.Detail = fallback + ": service temporarily unavailable"
+		}
+	case errors.As(err, &dependentsErr):
+		p.Detail = dependentsErr.Entity + " still has dependent records; retry with ?force=true to delete anyway"
+		p.Blockers = dependentsErr.Blockers
+	case errors.As(err, &stockErr):
+		p.Detail = "insufficient stock"
+		p.BookIDs = stockErr.BookIDs
+	case status == http.StatusNotFound && !errors.As(err, &notFound):
+		// a bare sql.ErrNoRows says nothing worth showing
+		p.Detail = "not found"
+	case errors.As(err, &validation):
+		p.Errors = validation.Fields
+	case errors.As(err, &conflict) && conflict.Field != "":
+		p.Errors = []models.FieldError{{Field: conflict.Field, Message: "is already taken"}}
+	}
+	writeProblem(w, r, p)
+}
+
+// writeJSONError answers a request body that could not be decoded, naming
+// the field of a value of the wrong type
+func writeJSONError(w http.ResponseWriter, r *http.Request, err error) {
+	p := Problem{Status: http.StatusBadRequest, Code: CodeBadRequest, Detail: "invalid JSON body"}
+
+	var typeErr *json.UnmarshalTypeError
+	if errors.As(err, &typeErr) && typeErr.Field != "" {
+		p.Code = CodeValidation
+		p.Errors = []models.FieldError{{Field: typeErr.Field, Message: "expected " + typeErr.Type.String() + ", got " + typeErr.Value}}
+	}
+	writeProblem(w, r, p)
+}
@@ -0,0 +1,48 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+	"net/http"
+	"time"
+
+	"online_bookStore/Interfaces"
+)
+
+type GenreHandler struct {
+	genreStore interfaces.GenreStore
+}
+
+func NewGenreHandler(genreStore interfaces.GenreStore) *GenreHandler {
+	return &GenreHandler{
+		genreStore: genreStore,
+	}
+}
+
+// GET /genres lists the genres with the number of books in each
+func (h *GenreHandler) GenresHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		WriteError(w, r, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 3*time.Second)
+	defer cancel()
+
+	genres, err := h.genreStore.ListGenres(ctx)
+	if err != nil {
+		writeStoreError(w, r, err, "failed to fetch genres")
+		return
+	}
+
+	resp, err := json.Marshal(genres)
+	if err != nil {
+		log.Printf("ERROR serializing genres: %v", err)
+		WriteError(w, r, http.StatusInternalServerError, "failed to serialize genres")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Write(resp)
+}
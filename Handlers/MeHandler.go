@@ -0,0 +1,129 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+	"net/http"
+	"time"
+
+	"online_bookStore/Interfaces"
+	"online_bookStore/models"
+)
+
+// Endpoints scoped to the authenticated user and their linked customer
+type MeHandler struct {
+	customerStore interfaces.CustomerStore
+	orderStore    interfaces.OrderStore
+}
+
+func NewMeHandler(customerStore interfaces.CustomerStore, orderStore interfaces.OrderStore) *MeHandler {
+	return &MeHandler{
+		customerStore: customerStore,
+		orderStore:    orderStore,
+	}
+}
+
+// /me and /me/...
+func (h *MeHandler) MeRouter(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		WriteError(w, r, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+
+	switch r.URL.Path {
+	case "/me":
+		h.getProfile(w, r)
+	case "/me/orders":
+		h.getMyOrders(w, r)
+	case "/me/addresses":
+		h.getMyAddresses(w, r)
+	default:
+		WriteError(w, r, http.StatusNotFound, "not found")
+	}
+}
+
+func (h *MeHandler) getProfile(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithTimeout(r.Context(), 3*time.Second)
+	defer cancel()
+
+	user, _ := UserFromContext(r.Context())
+	profile := models.Profile{User: user}
+
+	if user.CustomerID != 0 {
+		customer, err := h.customerStore.GetCustomer(ctx, user.CustomerID)
+		if err != nil {
+			writeStoreError(w, r, err, "failed to fetch customer profile")
+			return
+		}
+		profile.Customer = &customer
+	}
+
+	resp, err := json.Marshal(profile)
+	if err != nil {
+		log.Printf("ERROR serializing profile of user %d: %v", user.ID, err)
+		WriteError(w, r, http.StatusInternalServerError, "failed to serialize profile")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Write(resp)
+}
+
+func (h *MeHandler) getMyOrders(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithTimeout(r.Context(), 3*time.Second)
+	defer cancel()
+
+	user, _ := UserFromContext(r.Context())
+	if user.CustomerID == 0 {
+		WriteError(w, r, http.StatusNotFound, "no customer profile is linked to this account")
+		return
+	}
+
+	orders, err := h.orderStore.GetOrdersByCustomer(ctx, user.CustomerID)
+	if err != nil {
+		writeStoreError(w, r, err, "failed to fetch orders")
+		return
+	}
+
+	if orders == nil {
+		orders = []models.Order{}
+	}
+
+	resp, err := json.Marshal(orders)
+	if err != nil {
+		log.Printf("ERROR serializing orders of customer %d: %v", user.CustomerID, err)
+		WriteError(w, r, http.StatusInternalServerError, "failed to serialize orders")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Write(resp)
+}
+
+func (h *MeHandler) getMyAddresses(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithTimeout(r.Context(), 3*time.Second)
+	defer cancel()
+
+	user, _ := UserFromContext(r.Context())
+	if user.CustomerID == 0 {
+		WriteError(w, r, http.StatusNotFound, "no customer profile is linked to this account")
+		return
+	}
+
+	addresses, err := h.customerStore.GetAddresses(ctx, user.CustomerID)
+	if err != nil {
+		writeStoreError(w, r, err, "failed to fetch addresses")
+		return
+	}
+
+	resp, err := json.Marshal(addresses)
+	if err != nil {
+		log.Printf("ERROR serializing addresses of customer %d: %v", user.CustomerID, err)
+		WriteError(w, r, http.StatusInternalServerError, "failed to serialize addresses")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Write(resp)
+}
@@ -3,6 +3,7 @@ package handlers
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"io"
 	"log"
 	"net/http"
@@ -12,15 +13,18 @@ import (
 
 	"online_bookStore/Interfaces"
 	"online_bookStore/models"
+	"online_bookStore/services"
 )
 
 type OrderHandler struct {
-	OrderStore interfaces.OrderStore
+	OrderStore     interfaces.OrderStore
+	PaymentService *services.PaymentService
 }
 
-func NewOrderHandler(OrderStore interfaces.OrderStore) *OrderHandler {
+func NewOrderHandler(OrderStore interfaces.OrderStore, paymentService *services.PaymentService) *OrderHandler {
 	return &OrderHandler{
-		OrderStore: OrderStore,
+		OrderStore:     OrderStore,
+		PaymentService: paymentService,
 	}
 }
 
@@ -36,8 +40,38 @@ func (h *OrderHandler) OrdersHandler(w http.ResponseWriter, r *http.Request) {
 	}
 }
 
-// /orders/{id}
+// /orders/{id}, /orders/{id}/history, /orders/{id}/payments and /orders/{id}/restore
 func (h *OrderHandler) OrdersByIDHandler(w http.ResponseWriter, r *http.Request) {
+	if strings.HasSuffix(r.URL.Path, "/restore") {
+		if r.Method != http.MethodPost {
+			WriteError(w, r, http.StatusMethodNotAllowed, "method not allowed")
+			return
+		}
+		h.restoreOrder(w, r)
+		return
+	}
+
+	if strings.HasSuffix(r.URL.Path, "/payments") {
+		switch r.Method {
+		case http.MethodGet:
+			h.getOrderPayments(w, r)
+		case http.MethodPost:
+			h.payOrder(w, r)
+		default:
+			WriteError(w, r, http.StatusMethodNotAllowed, "method not allowed")
+		}
+		return
+	}
+
+	if strings.HasSuffix(r.URL.Path, "/history") {
+		if r.Method != http.MethodGet {
+			WriteError(w, r, http.StatusMethodNotAllowed, "method not allowed")
+			return
+		}
+		h.getOrderHistory(w, r)
+		return
+	}
+
 	switch r.Method {
 	case http.MethodGet:
 		h.getOrderByID(w, r)
@@ -51,27 +85,31 @@ func (h *OrderHandler) OrdersByIDHandler(w http.ResponseWriter, r *http.Request)
 }
 
 func (h *OrderHandler) getOrderByID(w http.ResponseWriter, r *http.Request) {
-	ctx, cancel := context.WithTimeout(r.Context(), 3*time.Second)
+	ctx, cancel := context.WithTimeout(readContext(r), 3*time.Second)
 	defer cancel()
 
 	idStr := strings.TrimPrefix(r.URL.Path, "/orders/")
 	id, err := strconv.Atoi(idStr)
 	if err != nil {
-		WriteError(w, http.StatusBadRequest, "invalid order id")
+		WriteError(w, r, http.StatusBadRequest, "invalid order id")
 		return
 	}
 
 	order, err := h.OrderStore.GetOrder(ctx, id)
 	if err != nil {
-		log.Printf("ERROR fetching order %d: %v", id, err)
-		WriteError(w, http.StatusNotFound, "order not found")
+		writeStoreError(w, r, err, "failed to fetch order")
+		return
+	}
+
+	if !canAccessCustomer(r, order.Customer.ID) {
+		WriteError(w, r, http.StatusForbidden, "access to this order is not allowed")
 		return
 	}
 
 	resp, err := json.Marshal(order)
 	if err != nil {
 		log.Printf("ERROR serializing order %d: %v", id, err)
-		WriteError(w, http.StatusInternalServerError, "failed to serialize order")
+		WriteError(w, r, http.StatusInternalServerError, "failed to serialize order")
 		return
 	}
 
@@ -86,14 +124,14 @@ func (h *OrderHandler) updateOrder(w http.ResponseWriter, r *http.Request) {
 	idStr := strings.TrimPrefix(r.URL.Path, "/orders/")
 	id, err := strconv.Atoi(idStr)
 	if err != nil {
-		WriteError(w, http.StatusBadRequest, "invalid order id")
+		WriteError(w, r, http.StatusBadRequest, "invalid order id")
 		return
 	}
 
 	body, err := io.ReadAll(r.Body)
 	if err != nil {
 		log.Printf("ERROR reading update order body: %v", err)
-		WriteError(w, http.StatusBadRequest, "failed to read request body")
+		WriteError(w, r, http.StatusBadRequest, "failed to read request body")
 		return
 	}
 
@@ -101,24 +139,27 @@ func (h *OrderHandler) updateOrder(w http.ResponseWriter, r *http.Request) {
 	err = json.Unmarshal(body, &order)
 	if err != nil {
 		log.Printf("ERROR unmarshalling order %d: %v", id, err)
-		WriteError(w, http.StatusBadRequest, "invalid JSON body")
+		writeJSONError(w, r, err)
 		return
 	}
 
-	updatedOrder, err := h.OrderStore.UpdateOrderStatus(ctx, id, order.Status)
+	actor, _ := UserFromContext(r.Context())
+
+	// cancelling or refunding gives the captured payment back
+	updatedOrder, err := h.PaymentService.UpdateOrderStatus(ctx, id, order.Status, actor.ID)
+
 	if err != nil {
-		log.Printf("ERROR updating order %d: %v", id, err)
-		WriteError(w, http.StatusNotFound, "order not found")
+		writeStoreError(w, r, err, "failed to update order")
 		return
 	}
 
 	// significant business event
-	log.Printf("ORDER UPDATED id=%d status=%s", id, order.Status)
+	log.Printf("ORDER UPDATED id=%d status=%s by=%d", id, updatedOrder.Status, actor.ID)
 
 	resp, err := json.Marshal(updatedOrder)
 	if err != nil {
 		log.Printf("ERROR serializing updated order %d: %v", id, err)
-		WriteError(w, http.StatusInternalServerError, "failed to serialize order")
+		WriteError(w, r, http.StatusInternalServerError, "failed to serialize order")
 		return
 	}
 
@@ -133,7 +174,7 @@ func (h *OrderHandler) createOrder(w http.ResponseWriter, r *http.Request) {
 	body, err := io.ReadAll(r.Body)
 	if err != nil {
 		log.Printf("ERROR reading create order body: %v", err)
-		WriteError(w, http.StatusBadRequest, "failed to read request body")
+		WriteError(w, r, http.StatusBadRequest, "failed to read request body")
 		return
 	}
 
@@ -141,29 +182,55 @@ func (h *OrderHandler) createOrder(w http.ResponseWriter, r *http.Request) {
 	err = json.Unmarshal(body, &order)
 	if err != nil {
 		log.Printf("ERROR unmarshalling order: %v", err)
-		WriteError(w, http.StatusBadRequest, "invalid JSON body")
+		writeJSONError(w, r, err)
 		return
 	}
 
+	// shoppers always order for their own customer record
+	if !isAdmin(r) {
+		user, _ := UserFromContext(r.Context())
+		if user.CustomerID == 0 {
+			WriteError(w, r, http.StatusForbidden, "no customer profile is linked to this account")
+			return
+		}
+		if order.Customer.ID != 0 && order.Customer.ID != user.CustomerID {
+			WriteError(w, r, http.StatusForbidden, "orders can only be placed for your own customer profile")
+			return
+		}
+		order.Customer.ID = user.CustomerID
+	}
+
 	createdOrder, err := h.OrderStore.CreateOrder(ctx, order)
-	if err != nil {
-		log.Printf("ERROR creating order: %v", err)
-		WriteError(w, http.StatusInternalServerError, "failed to create order")
+
+	var stockErr *models.InsufficientStockError
+	var transitionErr *models.InvalidTransitionError
+	switch {
+	case errors.As(err, &transitionErr):
+		WriteError(w, r, http.StatusBadRequest, "new orders must have status \"pending\"")
+		return
+	case errors.As(err, &stockErr):
+		log.Printf("ORDER REJECTED insufficient stock books=%v", stockErr.BookIDs)
+		writeStoreError(w, r, err, "failed to create order")
+		return
+	case err != nil:
+		writeStoreError(w, r, err, "failed to create order")
 		return
 	}
 
 	//  significant business event
 	log.Printf(
-		"ORDER CREATED id=%d customer=%d total=%.2f",
+		"ORDER CREATED id=%d customer=%d total=%.2f discount=%.2f coupon=%s",
 		createdOrder.ID,
 		createdOrder.Customer.ID,
 		createdOrder.TotalPrice,
+		createdOrder.Discount,
+		createdOrder.CouponCode,
 	)
 
 	resp, err := json.Marshal(createdOrder)
 	if err != nil {
 		log.Printf("ERROR serializing created order: %v", err)
-		WriteError(w, http.StatusInternalServerError, "failed to serialize order")
+		WriteError(w, r, http.StatusInternalServerError, "failed to serialize order")
 		return
 	}
 
@@ -179,14 +246,13 @@ func (h *OrderHandler) deleteOrder(w http.ResponseWriter, r *http.Request) {
 	idStr := strings.TrimPrefix(r.URL.Path, "/orders/")
 	id, err := strconv.Atoi(idStr)
 	if err != nil {
-		WriteError(w, http.StatusBadRequest, "invalid order id")
+		WriteError(w, r, http.StatusBadRequest, "invalid order id")
 		return
 	}
 
 	err = h.OrderStore.DeleteOrder(ctx, id)
 	if err != nil {
-		log.Printf("ERROR deleting order %d: %v", id, err)
-		WriteError(w, http.StatusNotFound, "order not found")
+		writeStoreError(w, r, err, "failed to delete order")
 		return
 	}
 
@@ -197,23 +263,212 @@ func (h *OrderHandler) deleteOrder(w http.ResponseWriter, r *http.Request) {
 }
 
 func (h *OrderHandler) GetAllOrders(w http.ResponseWriter, r *http.Request) {
-	ctx, cancel := context.WithTimeout(r.Context(), 3*time.Second)
+	ctx, cancel := context.WithTimeout(readContext(r), 3*time.Second)
 	defer cancel()
 
-	orders, err := h.OrderStore.GetAllOrders(ctx)
+	// non-admins only see their own orders
+	var orders []models.Order
+	var err error
+	if isAdmin(r) {
+		orders, err = h.OrderStore.GetAllOrders(ctx)
+	} else if user, _ := UserFromContext(r.Context()); user.CustomerID != 0 {
+		orders, err = h.OrderStore.GetOrdersByCustomer(ctx, user.CustomerID)
+	}
 	if err != nil {
-		log.Printf("ERROR fetching orders: %v", err)
-		WriteError(w, http.StatusInternalServerError, "failed to fetch orders")
+		writeStoreError(w, r, err, "failed to fetch orders")
 		return
 	}
 
+	if orders == nil {
+		orders = []models.Order{}
+	}
+
 	resp, err := json.Marshal(orders)
 	if err != nil {
 		log.Printf("ERROR serializing orders: %v", err)
-		WriteError(w, http.StatusInternalServerError, "failed to serialize orders")
+		WriteError(w, r, http.StatusInternalServerError, "failed to serialize orders")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Write(resp)
+}
+
+// GET /orders/{id}/history
+func (h *OrderHandler) getOrderHistory(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithTimeout(readContext(r), 3*time.Second)
+	defer cancel()
+
+	idStr := strings.TrimSuffix(strings.TrimPrefix(r.URL.Path, "/orders/"), "/history")
+	id, err := strconv.Atoi(idStr)
+	if err != nil {
+		WriteError(w, r, http.StatusBadRequest, "invalid order id")
+		return
+	}
+
+	order, err := h.OrderStore.GetOrder(ctx, id)
+	if err != nil {
+		writeStoreError(w, r, err, "failed to fetch order")
+		return
+	}
+
+	if !canAccessCustomer(r, order.Customer.ID) {
+		WriteError(w, r, http.StatusForbidden, "access to this order is not allowed")
+		return
+	}
+
+	history, err := h.OrderStore.GetOrderStatusHistory(ctx, id)
+	if err != nil {
+		writeStoreError(w, r, err, "failed to fetch order history")
+		return
+	}
+
+	resp, err := json.Marshal(history)
+	if err != nil {
+		log.Printf("ERROR serializing history of order %d: %v", id, err)
+		WriteError(w, r, http.StatusInternalServerError, "failed to serialize order history")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Write(resp)
+}
+
+// POST /orders/{id}/restore (admin)
+func (h *OrderHandler) restoreOrder(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithTimeout(r.Context(), 3*time.Second)
+	defer cancel()
+
+	if !isAdmin(r) {
+		WriteError(w, r, http.StatusForbidden, "admin role required")
+		return
+	}
+
+	idStr := strings.TrimSuffix(strings.TrimPrefix(r.URL.Path, "/orders/"), "/restore")
+	id, err := strconv.Atoi(idStr)
+	if err != nil {
+		WriteError(w, r, http.StatusBadRequest, "invalid order id")
+		return
+	}
+
+	order, err := h.OrderStore.RestoreOrder(ctx, id)
+	if err != nil {
+		writeStoreError(w, r, err, "failed to restore order")
+		return
+	}
+
+	// significant business event
+	log.Printf("ORDER RESTORED id=%d", id)
+
+	resp, err := json.Marshal(order)
+	if err != nil {
+		log.Printf("ERROR serializing order %d: %v", id, err)
+		WriteError(w, r, http.StatusInternalServerError, "failed to serialize order")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Write(resp)
+}
+
+// orderForCaller loads the order named in /orders/{id}/{suffix} and checks
+// the caller may see it; it writes the error response when it cannot
+func (h *OrderHandler) orderForCaller(ctx context.Context, w http.ResponseWriter, r *http.Request, suffix string) (models.Order, bool) {
+	idStr := strings.TrimSuffix(strings.TrimPrefix(r.URL.Path, "/orders/"), suffix)
+	id, err := strconv.Atoi(idStr)
+	if err != nil {
+		WriteError(w, r, http.StatusBadRequest, "invalid order id")
+		return models.Order{}, false
+	}
+
+	order, err := h.OrderStore.GetOrder(ctx, id)
+	if err != nil {
+		writeStoreError(w, r, err, "failed to fetch order")
+		return models.Order{}, false
+	}
+
+	if !canAccessCustomer(r, order.Customer.ID) {
+		WriteError(w, r, http.StatusForbidden, "access to this order is not allowed")
+		return models.Order{}, false
+	}
+
+	return order, true
+}
+
+// GET /orders/{id}/payments
+func (h *OrderHandler) getOrderPayments(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithTimeout(r.Context(), 3*time.Second)
+	defer cancel()
+
+	order, ok := h.orderForCaller(ctx, w, r, "/payments")
+	if !ok {
+		return
+	}
+
+	payments, err := h.PaymentService.GetPaymentsByOrder(ctx, order.ID)
+	if err != nil {
+		writeStoreError(w, r, err, "failed to fetch payments")
+		return
+	}
+
+	writePayment(w, r, http.StatusOK, payments)
+}
+
+// POST /orders/{id}/payments
+func (h *OrderHandler) payOrder(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithTimeout(r.Context(), 3*time.Second)
+	defer cancel()
+
+	order, ok := h.orderForCaller(ctx, w, r, "/payments")
+	if !ok {
+		return
+	}
+
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		log.Printf("ERROR reading payment body: %v", err)
+		WriteError(w, r, http.StatusBadRequest, "failed to read request body")
+		return
+	}
+
+	var req models.PaymentRequest
+	if err := json.Unmarshal(body, &req); err != nil {
+		log.Printf("ERROR unmarshalling payment of order %d: %v", order.ID, err)
+		writeJSONError(w, r, err)
+		return
+	}
+
+	actor, _ := UserFromContext(r.Context())
+
+	payment, err := h.PaymentService.PayOrder(ctx, order.ID, req, actor.ID)
+
+	var gatewayErr *models.GatewayError
+	switch {
+	case errors.As(err, &gatewayErr):
+		// the failed attempt is returned so the client sees why
+		log.Printf("PAYMENT FAILED order=%d payment=%d code=%s", order.ID, payment.ID, gatewayErr.Code)
+		writePayment(w, r, http.StatusPaymentRequired, payment)
+		return
+	case err != nil:
+		writeStoreError(w, r, err, "failed to process payment")
+		return
+	}
+
+	// significant business event
+	log.Printf("ORDER PAID id=%d payment=%d amount=%.2f by=%d", order.ID, payment.ID, payment.Amount, actor.ID)
+
+	writePayment(w, r, http.StatusCreated, payment)
+}
+
+func writePayment(w http.ResponseWriter, r *http.Request, status int, v interface{}) {
+	resp, err := json.Marshal(v)
+	if err != nil {
+		log.Printf("ERROR serializing payment: %v", err)
+		WriteError(w, r, http.StatusInternalServerError, "failed to serialize payment")
 		return
 	}
 
 	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
 	w.Write(resp)
 }
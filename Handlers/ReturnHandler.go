@@ -0,0 +1,221 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"online_bookStore/models"
+	"online_bookStore/services"
+)
+
+type ReturnHandler struct {
+	returnService *services.ReturnService
+}
+
+func NewReturnHandler(returnService *services.ReturnService) *ReturnHandler {
+	return &ReturnHandler{
+		returnService: returnService,
+	}
+}
+
+/*
+	ROUTE: /returns
+*/
+func (h *ReturnHandler) ReturnsHandler(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		h.getReturns(w, r)
+	case http.MethodPost:
+		h.requestReturn(w, r)
+	default:
+		WriteError(w, r, http.StatusMethodNotAllowed, "method not allowed")
+	}
+}
+
+/*
+	ROUTE: /returns/{id}
+*/
+func (h *ReturnHandler) ReturnByIDHandler(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		h.getReturnByID(w, r)
+	case http.MethodPut:
+		h.reviewReturn(w, r)
+	default:
+		WriteError(w, r, http.StatusMethodNotAllowed, "method not allowed")
+	}
+}
+
+/*
+	GET /returns?status=requested
+	admins see every return, shoppers the returns of their own orders
+*/
+func (h *ReturnHandler) getReturns(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithTimeout(r.Context(), 3*time.Second)
+	defer cancel()
+
+	customerID := 0
+	if !isAdmin(r) {
+		user, _ := UserFromContext(r.Context())
+		if user.CustomerID == 0 {
+			writeReturn(w, r, http.StatusOK, []models.OrderReturn{})
+			return
+		}
+		customerID = user.CustomerID
+	}
+
+	returns, err := h.returnService.GetReturns(ctx, customerID, r.URL.Query().Get("status"))
+	if err != nil {
+		writeStoreError(w, r, err, "failed to fetch returns")
+		return
+	}
+
+	writeReturn(w, r, http.StatusOK, returns)
+}
+
+/*
+	POST /returns
+*/
+func (h *ReturnHandler) requestReturn(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithTimeout(r.Context(), 3*time.Second)
+	defer cancel()
+
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		log.Printf("ERROR reading return body: %v", err)
+		WriteError(w, r, http.StatusBadRequest, "failed to read request body")
+		return
+	}
+
+	var ret models.OrderReturn
+	if err := json.Unmarshal(body, &ret); err != nil {
+		log.Printf("ERROR unmarshalling return: %v", err)
+		writeJSONError(w, r, err)
+		return
+	}
+
+	// shoppers can only return their own orders; admins any order
+	ret.CustomerID = 0
+	if !isAdmin(r) {
+		user, _ := UserFromContext(r.Context())
+		if user.CustomerID == 0 {
+			WriteError(w, r, http.StatusForbidden, "no customer profile is linked to this account")
+			return
+		}
+		ret.CustomerID = user.CustomerID
+	}
+
+	created, err := h.returnService.RequestReturn(ctx, ret)
+	if err != nil {
+		writeReturnError(w, r, err, "failed to create return")
+		return
+	}
+
+	// significant business event
+	log.Printf("RETURN REQUESTED id=%d order=%d customer=%d items=%d", created.ID, created.OrderID, created.CustomerID, len(created.Items))
+
+	writeReturn(w, r, http.StatusCreated, created)
+}
+
+/*
+	GET /returns/{id}
+*/
+func (h *ReturnHandler) getReturnByID(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithTimeout(r.Context(), 3*time.Second)
+	defer cancel()
+
+	id, err := strconv.Atoi(strings.TrimPrefix(r.URL.Path, "/returns/"))
+	if err != nil {
+		WriteError(w, r, http.StatusBadRequest, "invalid return id")
+		return
+	}
+
+	ret, err := h.returnService.GetReturn(ctx, id)
+	if err != nil {
+		writeReturnError(w, r, err, "failed to fetch return")
+		return
+	}
+
+	if !canAccessCustomer(r, ret.CustomerID) {
+		WriteError(w, r, http.StatusForbidden, "access to this return is not allowed")
+		return
+	}
+
+	writeReturn(w, r, http.StatusOK, ret)
+}
+
+/*
+	PUT /returns/{id} (admin)
+	{"status": "approved", "refund_amount": 10.00, "note": "..."}
+*/
+func (h *ReturnHandler) reviewReturn(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithTimeout(r.Context(), 3*time.Second)
+	defer cancel()
+
+	if !isAdmin(r) {
+		WriteError(w, r, http.StatusForbidden, "admin role required")
+		return
+	}
+
+	id, err := strconv.Atoi(strings.TrimPrefix(r.URL.Path, "/returns/"))
+	if err != nil {
+		WriteError(w, r, http.StatusBadRequest, "invalid return id")
+		return
+	}
+
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		log.Printf("ERROR reading return review body: %v", err)
+		WriteError(w, r, http.StatusBadRequest, "failed to read request body")
+		return
+	}
+
+	var review models.ReturnReview
+	if err := json.Unmarshal(body, &review); err != nil {
+		log.Printf("ERROR unmarshalling review of return %d: %v", id, err)
+		writeJSONError(w, r, err)
+		return
+	}
+
+	actor, _ := UserFromContext(r.Context())
+
+	reviewed, err := h.returnService.ReviewReturn(ctx, id, review, actor.ID)
+	if err != nil {
+		writeReturnError(w, r, err, "failed to review return")
+		return
+	}
+
+	// significant business event
+	log.Printf("RETURN REVIEWED id=%d order=%d status=%s refunded=%.2f by=%d", reviewed.ID, reviewed.OrderID, reviewed.Status, reviewed.RefundedAmount, actor.ID)
+
+	writeReturn(w, r, http.StatusOK, reviewed)
+}
+
+func writeReturn(w http.ResponseWriter, r *http.Request, status int, v interface{}) {
+	resp, err := json.Marshal(v)
+	if err != nil {
+		log.Printf("ERROR serializing return: %v", err)
+		WriteError(w, r, http.StatusInternalServerError, "failed to serialize return")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	w.Write(resp)
+}
+
+// maps return and refund errors to HTTP responses
+func writeReturnError(w http.ResponseWriter, r *http.Request, err error, fallback string) {
+	var gatewayErr *models.GatewayError
+	if errors.As(err, &gatewayErr) {
+		log.Printf("REFUND FAILED code=%s: %s", gatewayErr.Code, gatewayErr.Message)
+	}
+	writeStoreError(w, r, err, fallback)
+}
@@ -0,0 +1,184 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"log"
+	"net/http"
+	"time"
+
+	"online_bookStore/models"
+	"online_bookStore/services"
+)
+
+type UserHandler struct {
+	userService *services.UserService
+}
+
+func NewUserHandler(userService *services.UserService) *UserHandler {
+	return &UserHandler{
+		userService: userService,
+	}
+}
+
+// /users
+func (h *UserHandler) UsersHandler(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		h.getUsers(w, r)
+	case http.MethodPost:
+		h.createUser(w, r)
+	default:
+		WriteError(w, r, http.StatusMethodNotAllowed, "method not allowed")
+	}
+}
+
+// /users/{id}
+func (h *UserHandler) UserByIDHandler(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		h.getUserByID(w, r)
+	case http.MethodPut:
+		h.updateUser(w, r)
+	default:
+		WriteError(w, r, http.StatusMethodNotAllowed, "method not allowed")
+	}
+}
+
+func (h *UserHandler) getUsers(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithTimeout(r.Context(), 3*time.Second)
+	defer cancel()
+
+	users, err := h.userService.ListUsers(ctx)
+	if err != nil {
+		writeStoreError(w, r, err, "failed to fetch users")
+		return
+	}
+
+	resp, err := json.Marshal(users)
+	if err != nil {
+		log.Printf("ERROR serializing users: %v", err)
+		WriteError(w, r, http.StatusInternalServerError, "failed to serialize users")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Write(resp)
+}
+
+func (h *UserHandler) createUser(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithTimeout(r.Context(), 3*time.Second)
+	defer cancel()
+
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		log.Printf("ERROR reading create user body: %v", err)
+		WriteError(w, r, http.StatusBadRequest, "failed to read request body")
+		return
+	}
+
+	var req models.CreateUserRequest
+	if err := json.Unmarshal(body, &req); err != nil {
+		log.Printf("ERROR unmarshalling user: %v", err)
+		writeJSONError(w, r, err)
+		return
+	}
+
+	if req.Role == "" {
+		req.Role = models.RoleUser
+	}
+
+	user, err := h.userService.CreateUser(ctx, req.Email, req.Password, req.Role)
+	if err != nil {
+		writeStoreError(w, r, err, "failed to create user")
+		return
+	}
+
+	// significant business event
+	log.Printf("USER CREATED id=%d email=%s role=%s", user.ID, user.Email, user.Role)
+
+	resp, err := json.Marshal(user)
+	if err != nil {
+		log.Printf("ERROR serializing created user: %v", err)
+		WriteError(w, r, http.StatusInternalServerError, "failed to serialize user")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	w.Write(resp)
+}
+
+func (h *UserHandler) getUserByID(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithTimeout(r.Context(), 3*time.Second)
+	defer cancel()
+
+	id, err := parseID(r.URL.Path, "/users/")
+	if err != nil {
+		WriteError(w, r, http.StatusBadRequest, "invalid user id")
+		return
+	}
+
+	user, err := h.userService.GetUser(ctx, id)
+	if err != nil {
+		writeStoreError(w, r, err, "failed to fetch user")
+		return
+	}
+
+	resp, err := json.Marshal(user)
+	if err != nil {
+		log.Printf("ERROR serializing user %d: %v", id, err)
+		WriteError(w, r, http.StatusInternalServerError, "failed to serialize user")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Write(resp)
+}
+
+func (h *UserHandler) updateUser(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithTimeout(r.Context(), 3*time.Second)
+	defer cancel()
+
+	id, err := parseID(r.URL.Path, "/users/")
+	if err != nil {
+		WriteError(w, r, http.StatusBadRequest, "invalid user id")
+		return
+	}
+
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		log.Printf("ERROR reading update user body: %v", err)
+		WriteError(w, r, http.StatusBadRequest, "failed to read request body")
+		return
+	}
+
+	var req models.UpdateUserRequest
+	if err := json.Unmarshal(body, &req); err != nil {
+		log.Printf("ERROR unmarshalling user update %d: %v", id, err)
+		writeJSONError(w, r, err)
+		return
+	}
+
+	actor, _ := UserFromContext(r.Context())
+
+	user, err := h.userService.UpdateUser(ctx, actor.ID, id, req)
+	if err != nil {
+		writeStoreError(w, r, err, "failed to update user")
+		return
+	}
+
+	// significant business event
+	log.Printf("USER UPDATED id=%d role=%s disabled=%t customer=%d by=%d", user.ID, user.Role, user.Disabled, user.CustomerID, actor.ID)
+
+	resp, err := json.Marshal(user)
+	if err != nil {
+		log.Printf("ERROR serializing updated user %d: %v", id, err)
+		WriteError(w, r, http.StatusInternalServerError, "failed to serialize user")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Write(resp)
+}
@@ -0,0 +1,148 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"log"
+	"net/http"
+	"time"
+
+	"online_bookStore/Interfaces"
+	"online_bookStore/models"
+)
+
+const (
+	IdempotencyKeyHeader = "Idempotency-Key"
+	maxIdempotencyKeyLen = 255
+)
+
+type IdempotencyMiddleware struct {
+	store interfaces.IdempotencyStore
+	ttl   time.Duration
+}
+
+// Constructor: keys can be replayed for ttl after first use
+func NewIdempotencyMiddleware(store interfaces.IdempotencyStore, ttl time.Duration) *IdempotencyMiddleware {
+	return &IdempotencyMiddleware{
+		store: store,
+		ttl:   ttl,
+	}
+}
+
+// Wrap makes POST requests carrying an Idempotency-Key header safe to
+// retry: the first response is stored and replayed for later requests
+// with the same key and body. It must run inside Protect so keys are
+// scoped to the authenticated user.
+func (m *IdempotencyMiddleware) Wrap(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		key := r.Header.Get(IdempotencyKeyHeader)
+		if r.Method != http.MethodPost || key == "" {
+			next(w, r)
+			return
+		}
+		if len(key) > maxIdempotencyKeyLen {
+			WriteError(w, r, http.StatusBadRequest, "Idempotency-Key must be at most 255 characters")
+			return
+		}
+
+		body, err := io.ReadAll(r.Body)
+		if err != nil {
+			log.Printf("ERROR reading request body: %v", err)
+			WriteError(w, r, http.StatusBadRequest, "failed to read request body")
+			return
+		}
+		r.Body = io.NopCloser(bytes.NewReader(body))
+
+		userID := 0
+		if user, ok := UserFromContext(r.Context()); ok {
+			userID = user.ID
+		}
+
+		now := time.Now()
+		record := models.IdempotencyRecord{
+			UserID:      userID,
+			Key:         key,
+			RequestHash: requestFingerprint(r, body),
+			CreatedAt:   now,
+			ExpiresAt:   now.Add(m.ttl),
+		}
+
+		ctx, cancel := context.WithTimeout(r.Context(), 3*time.Second)
+		existing, reserved, err := m.store.Reserve(ctx, record)
+		cancel()
+		if err != nil {
+			writeStoreError(w, r, err, "failed to process idempotency key")
+			return
+		}
+
+		if !reserved {
+			switch {
+			case existing.RequestHash != record.RequestHash:
+				WriteError(w, r, http.StatusUnprocessableEntity, "Idempotency-Key was already used with a different request")
+			case !existing.Completed():
+				WriteError(w, r, http.StatusConflict, "a request with this Idempotency-Key is still being processed")
+			default:
+				log.Printf("IDEMPOTENT REPLAY user=%d %s %s", userID, r.Method, r.URL.Path)
+				if existing.ContentType != "" {
+					w.Header().Set("Content-Type", existing.ContentType)
+				}
+				w.Header().Set("Idempotent-Replayed", "true")
+				w.WriteHeader(existing.StatusCode)
+				w.Write(existing.ResponseBody)
+			}
+			return
+		}
+
+		rec := &responseRecorder{ResponseWriter: w, status: http.StatusOK}
+		next(rec, r)
+
+		// use a fresh context: the request may already be cancelled
+		ctx, cancel = context.WithTimeout(context.Background(), 3*time.Second)
+		defer cancel()
+
+		// server errors are not final, let the client retry them
+		if rec.status >= http.StatusInternalServerError {
+			if err := m.store.Release(ctx, userID, key); err != nil {
+				log.Printf("ERROR releasing idempotency key: %v", err)
+			}
+			return
+		}
+
+		if err := m.store.Complete(ctx, userID, key, rec.status, rec.Header().Get("Content-Type"), rec.body.Bytes()); err != nil {
+			log.Printf("ERROR storing idempotent response: %v", err)
+		}
+	}
+}
+
+// the same key may only be reused for the same method, path and body
+func requestFingerprint(r *http.Request, body []byte) string {
+	h := sha256.New()
+	io.WriteString(h, r.Method+" "+r.URL.RequestURI()+"\n")
+	h.Write(body)
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// responseRecorder passes the response through while keeping a copy
+type responseRecorder struct {
+	http.ResponseWriter
+	status      int
+	wroteHeader bool
+	body        bytes.Buffer
+}
+
+func (rec *responseRecorder) WriteHeader(status int) {
+	if !rec.wroteHeader {
+		rec.status = status
+		rec.wroteHeader = true
+	}
+	rec.ResponseWriter.WriteHeader(status)
+}
+
+func (rec *responseRecorder) Write(b []byte) (int, error) {
+	rec.wroteHeader = true
+	rec.body.Write(b)
+	return rec.ResponseWriter.Write(b)
+}
@@ -1,10 +1,18 @@
 package handlers
 
 import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
 	"log"
 	"net/http"
+	"strings"
 	"time"
-	
+
+	"online_bookStore/Interfaces"
+	"online_bookStore/models"
+	"online_bookStore/services"
 )
 
 func LoggingMiddleware(next http.Handler) http.Handler {
@@ -24,4 +32,155 @@ func LoggingMiddleware(next http.Handler) http.Handler {
 	})
 }
 
+type contextKey string
+
+const (
+	userContextKey      contextKey = "user"
+	requestIDContextKey contextKey = "request_id"
+)
+
+const requestIDHeader = "X-Request-ID"
+
+// RequestIDMiddleware tags every request with an ID, the one the client
+// sent in X-Request-ID or a random one, echoed in the response header and
+// in the problem details of errors
+func RequestIDMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id := r.Header.Get(requestIDHeader)
+		if id == "" || len(id) > 128 {
+			id = newRequestID()
+		}
+
+		w.Header().Set(requestIDHeader, id)
+		next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), requestIDContextKey, id)))
+	})
+}
+
+// RequestIDFromContext returns the ID RequestIDMiddleware gave the request
+func RequestIDFromContext(ctx context.Context) string {
+	id, _ := ctx.Value(requestIDContextKey).(string)
+	return id
+}
+
+func newRequestID() string {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return ""
+	}
+	return hex.EncodeToString(b)
+}
+
+// UserFromContext returns the authenticated user, if any
+func UserFromContext(ctx context.Context) (models.User, bool) {
+	user, ok := ctx.Value(userContextKey).(models.User)
+	return user, ok
+}
+
+func isAdmin(r *http.Request) bool {
+	user, ok := UserFromContext(r.Context())
+	return ok && user.Role == models.RoleAdmin
+}
+
+// readContext is the request context, asking the stores for soft-deleted
+// records too when an admin passes ?include_deleted=true
+func readContext(r *http.Request) context.Context {
+	if isAdmin(r) && r.URL.Query().Get("include_deleted") == "true" {
+		return interfaces.WithDeleted(r.Context())
+	}
+	return r.Context()
+}
+
+// canAccessCustomer reports whether the caller may act on the customer's
+// records: admins always can, other users only on their linked customer
+func canAccessCustomer(r *http.Request, customerID int) bool {
+	user, ok := UserFromContext(r.Context())
+	if !ok {
+		return false
+	}
+	if user.Role == models.RoleAdmin {
+		return true
+	}
+	return user.CustomerID != 0 && user.CustomerID == customerID
+}
+
+type AccessLevel int
+
+const (
+	AccessPublic AccessLevel = iota
+	AccessUser
+	AccessAdmin
+)
+
+// Policy maps an HTTP method to the access level it requires.
+// The "*" entry applies to every method not listed explicitly.
+type Policy map[string]AccessLevel
 
+var (
+	PublicPolicy         = Policy{"*": AccessPublic}
+	UserPolicy           = Policy{"*": AccessUser}
+	AdminPolicy          = Policy{"*": AccessAdmin}
+	PublicReadAdminWrite = Policy{http.MethodGet: AccessPublic, "*": AccessAdmin}
+)
+
+func (p Policy) levelFor(method string) AccessLevel {
+	if level, ok := p[method]; ok {
+		return level
+	}
+	return p["*"]
+}
+
+type AuthMiddleware struct {
+	authService *services.AuthService
+}
+
+func NewAuthMiddleware(authService *services.AuthService) *AuthMiddleware {
+	return &AuthMiddleware{
+		authService: authService,
+	}
+}
+
+// Protect validates the bearer token (when present), stores the user in
+// the request context and enforces the policy before calling next
+func (m *AuthMiddleware) Protect(policy Policy, next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		level := policy.levelFor(r.Method)
+
+		header := r.Header.Get("Authorization")
+		if header == "" {
+			if level != AccessPublic {
+				WriteError(w, r, http.StatusUnauthorized, "missing bearer token")
+				return
+			}
+			next(w, r)
+			return
+		}
+
+		token, ok := strings.CutPrefix(header, "Bearer ")
+		if !ok || token == "" {
+			WriteError(w, r, http.StatusUnauthorized, "invalid authorization header")
+			return
+		}
+
+		ctx, cancel := context.WithTimeout(r.Context(), 3*time.Second)
+		user, err := m.authService.Authenticate(ctx, token)
+		cancel()
+		if errors.Is(err, services.ErrInvalidToken) {
+			log.Printf("AUTH REJECTED %s %s: %v", r.Method, r.URL.Path, err)
+			WriteError(w, r, http.StatusUnauthorized, "invalid or expired token")
+			return
+		}
+		if err != nil {
+			writeStoreError(w, r, err, "failed to authenticate")
+			return
+		}
+
+		if level == AccessAdmin && user.Role != models.RoleAdmin {
+			log.Printf("ACCESS DENIED user=%d %s %s", user.ID, r.Method, r.URL.Path)
+			WriteError(w, r, http.StatusForbidden, "admin role required")
+			return
+		}
+
+		ctx = context.WithValue(r.Context(), userContextKey, user)
+		next(w, r.WithContext(ctx))
+	}
+}
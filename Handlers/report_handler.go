@@ -18,7 +18,7 @@ func (h *ReportHandler) GetReports(w http.ResponseWriter, r *http.Request){
 	files, err := os.ReadDir("reports")
 
 	if err != nil {
-		WriteError(w, http.StatusInternalServerError, "failed to read reports directory")
+		WriteError(w, r, http.StatusInternalServerError, "failed to read reports directory")
 		return
 	}
 
@@ -33,7 +33,7 @@ func (h *ReportHandler) GetReports(w http.ResponseWriter, r *http.Request){
 
 	resp, err := json.Marshal(reports)
 	if err != nil {
-		WriteError(w, http.StatusInternalServerError, "failed to serialize reports list")
+		WriteError(w, r, http.StatusInternalServerError, "failed to serialize reports list")
 		return
 	}
 
@@ -48,7 +48,7 @@ func (h *ReportHandler) GetReports(w http.ResponseWriter, r *http.Request){
 func (h *ReportHandler) GetReportByDate(w http.ResponseWriter, r *http.Request) {
 	date := strings.TrimPrefix(r.URL.Path, "/reports/")
 	if date == "" {
-		WriteError(w, http.StatusBadRequest, "missing report date")
+		WriteError(w, r, http.StatusBadRequest, "missing report date")
 		return
 	}
 
@@ -57,7 +57,7 @@ func (h *ReportHandler) GetReportByDate(w http.ResponseWriter, r *http.Request)
 
 	raw, err := os.ReadFile(path)
 	if err != nil {
-		WriteError(w, http.StatusNotFound, "report not found")
+		WriteError(w, r, http.StatusNotFound, "report not found")
 		return
 	}
 
@@ -65,14 +65,14 @@ func (h *ReportHandler) GetReportByDate(w http.ResponseWriter, r *http.Request)
 	var report map[string]interface{}
 	err = json.Unmarshal(raw, &report)
 	if err != nil {
-		WriteError(w, http.StatusInternalServerError, "invalid report format")
+		WriteError(w, r, http.StatusInternalServerError, "invalid report format")
 		return
 	}
 
 	// Marshal again for response
 	data, err := json.Marshal(report)
 	if err != nil {
-		WriteError(w, http.StatusInternalServerError, "failed to serialize report")
+		WriteError(w, r, http.StatusInternalServerError, "failed to serialize report")
 		return
 	}
 
@@ -0,0 +1,159 @@
+package inmemory
+
+import (
+	"context"
+
+	"online_bookStore/models"
+)
+
+type MemoryAuthorStore struct {
+	db *DB
+}
+
+// Constructor
+func NewMemoryAuthorStore(db *DB) *MemoryAuthorStore {
+	return &MemoryAuthorStore{
+		db: db,
+	}
+}
+
+func (s *MemoryAuthorStore) CreateAuthor(ctx context.Context, author models.Author) (models.Author, error) {
+	err := s.db.write(ctx, func(t *tables) error {
+		author.ID = t.nextID("authors")
+		author.DeletedAt = nil
+		t.authors[author.ID] = author
+		return nil
+	})
+	return author, err
+}
+
+func (s *MemoryAuthorStore) GetAuthor(ctx context.Context, id int) (models.Author, error) {
+	var author models.Author
+	err := s.db.read(ctx, func(t *tables) error {
+		a, ok := t.authors[id]
+		if !ok || !visible(ctx, a.DeletedAt) {
+			return notFound("author")
+		}
+		author = a
+		return nil
+	})
+	return author, err
+}
+
+func (s *MemoryAuthorStore) UpdateAuthor(ctx context.Context, id int, author models.Author) (models.Author, error) {
+	err := s.db.write(ctx, func(t *tables) error {
+		current, ok := t.authors[id]
+		if !ok || current.DeletedAt != nil {
+			return notFound("author")
+		}
+
+		current.FirstName = author.FirstName
+		current.LastName = author.LastName
+		current.Bio = author.Bio
+		t.authors[id] = current
+		return nil
+	})
+
+	author.ID = id
+	return author, err
+}
+
+// DeleteAuthor refuses while the author has books, contributes to other
+// authors' books or has active coupons; force deletes the author's books
+// along with the author and drops them from the contributors of the rest
+func (s *MemoryAuthorStore) DeleteAuthor(ctx context.Context, id int, force bool) error {
+	return s.db.write(ctx, func(t *tables) error {
+		author, ok := t.authors[id]
+		if !ok || author.DeletedAt != nil {
+			return notFound("author")
+		}
+
+		books, contributions := 0, 0
+		for _, book := range t.books {
+			if book.DeletedAt != nil {
+				continue
+			}
+			if book.Author.ID == id {
+				books++
+				continue
+			}
+			for _, c := range book.Contributors {
+				if c.Author.ID == id {
+					contributions++
+				}
+			}
+		}
+		coupons := 0
+		for _, coupon := range t.coupons {
+			if coupon.AuthorID == id && coupon.Active {
+				coupons++
+			}
+		}
+
+		deletedAt := now()
+		if !force {
+			blockers := []models.Blocker{
+				{Type: "books", Count: books},
+				{Type: "book_contributors", Count: contributions},
+				{Type: "coupons", Count: coupons},
+			}
+			if err := dependents("author", id, blockers); err != nil {
+				return err
+			}
+		} else {
+			for bookID, book := range t.books {
+				if book.Author.ID == id {
+					// contributions to the author's own books stay for a restore
+					if book.DeletedAt == nil {
+						book.DeletedAt = deletedAt
+						t.books[bookID] = book
+					}
+					continue
+				}
+				if hasContributor(book, id) {
+					kept := make([]models.Contributor, 0, len(book.Contributors))
+					for _, c := range book.Contributors {
+						if c.Author.ID != id {
+							kept = append(kept, c)
+						}
+					}
+					book.Contributors = kept
+					t.books[bookID] = book
+				}
+			}
+		}
+
+		author.DeletedAt = deletedAt
+		t.authors[id] = author
+		return nil
+	})
+}
+
+func (s *MemoryAuthorStore) RestoreAuthor(ctx context.Context, id int) (models.Author, error) {
+	err := s.db.write(ctx, func(t *tables) error {
+		author, ok := t.authors[id]
+		if !ok || author.DeletedAt == nil {
+			return notFound("author")
+		}
+		author.DeletedAt = nil
+		t.authors[id] = author
+		return nil
+	})
+	if err != nil {
+		return models.Author{}, err
+	}
+	return s.GetAuthor(ctx, id)
+}
+
+func (s *MemoryAuthorStore) GetAllAuthors(ctx context.Context) ([]models.Author, error) {
+	var authors []models.Author
+	err := s.db.read(ctx, func(t *tables) error {
+		for _, id := range sortedKeys(t.authors) {
+			if author := t.authors[id]; visible(ctx, author.DeletedAt) {
+				authors = append(authors, author)
+			}
+		}
+		return nil
+	})
+	return authors, err
+}
@@ -0,0 +1,229 @@
+package inmemory
+
+import (
+	"context"
+	"strings"
+
+	"online_bookStore/models"
+)
+
+type MemoryBookStore struct {
+	db *DB
+}
+
+// Constructor
+func NewMemoryBookStore(db *DB) *MemoryBookStore {
+	return &MemoryBookStore{
+		db: db,
+	}
+}
+
+// book returns a stored book with its author and contributors filled in
+func (t *tables) book(id int) (models.Book, bool) {
+	book, ok := t.books[id]
+	if !ok {
+		return book, false
+	}
+	book.Author = t.authors[book.Author.ID]
+	book.Genres = append([]string{}, book.Genres...)
+	book.Contributors = t.contributors(book.Contributors)
+	return book, true
+}
+
+// contributors fills in the authors of stored contributors
+func (t *tables) contributors(stored []models.Contributor) []models.Contributor {
+	contributors := make([]models.Contributor, 0, len(stored))
+	for _, c := range stored {
+		contributors = append(contributors, models.Contributor{Author: t.authors[c.Author.ID], Role: c.Role})
+	}
+	return contributors
+}
+
+// checkContributors fails like the book_contributors foreign key, and
+// for soft-deleted authors too; the primary author is always among them
+func (t *tables) checkContributors(book models.Book) error {
+	for _, c := range book.Contributors {
+		if author, ok := t.authors[c.Author.ID]; !ok || author.DeletedAt != nil {
+			return missingReference("book", "author_id")
+		}
+	}
+	return nil
+}
+
+func hasContributor(book models.Book, authorID int) bool {
+	for _, c := range book.Contributors {
+		if c.Author.ID == authorID {
+			return true
+		}
+	}
+	return false
+}
+
+func (s *MemoryBookStore) CreateBook(ctx context.Context, book models.Book) (models.Book, error) {
+	genres, err := models.NormalizeGenres(book.Genres)
+	if err != nil {
+		return book, err
+	}
+
+	book, err = models.NormalizeContributors(book)
+	if err != nil {
+		return book, err
+	}
+
+	err = s.db.write(ctx, func(t *tables) error {
+		if err := t.checkContributors(book); err != nil {
+			return err
+		}
+
+		book.ID = t.nextID("books")
+		book.Genres = t.genreNames(genres)
+		t.books[book.ID] = storedBook(book)
+		book.Contributors = t.contributors(book.Contributors)
+		return nil
+	})
+	return book, err
+}
+
+// storedBook keeps the columns of the books table: the author's id and
+// copies of the genres and contributors, which hold author ids only
+func storedBook(book models.Book) models.Book {
+	book.Author = models.Author{ID: book.Author.ID}
+	book.Genres = append([]string(nil), book.Genres...)
+	contributors := make([]models.Contributor, 0, len(book.Contributors))
+	for _, c := range book.Contributors {
+		contributors = append(contributors, models.Contributor{Author: models.Author{ID: c.Author.ID}, Role: c.Role})
+	}
+	book.Contributors = contributors
+	book.DeletedAt = nil
+	return book
+}
+
+func (s *MemoryBookStore) GetBook(ctx context.Context, id int) (models.Book, error) {
+	var book models.Book
+	err := s.db.read(ctx, func(t *tables) error {
+		b, ok := t.book(id)
+		if !ok || !visible(ctx, b.DeletedAt) {
+			return notFound("book")
+		}
+		book = b
+		return nil
+	})
+	return book, err
+}
+
+func (s *MemoryBookStore) UpdateBook(ctx context.Context, id int, book models.Book) (models.Book, error) {
+	genres, err := models.NormalizeGenres(book.Genres)
+	if err != nil {
+		return book, err
+	}
+
+	book, err = models.NormalizeContributors(book)
+	if err != nil {
+		return book, err
+	}
+
+	err = s.db.write(ctx, func(t *tables) error {
+		current, ok := t.books[id]
+		if !ok || current.DeletedAt != nil {
+			return notFound("book")
+		}
+		if err := t.checkContributors(book); err != nil {
+			return err
+		}
+
+		book.ID = id
+		book.Genres = t.genreNames(genres)
+		t.books[id] = storedBook(book)
+		book.Contributors = t.contributors(book.Contributors)
+		return nil
+	})
+
+	book.ID = id
+	return book, err
+}
+
+// DeleteBook refuses while the book is in orders still being fulfilled
+// or in carts
+func (s *MemoryBookStore) DeleteBook(ctx context.Context, id int, force bool) error {
+	return s.db.write(ctx, func(t *tables) error {
+		book, ok := t.books[id]
+		if !ok || book.DeletedAt != nil {
+			return notFound("book")
+		}
+
+		if !force {
+			openOrders := 0
+			for _, order := range t.orders {
+				if order.open() && order.hasBook(id) {
+					openOrders++
+				}
+			}
+			carts := 0
+			for _, cart := range t.carts {
+				if _, ok := cart.items[id]; ok {
+					carts++
+				}
+			}
+
+			blockers := []models.Blocker{{Type: "open_orders", Count: openOrders}, {Type: "carts", Count: carts}}
+			if err := dependents("book", id, blockers); err != nil {
+				return err
+			}
+		}
+
+		book.DeletedAt = now()
+		t.books[id] = book
+		return nil
+	})
+}
+
+func (s *MemoryBookStore) RestoreBook(ctx context.Context, id int) (models.Book, error) {
+	err := s.db.write(ctx, func(t *tables) error {
+		book, ok := t.books[id]
+		if !ok || book.DeletedAt == nil {
+			return notFound("book")
+		}
+		book.DeletedAt = nil
+		t.books[id] = book
+		return nil
+	})
+	if err != nil {
+		return models.Book{}, err
+	}
+	return s.GetBook(ctx, id)
+}
+
+// SearchBooks matches like the MySQL store: the title is a
+// case-insensitive substring, the genre an exact name in any case, the
+// author any contributor, and the results carry the author, genres and
+// contributors like GetBook
+func (s *MemoryBookStore) SearchBooks(ctx context.Context, c models.SearchCriteria) ([]models.Book, error) {
+	var books []models.Book
+	err := s.db.read(ctx, func(t *tables) error {
+		for _, id := range sortedKeys(t.books) {
+			b, _ := t.book(id)
+			switch {
+			case !visible(ctx, b.DeletedAt):
+				continue
+			case c.Title != "" && !containsFold(b.Title, c.Title):
+				continue
+			case c.AuthorId != 0 && !hasContributor(b, c.AuthorId):
+				continue
+			case c.Genre != "" && !hasGenre(b, c.Genre):
+				continue
+			case c.MinPrice != 0 && b.Price < c.MinPrice:
+				continue
+			case c.MaxPrice != 0 && b.Price > c.MaxPrice:
+				continue
+			}
+
+			books = append(books, b)
+		}
+		return nil
+	})
+	return books, err
+}
+
+func containsFold(s string, substr string) bool {
+	return strings.Contains(strings.ToLower(s), strings.ToLower(substr))
+}
@@ -0,0 +1,230 @@
+package inmemory
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"online_bookStore/models"
+)
+
+type MemoryCartStore struct {
+	db  *DB
+	ttl time.Duration
+}
+
+// Constructor: carts expire ttl after their last change
+func NewMemoryCartStore(db *DB, ttl time.Duration) *MemoryCartStore {
+	return &MemoryCartStore{
+		db:  db,
+		ttl: ttl,
+	}
+}
+
+// cartRow is a stored cart; items are keyed by book id
+type cartRow struct {
+	id        int
+	items     map[int]cartItemRow
+	updatedAt time.Time
+	expiresAt time.Time
+}
+
+type cartItemRow struct {
+	quantity int
+	addedAt  time.Time
+}
+
+// GetCart answers an expired cart as empty; the row itself is dropped by
+// the next change or by DeleteExpiredCarts
+func (s *MemoryCartStore) GetCart(ctx context.Context, customerID int) (models.Cart, error) {
+	var cart models.Cart
+	err := s.db.read(ctx, func(t *tables) error {
+		cart = t.cart(customerID)
+		return nil
+	})
+	return cart, err
+}
+
+func (t *tables) cart(customerID int) models.Cart {
+	cart := models.Cart{CustomerID: customerID, Items: []models.CartItem{}}
+
+	row, ok := t.carts[customerID]
+	if !ok || time.Now().After(row.expiresAt) {
+		return cart
+	}
+	cart.ID = row.id
+	cart.UpdatedAt = row.updatedAt
+	cart.ExpiresAt = row.expiresAt
+
+	// books deleted from the catalogue drop out of the cart
+	bookIDs := make([]int, 0, len(row.items))
+	for bookID := range row.items {
+		if book, ok := t.books[bookID]; ok && book.DeletedAt == nil {
+			bookIDs = append(bookIDs, bookID)
+		}
+	}
+	sort.Slice(bookIDs, func(i, j int) bool {
+		a, b := row.items[bookIDs[i]], row.items[bookIDs[j]]
+		if !a.addedAt.Equal(b.addedAt) {
+			return a.addedAt.Before(b.addedAt)
+		}
+		return bookIDs[i] < bookIDs[j]
+	})
+
+	subtotal := 0.0
+	for _, bookID := range bookIDs {
+		book := t.books[bookID]
+		item := models.CartItem{
+			Book: models.Book{
+				ID:          book.ID,
+				Title:       book.Title,
+				PublishedAt: book.PublishedAt,
+				Price:       book.Price,
+				Stock:       book.Stock,
+				Author:      models.Author{ID: book.Author.ID},
+			},
+			Quantity:  row.items[bookID].quantity,
+			UnitPrice: book.Price,
+		}
+		item.InStock = book.Stock >= item.Quantity
+		subtotal += item.UnitPrice * float64(item.Quantity)
+
+		cart.Items = append(cart.Items, item)
+	}
+
+	cart.Subtotal = roundCents(subtotal)
+	return cart
+}
+
+// AddItem adds quantity copies of a book, on top of any already in the cart
+func (s *MemoryCartStore) AddItem(ctx context.Context, customerID int, bookID int, quantity int) (models.Cart, error) {
+	if quantity <= 0 {
+		return models.Cart{}, models.NewValidationError(models.ErrInvalidQuantity, "cart", fmt.Sprintf("book %d", bookID),
+			models.FieldError{Field: "quantity", Message: "must be positive"})
+	}
+
+	var cart models.Cart
+	err := s.db.write(ctx, func(t *tables) error {
+		row, err := s.ensureCart(t, customerID)
+		if err != nil {
+			return err
+		}
+		if err := t.setQuantity(customerID, row, bookID, row.items[bookID].quantity+quantity); err != nil {
+			return err
+		}
+		cart = t.cart(customerID)
+		return nil
+	})
+	return cart, err
+}
+
+// SetItemQuantity replaces the quantity of a book; 0 removes it
+func (s *MemoryCartStore) SetItemQuantity(ctx context.Context, customerID int, bookID int, quantity int) (models.Cart, error) {
+	if quantity < 0 {
+		return models.Cart{}, models.NewValidationError(models.ErrInvalidQuantity, "cart", fmt.Sprintf("book %d", bookID),
+			models.FieldError{Field: "quantity", Message: "must be positive"})
+	}
+	if quantity == 0 {
+		return s.RemoveItem(ctx, customerID, bookID)
+	}
+
+	var cart models.Cart
+	err := s.db.write(ctx, func(t *tables) error {
+		row, err := s.ensureCart(t, customerID)
+		if err != nil {
+			return err
+		}
+		if err := t.setQuantity(customerID, row, bookID, quantity); err != nil {
+			return err
+		}
+		cart = t.cart(customerID)
+		return nil
+	})
+	return cart, err
+}
+
+// setQuantity validates the quantity against the live catalogue
+func (t *tables) setQuantity(customerID int, row cartRow, bookID int, quantity int) error {
+	book, ok := t.books[bookID]
+	if !ok || book.DeletedAt != nil {
+		return fmt.Errorf("%w: %d", models.ErrUnknownBook, bookID)
+	}
+
+	if book.Stock < quantity {
+		return &models.InsufficientStockError{BookIDs: []int{bookID}}
+	}
+
+	item, ok := row.items[bookID]
+	if !ok {
+		item.addedAt = time.Now()
+	}
+	item.quantity = quantity
+	row.items[bookID] = item
+	t.carts[customerID] = row
+	return nil
+}
+
+func (s *MemoryCartStore) RemoveItem(ctx context.Context, customerID int, bookID int) (models.Cart, error) {
+	var cart models.Cart
+	err := s.db.write(ctx, func(t *tables) error {
+		row, ok := t.carts[customerID]
+		if !ok {
+			return notFound("cart")
+		}
+		if _, ok := row.items[bookID]; !ok {
+			return notFound("cart")
+		}
+
+		delete(row.items, bookID)
+		s.touch(&row)
+		t.carts[customerID] = row
+
+		cart = t.cart(customerID)
+		return nil
+	})
+	return cart, err
+}
+
+func (s *MemoryCartStore) ClearCart(ctx context.Context, customerID int) error {
+	return s.db.write(ctx, func(t *tables) error {
+		delete(t.carts, customerID)
+		return nil
+	})
+}
+
+func (s *MemoryCartStore) DeleteExpiredCarts(ctx context.Context, before time.Time) (int64, error) {
+	var deleted int64
+	err := s.db.write(ctx, func(t *tables) error {
+		for customerID, row := range t.carts {
+			if row.expiresAt.Before(before) {
+				delete(t.carts, customerID)
+				deleted++
+			}
+		}
+		return nil
+	})
+	return deleted, err
+}
+
+// ensureCart returns the customer's live cart, creating it (or emptying
+// an expired one) as needed, and pushes its expiry forward
+func (s *MemoryCartStore) ensureCart(t *tables, customerID int) (cartRow, error) {
+	if _, ok := t.customers[customerID]; !ok {
+		return cartRow{}, missingReference("cart", "customer_id")
+	}
+
+	row, ok := t.carts[customerID]
+	if !ok || row.expiresAt.Before(time.Now()) {
+		row = cartRow{id: t.nextID("carts"), items: make(map[int]cartItemRow)}
+	}
+
+	s.touch(&row)
+	t.carts[customerID] = row
+	return row, nil
+}
+
+func (s *MemoryCartStore) touch(row *cartRow) {
+	row.updatedAt = time.Now()
+	row.expiresAt = row.updatedAt.Add(s.ttl)
+}
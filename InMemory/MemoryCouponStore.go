@@ -0,0 +1,181 @@
+package inmemory
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"online_bookStore/models"
+)
+
+type MemoryCouponStore struct {
+	db *DB
+}
+
+// Constructor
+func NewMemoryCouponStore(db *DB) *MemoryCouponStore {
+	return &MemoryCouponStore{
+		db: db,
+	}
+}
+
+// couponByCode finds a coupon by its normalized code
+func (t *tables) couponByCode(code string) (models.Coupon, bool) {
+	code = models.NormalizeCouponCode(code)
+	for _, coupon := range t.coupons {
+		if coupon.Code == code {
+			return coupon, true
+		}
+	}
+	return models.Coupon{}, false
+}
+
+// putCoupon stores the definition of a coupon, keeping its usage count
+// and creation time
+func (t *tables) putCoupon(id int, coupon models.Coupon) error {
+	if other, ok := t.couponByCode(coupon.Code); ok && other.ID != id {
+		return models.ErrCouponCodeTaken
+	}
+	if _, ok := t.authors[coupon.AuthorID]; coupon.AuthorID != 0 && !ok {
+		return missingReference("coupon", "author_id")
+	}
+
+	current, ok := t.coupons[id]
+	if !ok {
+		current.CreatedAt = time.Now()
+	}
+	coupon.ID = id
+	coupon.UsedCount = current.UsedCount
+	coupon.CreatedAt = current.CreatedAt
+	t.coupons[id] = coupon
+	return nil
+}
+
+func (s *MemoryCouponStore) CreateCoupon(ctx context.Context, coupon models.Coupon) (models.Coupon, error) {
+	coupon.Code = models.NormalizeCouponCode(coupon.Code)
+	if err := coupon.Validate(); err != nil {
+		return coupon, err
+	}
+
+	var id int
+	err := s.db.write(ctx, func(t *tables) error {
+		id = t.nextID("coupons")
+		return t.putCoupon(id, coupon)
+	})
+	if err != nil {
+		return coupon, err
+	}
+
+	return s.GetCoupon(ctx, id)
+}
+
+func (s *MemoryCouponStore) GetCoupon(ctx context.Context, id int) (models.Coupon, error) {
+	var coupon models.Coupon
+	err := s.db.read(ctx, func(t *tables) error {
+		c, ok := t.coupons[id]
+		if !ok {
+			return notFound("coupon")
+		}
+		coupon = c
+		return nil
+	})
+	return coupon, err
+}
+
+func (s *MemoryCouponStore) GetCouponByCode(ctx context.Context, code string) (models.Coupon, error) {
+	var coupon models.Coupon
+	err := s.db.read(ctx, func(t *tables) error {
+		c, ok := t.couponByCode(code)
+		if !ok {
+			return notFound("coupon")
+		}
+		coupon = c
+		return nil
+	})
+	return coupon, err
+}
+
+// UpdateCoupon replaces the definition; the usage count is kept
+func (s *MemoryCouponStore) UpdateCoupon(ctx context.Context, id int, coupon models.Coupon) (models.Coupon, error) {
+	coupon.Code = models.NormalizeCouponCode(coupon.Code)
+	if err := coupon.Validate(); err != nil {
+		return coupon, err
+	}
+
+	err := s.db.write(ctx, func(t *tables) error {
+		if _, ok := t.coupons[id]; !ok {
+			return notFound("coupon")
+		}
+		return t.putCoupon(id, coupon)
+	})
+	if err != nil {
+		return coupon, err
+	}
+
+	return s.GetCoupon(ctx, id)
+}
+
+func (s *MemoryCouponStore) DeleteCoupon(ctx context.Context, id int) error {
+	return s.db.write(ctx, func(t *tables) error {
+		if _, ok := t.coupons[id]; !ok {
+			return notFound("coupon")
+		}
+		delete(t.coupons, id)
+
+		// orders keep the code they were placed with
+		for orderID, order := range t.orders {
+			if order.couponID == id {
+				order.couponID = 0
+				t.orders[orderID] = order
+			}
+		}
+		return nil
+	})
+}
+
+func (s *MemoryCouponStore) GetAllCoupons(ctx context.Context) ([]models.Coupon, error) {
+	coupons := []models.Coupon{}
+	err := s.db.read(ctx, func(t *tables) error {
+		for _, id := range sortedKeys(t.coupons) {
+			coupons = append(coupons, t.coupons[id])
+		}
+		return nil
+	})
+	return coupons, err
+}
+
+// redeemCoupon checks the coupon can be used for the priced order and
+// counts the use. It runs inside the order's write and returns the
+// coupon id.
+func (t *tables) redeemCoupon(order *models.Order) (int, error) {
+	coupon, ok := t.couponByCode(order.CouponCode)
+	if !ok {
+		return 0, fmt.Errorf("%w: %s", models.ErrUnknownCoupon, order.CouponCode)
+	}
+
+	if err := coupon.CheckUsable(time.Now()); err != nil {
+		return 0, err
+	}
+
+	discount, err := coupon.Discount(order.Items)
+	if err != nil {
+		return 0, err
+	}
+
+	coupon.UsedCount++
+	t.coupons[coupon.ID] = coupon
+
+	order.CouponCode = coupon.Code
+	order.Discount = discount
+	return coupon.ID, nil
+}
+
+// releaseCoupon gives back the use of a coupon an order redeemed
+func (t *tables) releaseCoupon(couponID int) {
+	coupon, ok := t.coupons[couponID]
+	if !ok || coupon.UsedCount == 0 {
+		return
+	}
+	coupon.UsedCount--
+	t.coupons[couponID] = coupon
+}
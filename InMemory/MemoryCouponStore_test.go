@@ -0,0 +1,82 @@
+package inmemory
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"online_bookStore/models"
+)
+
+func TestCouponLimits(t *testing.T) {
+	yesterday := time.Now().Add(-24 * time.Hour)
+	tomorrow := time.Now().Add(24 * time.Hour)
+
+	tests := []struct {
+		name         string
+		coupon       models.Coupon
+		inactive     bool
+		cancelFirst  bool // the first order is cancelled before the others are placed
+		orders       int
+		wantAccepted int
+		wantUsed     int
+	}{
+		{name: "unlimited", orders: 3, wantAccepted: 3, wantUsed: 3},
+		{name: "usage limit reached", coupon: models.Coupon{UsageLimit: 2}, orders: 3, wantAccepted: 2, wantUsed: 2},
+		{name: "cancelled order gives its use back", coupon: models.Coupon{UsageLimit: 1}, cancelFirst: true, orders: 2, wantAccepted: 2, wantUsed: 1},
+		{name: "inactive", inactive: true, orders: 1},
+		{name: "expired", coupon: models.Coupon{ValidUntil: &yesterday}, orders: 1},
+		{name: "not valid yet", coupon: models.Coupon{ValidFrom: &tomorrow}, orders: 1},
+		{name: "below the minimum order value", coupon: models.Coupon{MinOrderValue: 1000}, orders: 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			db := newSeededDB(t)
+			coupons := NewMemoryCouponStore(db)
+			orders := newOrderStore(db)
+
+			coupon := tt.coupon
+			coupon.Code = "TEST"
+			coupon.Type = models.CouponFixed
+			coupon.Value = 1
+			coupon.Active = !tt.inactive
+			coupon, err := coupons.CreateCoupon(ctx, coupon)
+			if err != nil {
+				t.Fatalf("CreateCoupon: %v", err)
+			}
+
+			accepted := 0
+			for i := 0; i < tt.orders; i++ {
+				order, err := orders.CreateOrder(ctx, newOrder(1, 1, "test"))
+				if errors.Is(err, models.ErrCouponNotApplicable) {
+					continue
+				}
+				if err != nil {
+					t.Fatalf("CreateOrder: %v", err)
+				}
+				accepted++
+
+				if tt.cancelFirst && i == 0 {
+					if _, err := orders.UpdateOrderStatus(ctx, order.ID, models.OrderStatusCancelled, 1); err != nil {
+						t.Fatalf("UpdateOrderStatus: %v", err)
+					}
+				}
+			}
+
+			if accepted != tt.wantAccepted {
+				t.Errorf("%d orders accepted the coupon, want %d", accepted, tt.wantAccepted)
+			}
+
+			stored, err := coupons.GetCoupon(ctx, coupon.ID)
+			if err != nil {
+				t.Fatalf("GetCoupon: %v", err)
+			}
+			if stored.UsedCount != tt.wantUsed {
+				t.Errorf("used_count = %d, want %d", stored.UsedCount, tt.wantUsed)
+			}
+		})
+	}
+}
@@ -0,0 +1,396 @@
+package inmemory
+
+import (
+	"context"
+	"time"
+
+	"online_bookStore/models"
+)
+
+type MemoryCustomerStore struct {
+	db *DB
+}
+
+// Constructor
+func NewMemoryCustomerStore(db *DB) *MemoryCustomerStore {
+	return &MemoryCustomerStore{
+		db: db,
+	}
+}
+
+// customer returns a stored customer with its default shipping address
+func (t *tables) customer(id int) (models.Customer, bool) {
+	customer, ok := t.customers[id]
+	if !ok {
+		return customer, false
+	}
+
+	customer.Address = models.Address{}
+	for _, address := range t.addresses {
+		if address.CustomerID == id && address.IsDefaultShipping {
+			customer.Address = address.Address
+		}
+	}
+	return customer, true
+}
+
+func (t *tables) emailTaken(email string, customerID int) bool {
+	for id, customer := range t.customers {
+		if id != customerID && customer.Email == email {
+			return true
+		}
+	}
+	return false
+}
+
+// liveCustomer reports whether the customer exists and is not deleted
+func (t *tables) liveCustomer(id int) bool {
+	customer, ok := t.customers[id]
+	return ok && customer.DeletedAt == nil
+}
+
+// CreateCustomer stores the customer and its address, which becomes the
+// default shipping and billing address
+func (s *MemoryCustomerStore) CreateCustomer(ctx context.Context, customer models.Customer) (models.Customer, error) {
+	if err := customer.Address.Validate(); err != nil {
+		return customer, models.PrefixFields(err, "address")
+	}
+
+	err := s.db.write(ctx, func(t *tables) error {
+		if t.emailTaken(customer.Email, 0) {
+			return duplicate("customer", "email")
+		}
+
+		customer.ID = t.nextID("customers")
+		t.customers[customer.ID] = models.Customer{
+			ID:        customer.ID,
+			Name:      customer.Name,
+			Email:     customer.Email,
+			CreatedAt: time.Now(),
+		}
+
+		t.insertAddress(models.CustomerAddress{
+			Address:           customer.Address,
+			CustomerID:        customer.ID,
+			Label:             models.DefaultAddressLabel,
+			IsDefaultShipping: true,
+			IsDefaultBilling:  true,
+		})
+		return nil
+	})
+	if err != nil {
+		return customer, err
+	}
+
+	return s.GetCustomer(ctx, customer.ID)
+}
+
+func (s *MemoryCustomerStore) GetCustomer(ctx context.Context, id int) (models.Customer, error) {
+	var customer models.Customer
+	err := s.db.read(ctx, func(t *tables) error {
+		c, ok := t.customer(id)
+		if !ok || !visible(ctx, c.DeletedAt) {
+			return notFound("customer")
+		}
+		customer = c
+		return nil
+	})
+	return customer, err
+}
+
+// UpdateCustomer changes name and email and, when an address is sent,
+// the default shipping address. Orders keep their own address copies.
+func (s *MemoryCustomerStore) UpdateCustomer(ctx context.Context, id int, customer models.Customer) (models.Customer, error) {
+	updateAddress := customer.Address != (models.Address{ID: customer.Address.ID})
+	if updateAddress {
+		if err := customer.Address.Validate(); err != nil {
+			return customer, models.PrefixFields(err, "address")
+		}
+	}
+
+	err := s.db.write(ctx, func(t *tables) error {
+		if !t.liveCustomer(id) {
+			return notFound("customer")
+		}
+		if t.emailTaken(customer.Email, id) {
+			return duplicate("customer", "email")
+		}
+
+		current := t.customers[id]
+		current.Name = customer.Name
+		current.Email = customer.Email
+		t.customers[id] = current
+
+		if !updateAddress {
+			return nil
+		}
+		for addressID, address := range t.addresses {
+			if address.CustomerID == id && address.IsDefaultShipping {
+				address.Street = customer.Address.Street
+				address.City = customer.Address.City
+				address.State = customer.Address.State
+				address.PostalCode = customer.Address.PostalCode
+				address.Country = customer.Address.Country
+				t.addresses[addressID] = address
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return customer, err
+	}
+
+	return s.GetCustomer(ctx, id)
+}
+
+// DeleteCustomer refuses while the customer has orders being fulfilled,
+// returns awaiting review or a linked user account
+func (s *MemoryCustomerStore) DeleteCustomer(ctx context.Context, id int, force bool) error {
+	return s.db.write(ctx, func(t *tables) error {
+		if !t.liveCustomer(id) {
+			return notFound("customer")
+		}
+
+		if !force {
+			openOrders, openReturns, users := 0, 0, 0
+			for _, order := range t.orders {
+				if order.Customer.ID == id && order.open() {
+					openOrders++
+				}
+			}
+			for _, ret := range t.returns {
+				if ret.CustomerID == id && ret.Status == models.ReturnStatusRequested {
+					openReturns++
+				}
+			}
+			for _, user := range t.users {
+				if user.CustomerID == id {
+					users++
+				}
+			}
+
+			blockers := []models.Blocker{
+				{Type: "open_orders", Count: openOrders},
+				{Type: "open_returns", Count: openReturns},
+				{Type: "users", Count: users},
+			}
+			if err := dependents("customer", id, blockers); err != nil {
+				return err
+			}
+		}
+
+		customer := t.customers[id]
+		customer.DeletedAt = now()
+		t.customers[id] = customer
+		return nil
+	})
+}
+
+func (s *MemoryCustomerStore) RestoreCustomer(ctx context.Context, id int) (models.Customer, error) {
+	err := s.db.write(ctx, func(t *tables) error {
+		customer, ok := t.customers[id]
+		if !ok || customer.DeletedAt == nil {
+			return notFound("customer")
+		}
+		customer.DeletedAt = nil
+		t.customers[id] = customer
+		return nil
+	})
+	if err != nil {
+		return models.Customer{}, err
+	}
+	return s.GetCustomer(ctx, id)
+}
+
+func (s *MemoryCustomerStore) GetAllCustomers(ctx context.Context) ([]models.Customer, error) {
+	var customers []models.Customer
+	err := s.db.read(ctx, func(t *tables) error {
+		for _, id := range sortedKeys(t.customers) {
+			if customer, _ := t.customer(id); visible(ctx, customer.DeletedAt) {
+				customers = append(customers, customer)
+			}
+		}
+		return nil
+	})
+	return customers, err
+}
+
+func (t *tables) insertAddress(address models.CustomerAddress) models.CustomerAddress {
+	address.ID = t.nextID("customer_addresses")
+	address.CreatedAt = time.Now()
+	t.addresses[address.ID] = address
+	return address
+}
+
+// clearDefaults removes the default flags the address is taking over
+// from the customer's other addresses
+func (t *tables) clearDefaults(address models.CustomerAddress) {
+	for id, other := range t.addresses {
+		if other.CustomerID != address.CustomerID || id == address.ID {
+			continue
+		}
+		if address.IsDefaultShipping {
+			other.IsDefaultShipping = false
+		}
+		if address.IsDefaultBilling {
+			other.IsDefaultBilling = false
+		}
+		t.addresses[id] = other
+	}
+}
+
+func (t *tables) customerAddress(customerID int, addressID int) (models.CustomerAddress, error) {
+	address, ok := t.addresses[addressID]
+	if !ok || address.CustomerID != customerID {
+		return models.CustomerAddress{}, notFound("address")
+	}
+	return address, nil
+}
+
+// customerAddressIDs lists the ids of the customer's saved addresses, oldest first
+func (t *tables) customerAddressIDs(customerID int) []int {
+	var ids []int
+	for _, id := range sortedKeys(t.addresses) {
+		if t.addresses[id].CustomerID == customerID {
+			ids = append(ids, id)
+		}
+	}
+	return ids
+}
+
+func (s *MemoryCustomerStore) GetAddresses(ctx context.Context, customerID int) ([]models.CustomerAddress, error) {
+	addresses := []models.CustomerAddress{}
+	err := s.db.read(ctx, func(t *tables) error {
+		customer, ok := t.customers[customerID]
+		if !ok || !visible(ctx, customer.DeletedAt) {
+			return notFound("address")
+		}
+
+		for _, id := range t.customerAddressIDs(customerID) {
+			addresses = append(addresses, t.addresses[id])
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return addresses, nil
+}
+
+func (s *MemoryCustomerStore) GetAddress(ctx context.Context, customerID int, addressID int) (models.CustomerAddress, error) {
+	var address models.CustomerAddress
+	err := s.db.read(ctx, func(t *tables) error {
+		var err error
+		address, err = t.customerAddress(customerID, addressID)
+		return err
+	})
+	return address, err
+}
+
+// AddAddress saves a new address; the customer's first address, and any
+// address flagged as default, becomes the default for that kind
+func (s *MemoryCustomerStore) AddAddress(ctx context.Context, customerID int, address models.CustomerAddress) (models.CustomerAddress, error) {
+	address.Normalize()
+	if err := address.Validate(); err != nil {
+		return address, err
+	}
+	address.CustomerID = customerID
+
+	err := s.db.write(ctx, func(t *tables) error {
+		if !t.liveCustomer(customerID) {
+			return notFound("address")
+		}
+
+		if len(t.customerAddressIDs(customerID)) == 0 {
+			address.IsDefaultShipping = true
+			address.IsDefaultBilling = true
+		}
+
+		address = t.insertAddress(address)
+		t.clearDefaults(address)
+		return nil
+	})
+	return address, err
+}
+
+// UpdateAddress replaces the address fields and label. Default flags can
+// only be moved to an address, never cleared: mark another address as
+// default instead.
+func (s *MemoryCustomerStore) UpdateAddress(ctx context.Context, customerID int, addressID int, address models.CustomerAddress) (models.CustomerAddress, error) {
+	address.Normalize()
+	if err := address.Validate(); err != nil {
+		return address, err
+	}
+
+	err := s.db.write(ctx, func(t *tables) error {
+		if !t.liveCustomer(customerID) {
+			return notFound("address")
+		}
+
+		current, err := t.customerAddress(customerID, addressID)
+		if err != nil {
+			return err
+		}
+
+		address.ID = addressID
+		address.CustomerID = customerID
+		address.CreatedAt = current.CreatedAt
+		address.IsDefaultShipping = address.IsDefaultShipping || current.IsDefaultShipping
+		address.IsDefaultBilling = address.IsDefaultBilling || current.IsDefaultBilling
+
+		t.addresses[addressID] = address
+		t.clearDefaults(address)
+		return nil
+	})
+	return address, err
+}
+
+// DeleteAddress removes a saved address. The last address cannot be
+// removed; defaults held by the removed address move to the oldest one left.
+func (s *MemoryCustomerStore) DeleteAddress(ctx context.Context, customerID int, addressID int) error {
+	return s.db.write(ctx, func(t *tables) error {
+		if !t.liveCustomer(customerID) {
+			return notFound("address")
+		}
+
+		current, err := t.customerAddress(customerID, addressID)
+		if err != nil {
+			return err
+		}
+
+		if len(t.customerAddressIDs(customerID)) <= 1 {
+			return models.ErrLastAddress
+		}
+		delete(t.addresses, addressID)
+
+		oldestID := t.customerAddressIDs(customerID)[0]
+		oldest := t.addresses[oldestID]
+		oldest.IsDefaultShipping = oldest.IsDefaultShipping || current.IsDefaultShipping
+		oldest.IsDefaultBilling = oldest.IsDefaultBilling || current.IsDefaultBilling
+		t.addresses[oldestID] = oldest
+		return nil
+	})
+}
+
+// lookupCustomerAddress returns the saved address with the given id, or
+// the customer's default of the given kind when id is 0
+func (t *tables) lookupCustomerAddress(customerID int, addressID int, kind string) (models.Address, error) {
+	for _, id := range t.customerAddressIDs(customerID) {
+		address := t.addresses[id]
+		switch {
+		case addressID != 0:
+			if id == addressID {
+				return address.Address, nil
+			}
+		case kind == models.AddressBilling:
+			if address.IsDefaultBilling {
+				return address.Address, nil
+			}
+		default:
+			if address.IsDefaultShipping {
+				return address.Address, nil
+			}
+		}
+	}
+	return models.Address{}, models.ErrUnknownAddress
+}
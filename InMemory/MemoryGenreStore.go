@@ -0,0 +1,81 @@
+package inmemory
+
+import (
+	"context"
+	"sort"
+	"strings"
+
+	"online_bookStore/models"
+)
+
+type MemoryGenreStore struct {
+	db *DB
+}
+
+// Constructor
+func NewMemoryGenreStore(db *DB) *MemoryGenreStore {
+	return &MemoryGenreStore{
+		db: db,
+	}
+}
+
+// ListGenres returns every genre by name; genres whose books were all
+// deleted or moved stay listed with a count of 0
+func (s *MemoryGenreStore) ListGenres(ctx context.Context) ([]models.Genre, error) {
+	genres := []models.Genre{}
+	err := s.db.read(ctx, func(t *tables) error {
+		for id, name := range t.genres {
+			genre := models.Genre{ID: id, Name: name}
+			for _, book := range t.books {
+				if book.DeletedAt == nil && hasGenre(book, name) {
+					genre.BookCount++
+				}
+			}
+			genres = append(genres, genre)
+		}
+		return nil
+	})
+
+	sort.Slice(genres, func(i, j int) bool {
+		return strings.ToLower(genres[i].Name) < strings.ToLower(genres[j].Name)
+	})
+	return genres, err
+}
+
+// genreNames files a book's genres like the genres table: names match
+// regardless of case, unknown ones are added, and the stored spellings
+// come back in name order
+func (t *tables) genreNames(names []string) []string {
+	genres := make([]string, 0, len(names))
+	for _, name := range names {
+		id := t.genreID(name)
+		if id == 0 {
+			id = t.nextID("genres")
+			t.genres[id] = name
+		}
+		genres = append(genres, t.genres[id])
+	}
+
+	sort.Slice(genres, func(i, j int) bool {
+		return strings.ToLower(genres[i]) < strings.ToLower(genres[j])
+	})
+	return genres
+}
+
+func (t *tables) genreID(name string) int {
+	for id, genre := range t.genres {
+		if strings.EqualFold(genre, name) {
+			return id
+		}
+	}
+	return 0
+}
+
+func hasGenre(book models.Book, name string) bool {
+	for _, genre := range book.Genres {
+		if strings.EqualFold(genre, name) {
+			return true
+		}
+	}
+	return false
+}
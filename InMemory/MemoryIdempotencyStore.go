@@ -0,0 +1,93 @@
+package inmemory
+
+import (
+	"context"
+	"time"
+
+	"online_bookStore/models"
+)
+
+type MemoryIdempotencyStore struct {
+	db *DB
+}
+
+// Constructor
+func NewMemoryIdempotencyStore(db *DB) *MemoryIdempotencyStore {
+	return &MemoryIdempotencyStore{db: db}
+}
+
+// idempotencyKey is the primary key of a record: keys are per user
+type idempotencyKey struct {
+	userID int
+	key    string
+}
+
+func (s *MemoryIdempotencyStore) Reserve(ctx context.Context, record models.IdempotencyRecord) (models.IdempotencyRecord, bool, error) {
+	var (
+		existing models.IdempotencyRecord
+		reserved bool
+	)
+	err := s.db.write(ctx, func(t *tables) error {
+		k := idempotencyKey{userID: record.UserID, key: record.Key}
+
+		// an expired key can be reused
+		if current, ok := t.idempotency[k]; ok && !current.ExpiresAt.Before(time.Now()) {
+			existing = current
+			existing.ResponseBody = append([]byte(nil), current.ResponseBody...)
+			return nil
+		}
+
+		stored := record
+		stored.StatusCode = 0
+		stored.ContentType = ""
+		stored.ResponseBody = nil
+		t.idempotency[k] = stored
+		reserved = true
+		return nil
+	})
+	if err != nil || reserved {
+		return record, reserved, err
+	}
+	return existing, false, nil
+}
+
+func (s *MemoryIdempotencyStore) Complete(ctx context.Context, userID int, key string, statusCode int, contentType string, body []byte) error {
+	return s.db.write(ctx, func(t *tables) error {
+		k := idempotencyKey{userID: userID, key: key}
+		record, ok := t.idempotency[k]
+		if !ok {
+			return nil
+		}
+
+		record.StatusCode = statusCode
+		record.ContentType = contentType
+		record.ResponseBody = append([]byte(nil), body...)
+		t.idempotency[k] = record
+		return nil
+	})
+}
+
+// Release leaves completed keys alone
+func (s *MemoryIdempotencyStore) Release(ctx context.Context, userID int, key string) error {
+	return s.db.write(ctx, func(t *tables) error {
+		k := idempotencyKey{userID: userID, key: key}
+		if record, ok := t.idempotency[k]; ok && !record.Completed() {
+			delete(t.idempotency, k)
+		}
+		return nil
+	})
+}
+
+func (s *MemoryIdempotencyStore) DeleteExpiredKeys(ctx context.Context, before time.Time) (int64, error) {
+	var deleted int64
+	err := s.db.write(ctx, func(t *tables) error {
+		for k, record := range t.idempotency {
+			if record.ExpiresAt.Before(before) {
+				delete(t.idempotency, k)
+				deleted++
+			}
+		}
+		return nil
+	})
+	return deleted, err
+}
@@ -0,0 +1,486 @@
+package inmemory
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sort"
+	"time"
+
+	"online_bookStore/Interfaces"
+	"online_bookStore/models"
+)
+
+type MemoryOrderStore struct {
+	db       *DB
+	tax      interfaces.TaxCalculator
+	shipping interfaces.ShippingCalculator
+}
+
+// Constructor: a nil calculator charges no tax or shipping
+func NewMemoryOrderStore(db *DB, tax interfaces.TaxCalculator, shipping interfaces.ShippingCalculator) *MemoryOrderStore {
+	return &MemoryOrderStore{
+		db:       db,
+		tax:      tax,
+		shipping: shipping,
+	}
+}
+
+// orderRow is a stored order: Customer holds the customer's id only and
+// the items their book's id, quantity and price
+type orderRow struct {
+	models.Order
+	couponID int
+}
+
+// open reports whether the order is still being fulfilled
+func (o orderRow) open() bool {
+	if o.DeletedAt != nil {
+		return false
+	}
+	switch models.NormalizeOrderStatus(o.Status) {
+	case models.OrderStatusPending, models.OrderStatusPaid, models.OrderStatusShipped:
+		return true
+	}
+	return false
+}
+
+func (o orderRow) hasBook(bookID int) bool {
+	for _, item := range o.Items {
+		if item.Book.ID == bookID {
+			return true
+		}
+	}
+	return false
+}
+
+// quantities totals the ordered copies per book
+func (o orderRow) quantities() map[int]int {
+	wanted := make(map[int]int)
+	for _, item := range o.Items {
+		wanted[item.Book.ID] += item.Quantity
+	}
+	return wanted
+}
+
+// orderHeader is what the order listings return: the order with its
+// customer's id, name and email, without items or addresses
+func (t *tables) orderHeader(row orderRow) models.Order {
+	order := row.Order
+	customer := t.customers[order.Customer.ID]
+	order.Customer = models.Customer{ID: customer.ID, Name: customer.Name, Email: customer.Email}
+	order.Items = nil
+	order.ShippingAddress = nil
+	order.BillingAddress = nil
+	return order
+}
+
+// CreateOrder prices, reserves stock for and stores an order. It joins the
+// transaction carried by ctx (see MemoryTransactor) or runs its own.
+func (s *MemoryOrderStore) CreateOrder(ctx context.Context, order models.Order) (models.Order, error) {
+	if len(order.Items) == 0 {
+		return order, models.ErrEmptyOrder
+	}
+
+	// every order starts its lifecycle as pending
+	order.Status = models.NormalizeOrderStatus(order.Status)
+	if order.Status == "" {
+		order.Status = models.OrderStatusPending
+	}
+	if order.Status != models.OrderStatusPending {
+		return order, &models.InvalidTransitionError{From: "", To: order.Status}
+	}
+
+	// copy the items so a failed attempt leaves the caller's order untouched
+	order.Items = append([]models.OrderItem(nil), order.Items...)
+
+	// the calculators read with the transaction's context, which the
+	// write below joins
+	err := s.db.transaction(ctx, func(ctx context.Context) error {
+		return s.db.write(ctx, func(t *tables) error {
+			return s.createOrder(ctx, t, &order)
+		})
+	})
+	return order, err
+}
+
+func (s *MemoryOrderStore) createOrder(ctx context.Context, t *tables, order *models.Order) error {
+	// total quantity per book, so a book listed twice is checked once
+	wanted := make(map[int]int)
+	for i, item := range order.Items {
+		if item.Quantity <= 0 {
+			return models.NewValidationError(models.ErrInvalidQuantity, "order", fmt.Sprintf("book %d", item.Book.ID),
+				models.FieldError{Field: fmt.Sprintf("items[%d].quantity", i), Message: "must be positive"})
+		}
+		wanted[item.Book.ID] += item.Quantity
+	}
+
+	bookIDs := make([]int, 0, len(wanted))
+	for id := range wanted {
+		bookIDs = append(bookIDs, id)
+	}
+	sort.Ints(bookIDs)
+
+	var shortBookIDs []int
+	for _, id := range bookIDs {
+		book, ok := t.books[id]
+		if !ok || book.DeletedAt != nil {
+			return fmt.Errorf("%w: %d", models.ErrUnknownBook, id)
+		}
+		if book.Stock < wanted[id] {
+			shortBookIDs = append(shortBookIDs, id)
+		}
+	}
+
+	if len(shortBookIDs) > 0 {
+		return &models.InsufficientStockError{BookIDs: shortBookIDs}
+	}
+	t.moveStock(wanted, -1)
+
+	// price every line from the catalogue, never from the payload
+	subtotal := 0.0
+	for i, item := range order.Items {
+		book := t.books[item.Book.ID]
+		order.Items[i].Book.Author.ID = book.Author.ID
+		order.Items[i].Book.Genres = append([]string(nil), book.Genres...)
+		order.Items[i].Book.WeightGrams = book.WeightGrams
+		order.Items[i].UnitPrice = book.Price
+		subtotal += order.Items[i].UnitPrice * float64(item.Quantity)
+	}
+	order.Subtotal = roundCents(subtotal)
+
+	order.Discount = 0
+	couponID := 0
+	if order.CouponCode != "" {
+		id, err := t.redeemCoupon(order)
+		if err != nil {
+			return err
+		}
+		couponID = id
+	}
+	if err := t.resolveOrderAddresses(order); err != nil {
+		return err
+	}
+	if err := s.applyTaxAndShipping(ctx, order); err != nil {
+		return err
+	}
+	total := roundCents(order.Subtotal - order.Discount + order.Tax + order.Shipping)
+
+	// a client-supplied total is only accepted as a cross-check
+	if order.TotalPrice != 0 && math.Abs(order.TotalPrice-total) > 0.005 {
+		return fmt.Errorf("%w: expected %.2f, got %.2f", models.ErrTotalMismatch, total, order.TotalPrice)
+	}
+	order.TotalPrice = total
+
+	order.ID = t.nextID("orders")
+	order.CreatedAt = time.Now()
+
+	row := orderRow{Order: *order, couponID: couponID}
+	row.Customer = models.Customer{ID: order.Customer.ID}
+	row.ShippingAddress = copyAddress(order.ShippingAddress)
+	row.BillingAddress = copyAddress(order.BillingAddress)
+	row.ShippingAddressID = 0
+	row.BillingAddressID = 0
+	row.Items = make([]models.OrderItem, len(order.Items))
+	for i, item := range order.Items {
+		order.Items[i].ID = t.nextID("order_items")
+		row.Items[i] = models.OrderItem{
+			ID:        order.Items[i].ID,
+			Book:      models.Book{ID: item.Book.ID},
+			Quantity:  item.Quantity,
+			UnitPrice: item.UnitPrice,
+		}
+	}
+	t.orders[order.ID] = row
+
+	t.recordStatusChange(order.ID, "", order.Status, 0)
+	return nil
+}
+
+// moveStock adds sign times the quantities to the books' stock
+func (t *tables) moveStock(quantities map[int]int, sign int) {
+	for bookID, quantity := range quantities {
+		book, ok := t.books[bookID]
+		if !ok {
+			continue
+		}
+		book.Stock += sign * quantity
+		t.books[bookID] = book
+	}
+}
+
+// resolveOrderAddresses snapshots the shipping and billing addresses:
+// an address sent with the order wins, then a saved address picked by id,
+// then the customer's default address of that kind
+func (t *tables) resolveOrderAddresses(order *models.Order) error {
+	if !t.liveCustomer(order.Customer.ID) {
+		return fmt.Errorf("%w: %d", models.ErrUnknownCustomer, order.Customer.ID)
+	}
+
+	var err error
+	order.ShippingAddress, err = t.snapshotAddress(order, order.ShippingAddress, order.ShippingAddressID, models.AddressShipping)
+	if err != nil {
+		return fmt.Errorf("shipping_address: %w", models.PrefixFields(err, "shipping_address"))
+	}
+	order.BillingAddress, err = t.snapshotAddress(order, order.BillingAddress, order.BillingAddressID, models.AddressBilling)
+	if err != nil {
+		return fmt.Errorf("billing_address: %w", models.PrefixFields(err, "billing_address"))
+	}
+	return nil
+}
+
+// snapshotAddress returns a detached copy of the address to store
+func (t *tables) snapshotAddress(order *models.Order, given *models.Address, savedID int, kind string) (*models.Address, error) {
+	var address models.Address
+	if given != nil {
+		if err := given.Validate(); err != nil {
+			return nil, err
+		}
+		address = *given
+	} else {
+		saved, err := t.lookupCustomerAddress(order.Customer.ID, savedID, kind)
+		if err != nil {
+			return nil, err
+		}
+		address = saved
+	}
+
+	address.ID = 0
+	return &address, nil
+}
+
+// applyTaxAndShipping prices tax and delivery to the shipping address;
+// both are charged on the discounted subtotal
+func (s *MemoryOrderStore) applyTaxAndShipping(ctx context.Context, order *models.Order) error {
+	amount := roundCents(order.Subtotal - order.Discount)
+
+	var err error
+	order.Tax = 0
+	if s.tax != nil {
+		if order.Tax, err = s.tax.CalculateTax(ctx, *order.ShippingAddress, amount); err != nil {
+			return err
+		}
+	}
+
+	order.Shipping = 0
+	if s.shipping != nil {
+		if order.Shipping, err = s.shipping.CalculateShipping(ctx, *order.ShippingAddress, order.Items, amount); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (t *tables) recordStatusChange(orderID int, from string, to string, changedBy int) {
+	change := models.OrderStatusChange{
+		ID:         t.nextID("order_status_history"),
+		OrderID:    orderID,
+		FromStatus: from,
+		ToStatus:   to,
+		ChangedBy:  changedBy,
+		ChangedAt:  time.Now(),
+	}
+
+	history := t.statusHistory[orderID]
+	t.statusHistory[orderID] = append(history[:len(history):len(history)], change)
+}
+
+func (s *MemoryOrderStore) GetOrder(ctx context.Context, id int) (models.Order, error) {
+	var order models.Order
+	err := s.db.read(ctx, func(t *tables) error {
+		row, ok := t.orders[id]
+		if !ok || !visible(ctx, row.DeletedAt) {
+			return notFound("order")
+		}
+
+		order = t.orderHeader(row)
+		order.ShippingAddress = copyAddress(row.ShippingAddress)
+		order.BillingAddress = copyAddress(row.BillingAddress)
+
+		for _, item := range row.Items {
+			book := t.books[item.Book.ID]
+			item.Book = models.Book{
+				ID:          book.ID,
+				Title:       book.Title,
+				Genres:      append([]string(nil), book.Genres...),
+				PublishedAt: book.PublishedAt,
+				Price:       book.Price,
+				Stock:       book.Stock,
+			}
+			order.Items = append(order.Items, item)
+		}
+		return nil
+	})
+	return order, err
+}
+
+func copyAddress(address *models.Address) *models.Address {
+	if address == nil {
+		return nil
+	}
+	c := *address
+	return &c
+}
+
+// UpdateOrderStatus moves an order along its lifecycle. Transitions not
+// allowed by models.CanTransitionOrder are rejected; every accepted change
+// is recorded in the status history with the user who made it. It joins
+// the transaction carried by ctx (see MemoryTransactor) or runs its own.
+func (s *MemoryOrderStore) UpdateOrderStatus(ctx context.Context, id int, status string, changedBy int) (models.Order, error) {
+	status = models.NormalizeOrderStatus(status)
+	if !models.IsValidOrderStatus(status) {
+		return models.Order{}, fmt.Errorf("%w: %q", models.ErrUnknownStatus, status)
+	}
+
+	err := s.db.write(ctx, func(t *tables) error {
+		row, ok := t.orders[id]
+		if !ok || row.DeletedAt != nil {
+			return notFound("order")
+		}
+		current := models.NormalizeOrderStatus(row.Status)
+
+		if !models.CanTransitionOrder(current, status) {
+			return &models.InvalidTransitionError{From: current, To: status}
+		}
+
+		row.Status = status
+		t.orders[id] = row
+
+		if models.ReleasesStock(current, status) {
+			t.moveStock(row.quantities(), 1)
+		}
+
+		// a cancelled order does not use up its coupon
+		if status == models.OrderStatusCancelled {
+			t.releaseCoupon(row.couponID)
+		}
+
+		t.recordStatusChange(id, current, status, changedBy)
+		return nil
+	})
+	if err != nil {
+		return models.Order{}, err
+	}
+
+	return s.GetOrder(ctx, id)
+}
+
+func (s *MemoryOrderStore) GetOrderStatusHistory(ctx context.Context, orderID int) ([]models.OrderStatusChange, error) {
+	history := []models.OrderStatusChange{}
+	err := s.db.read(ctx, func(t *tables) error {
+		row, ok := t.orders[orderID]
+		if !ok || !visible(ctx, row.DeletedAt) {
+			return notFound("order")
+		}
+
+		history = append(history, t.statusHistory[orderID]...)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return history, nil
+}
+
+// DeleteOrder soft-deletes the order; its items, history and payments are
+// kept. A pending or paid order still holds stock, a coupon use or a
+// payment, so it is refused with models.ErrOrderNotDeletable: cancelling
+// gives those back.
+func (s *MemoryOrderStore) DeleteOrder(ctx context.Context, id int) error {
+	return s.db.write(ctx, func(t *tables) error {
+		row, ok := t.orders[id]
+		if !ok || row.DeletedAt != nil {
+			return notFound("order")
+		}
+
+		if models.HoldsStock(row.Status) {
+			return fmt.Errorf("%w: order is %s", models.ErrOrderNotDeletable, row.Status)
+		}
+
+		row.DeletedAt = now()
+		t.orders[id] = row
+		return nil
+	})
+}
+
+// RestoreOrder undeletes an order. A pending or paid order reserves its
+// stock again, which fails with *models.InsufficientStockError when the
+// books have sold out meanwhile.
+func (s *MemoryOrderStore) RestoreOrder(ctx context.Context, id int) (models.Order, error) {
+	err := s.db.write(ctx, func(t *tables) error {
+		row, ok := t.orders[id]
+		if !ok || row.DeletedAt == nil {
+			return notFound("order")
+		}
+
+		if models.HoldsStock(row.Status) {
+			wanted := row.quantities()
+
+			var shortBookIDs []int
+			for bookID, quantity := range wanted {
+				if t.books[bookID].Stock < quantity {
+					shortBookIDs = append(shortBookIDs, bookID)
+				}
+			}
+			if len(shortBookIDs) > 0 {
+				sort.Ints(shortBookIDs)
+				return &models.InsufficientStockError{BookIDs: shortBookIDs}
+			}
+
+			t.moveStock(wanted, -1)
+		}
+
+		row.DeletedAt = nil
+		t.orders[id] = row
+		return nil
+	})
+	if err != nil {
+		return models.Order{}, err
+	}
+
+	return s.GetOrder(ctx, id)
+}
+
+// listOrders returns the headers of the visible orders keep accepts, by id
+func (s *MemoryOrderStore) listOrders(ctx context.Context, keep func(row orderRow) bool) ([]models.Order, error) {
+	var orders []models.Order
+	err := s.db.read(ctx, func(t *tables) error {
+		for _, id := range sortedKeys(t.orders) {
+			row := t.orders[id]
+			if visible(ctx, row.DeletedAt) && keep(row) {
+				orders = append(orders, t.orderHeader(row))
+			}
+		}
+		return nil
+	})
+	return orders, err
+}
+
+// GetOrderByDateRange includes both ends of the range, oldest first
+func (s *MemoryOrderStore) GetOrderByDateRange(ctx context.Context, from time.Time, to time.Time) ([]models.Order, error) {
+	orders, err := s.listOrders(ctx, func(row orderRow) bool {
+		return !row.CreatedAt.Before(from) && !row.CreatedAt.After(to)
+	})
+	sort.SliceStable(orders, func(i, j int) bool {
+		return orders[i].CreatedAt.Before(orders[j].CreatedAt)
+	})
+	return orders, err
+}
+
+func (s *MemoryOrderStore) GetAllOrders(ctx context.Context) ([]models.Order, error) {
+	return s.listOrders(ctx, func(row orderRow) bool {
+		return true
+	})
+}
+
+// GetOrdersByCustomer lists the customer's orders, newest first
+func (s *MemoryOrderStore) GetOrdersByCustomer(ctx context.Context, customerID int) ([]models.Order, error) {
+	orders, err := s.listOrders(ctx, func(row orderRow) bool {
+		return row.Customer.ID == customerID
+	})
+	sort.SliceStable(orders, func(i, j int) bool {
+		return orders[i].CreatedAt.After(orders[j].CreatedAt)
+	})
+	return orders, err
+}
@@ -0,0 +1,146 @@
+package inmemory
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+
+	"online_bookStore/models"
+)
+
+// newSeededDB returns a DB holding the sample data, as the server starts with
+func newSeededDB(t *testing.T) *DB {
+	t.Helper()
+
+	db := NewDB()
+	if err := Seed(context.Background(), db); err != nil {
+		t.Fatalf("seeding: %v", err)
+	}
+	return db
+}
+
+func newOrderStore(db *DB) *MemoryOrderStore {
+	return NewMemoryOrderStore(db, NewMemoryTaxCalculator(db), NewMemoryShippingCalculator(db))
+}
+
+// newOrder is an order of the seeded customer 1, shipped to their
+// default address
+func newOrder(bookID int, quantity int, couponCode string) models.Order {
+	return models.Order{
+		Customer:   models.Customer{ID: 1},
+		Items:      []models.OrderItem{{Book: models.Book{ID: bookID}, Quantity: quantity}},
+		CouponCode: couponCode,
+	}
+}
+
+func bookStock(t *testing.T, db *DB, bookID int) int {
+	t.Helper()
+
+	book, err := NewMemoryBookStore(db).GetBook(context.Background(), bookID)
+	if err != nil {
+		t.Fatalf("getting book %d: %v", bookID, err)
+	}
+	return book.Stock
+}
+
+func TestOrderStockReservation(t *testing.T) {
+	const bookID, quantity = 1, 2
+
+	tests := []struct {
+		name      string
+		statuses  []string // moves made after the order is placed
+		delete    bool
+		restore   bool
+		deleteErr error
+		wantTaken int // how much of the stock the order still holds
+	}{
+		{name: "pending order holds its stock", wantTaken: quantity},
+		{name: "cancelled while pending", statuses: []string{"cancelled"}},
+		{name: "cancelled after payment", statuses: []string{"paid", "cancelled"}},
+		{name: "refunded before shipping", statuses: []string{"paid", "refunded"}},
+		{name: "shipped", statuses: []string{"paid", "shipped"}, wantTaken: quantity},
+		{name: "refunded after delivery", statuses: []string{"paid", "shipped", "delivered", "refunded"}, wantTaken: quantity},
+		{name: "pending order cannot be deleted", delete: true, deleteErr: models.ErrOrderNotDeletable, wantTaken: quantity},
+		{name: "paid order cannot be deleted", statuses: []string{"paid"}, delete: true, deleteErr: models.ErrOrderNotDeletable, wantTaken: quantity},
+		{name: "shipped order deleted", statuses: []string{"paid", "shipped"}, delete: true, wantTaken: quantity},
+		{name: "cancelled order deleted and restored", statuses: []string{"cancelled"}, delete: true, restore: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			db := newSeededDB(t)
+			store := newOrderStore(db)
+			initial := bookStock(t, db, bookID)
+
+			order, err := store.CreateOrder(ctx, newOrder(bookID, quantity, ""))
+			if err != nil {
+				t.Fatalf("CreateOrder: %v", err)
+			}
+			if got := initial - bookStock(t, db, bookID); got != quantity {
+				t.Fatalf("placing the order took %d, want %d", got, quantity)
+			}
+
+			for _, status := range tt.statuses {
+				if _, err := store.UpdateOrderStatus(ctx, order.ID, status, 1); err != nil {
+					t.Fatalf("UpdateOrderStatus(%s): %v", status, err)
+				}
+			}
+			if tt.delete {
+				if err := store.DeleteOrder(ctx, order.ID); !errors.Is(err, tt.deleteErr) {
+					t.Fatalf("DeleteOrder error = %v, want %v", err, tt.deleteErr)
+				}
+			}
+			if tt.restore {
+				if _, err := store.RestoreOrder(ctx, order.ID); err != nil {
+					t.Fatalf("RestoreOrder: %v", err)
+				}
+			}
+
+			if got := initial - bookStock(t, db, bookID); got != tt.wantTaken {
+				t.Errorf("order holds %d of the stock, want %d", got, tt.wantTaken)
+			}
+		})
+	}
+}
+
+func TestOrderInsufficientStock(t *testing.T) {
+	const bookID = 3 // 7 seeded copies
+
+	tests := []struct {
+		name     string
+		quantity int
+		wantErr  bool
+	}{
+		{name: "whole stock", quantity: 7},
+		{name: "one more than the stock", quantity: 8, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db := newSeededDB(t)
+			initial := bookStock(t, db, bookID)
+
+			_, err := newOrderStore(db).CreateOrder(context.Background(), newOrder(bookID, tt.quantity, ""))
+
+			var stockErr *models.InsufficientStockError
+			switch {
+			case !tt.wantErr && err != nil:
+				t.Fatalf("CreateOrder: %v", err)
+			case tt.wantErr && !errors.As(err, &stockErr):
+				t.Fatalf("CreateOrder error = %v, want *models.InsufficientStockError", err)
+			case tt.wantErr && !reflect.DeepEqual(stockErr.BookIDs, []int{bookID}):
+				t.Errorf("short books = %v, want [%d]", stockErr.BookIDs, bookID)
+			}
+
+			wantStock := initial - tt.quantity
+			if tt.wantErr {
+				wantStock = initial
+			}
+			if got := bookStock(t, db, bookID); got != wantStock {
+				t.Errorf("stock = %d, want %d", got, wantStock)
+			}
+		})
+	}
+}
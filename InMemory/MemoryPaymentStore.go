@@ -0,0 +1,95 @@
+package inmemory
+
+import (
+	"context"
+	"time"
+
+	"online_bookStore/models"
+)
+
+type MemoryPaymentStore struct {
+	db *DB
+}
+
+// Constructor
+func NewMemoryPaymentStore(db *DB) *MemoryPaymentStore {
+	return &MemoryPaymentStore{
+		db: db,
+	}
+}
+
+// CreatePayment and UpdatePayment join the transaction carried by ctx
+func (s *MemoryPaymentStore) CreatePayment(ctx context.Context, payment models.Payment) (models.Payment, error) {
+	if payment.Status == "" {
+		payment.Status = models.PaymentStatusPending
+	}
+
+	err := s.db.write(ctx, func(t *tables) error {
+		if _, ok := t.orders[payment.OrderID]; !ok {
+			return missingReference("payment", "order_id")
+		}
+
+		payment.ID = t.nextID("payments")
+		payment.Amount = roundCents(payment.Amount)
+		payment.CreatedAt = time.Now()
+		payment.UpdatedAt = payment.CreatedAt
+		t.payments[payment.ID] = payment
+		return nil
+	})
+	return payment, err
+}
+
+// UpdatePayment changes the status and gateway references only
+func (s *MemoryPaymentStore) UpdatePayment(ctx context.Context, payment models.Payment) (models.Payment, error) {
+	var updated models.Payment
+	err := s.db.write(ctx, func(t *tables) error {
+		current, ok := t.payments[payment.ID]
+		if !ok {
+			return notFound("payment")
+		}
+
+		current.Status = payment.Status
+		current.AuthorizationID = payment.AuthorizationID
+		current.CaptureID = payment.CaptureID
+		current.RefundID = payment.RefundID
+		current.GatewayError = payment.GatewayError
+		current.UpdatedAt = time.Now()
+		t.payments[payment.ID] = current
+
+		updated = current
+		return nil
+	})
+	if err != nil {
+		return payment, err
+	}
+	return updated, nil
+}
+
+func (s *MemoryPaymentStore) GetPayment(ctx context.Context, id int) (models.Payment, error) {
+	var payment models.Payment
+	err := s.db.read(ctx, func(t *tables) error {
+		p, ok := t.payments[id]
+		if !ok {
+			return notFound("payment")
+		}
+		payment = p
+		return nil
+	})
+	return payment, err
+}
+
+func (s *MemoryPaymentStore) GetPaymentsByOrder(ctx context.Context, orderID int) ([]models.Payment, error) {
+	payments := []models.Payment{}
+	err := s.db.read(ctx, func(t *tables) error {
+		for _, id := range sortedKeys(t.payments) {
+			if t.payments[id].OrderID == orderID {
+				payments = append(payments, t.payments[id])
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return payments, nil
+}
@@ -0,0 +1,130 @@
+package inmemory
+
+import (
+	"context"
+	"fmt"
+
+	"online_bookStore/models"
+)
+
+// MemoryTaxCalculator looks rates up in the rules added with AddRule
+type MemoryTaxCalculator struct {
+	db *DB
+}
+
+// Constructor
+func NewMemoryTaxCalculator(db *DB) *MemoryTaxCalculator {
+	return &MemoryTaxCalculator{
+		db: db,
+	}
+}
+
+// AddRule stores a tax rule, replacing the one for the same destination
+func (c *MemoryTaxCalculator) AddRule(ctx context.Context, rule models.TaxRule) (models.TaxRule, error) {
+	err := c.db.write(ctx, func(t *tables) error {
+		var rules []models.TaxRule
+		for _, r := range t.taxRules {
+			if r.Country != rule.Country || r.State != rule.State {
+				rules = append(rules, r)
+			}
+		}
+		rule.ID = t.nextID("tax_rules")
+		t.taxRules = append(rules, rule)
+		return nil
+	})
+	return rule, err
+}
+
+// CalculateTax uses the most specific rule for the address: country and
+// state, then country only, then the "*" fallback. No rule means no tax.
+func (c *MemoryTaxCalculator) CalculateTax(ctx context.Context, address models.Address, taxable float64) (float64, error) {
+	var tax float64
+	err := c.db.read(ctx, func(t *tables) error {
+		best, bestRank := models.TaxRule{}, -1
+		for _, rule := range t.taxRules {
+			if rank := ruleRank(rule.Country, rule.State, address); rank > bestRank {
+				best, bestRank = rule, rank
+			}
+		}
+		if bestRank >= 0 {
+			tax = best.Tax(taxable)
+		}
+		return nil
+	})
+	return tax, err
+}
+
+// MemoryShippingCalculator looks rates up in the rules added with AddRule
+type MemoryShippingCalculator struct {
+	db *DB
+}
+
+// Constructor
+func NewMemoryShippingCalculator(db *DB) *MemoryShippingCalculator {
+	return &MemoryShippingCalculator{
+		db: db,
+	}
+}
+
+// AddRule stores a shipping rule, replacing the one for the same destination
+func (c *MemoryShippingCalculator) AddRule(ctx context.Context, rule models.ShippingRule) (models.ShippingRule, error) {
+	err := c.db.write(ctx, func(t *tables) error {
+		var rules []models.ShippingRule
+		for _, r := range t.shippingRules {
+			if r.Country != rule.Country || r.State != rule.State {
+				rules = append(rules, r)
+			}
+		}
+		rule.ID = t.nextID("shipping_rules")
+		t.shippingRules = append(rules, rule)
+		return nil
+	})
+	return rule, err
+}
+
+// CalculateShipping uses the most specific rule for the address, like
+// CalculateTax. Destinations without any rule cannot be shipped to.
+func (c *MemoryShippingCalculator) CalculateShipping(ctx context.Context, address models.Address, items []models.OrderItem, amount float64) (float64, error) {
+	var shipping float64
+	err := c.db.read(ctx, func(t *tables) error {
+		best, bestRank := models.ShippingRule{}, -1
+		for _, rule := range t.shippingRules {
+			if rank := ruleRank(rule.Country, rule.State, address); rank > bestRank {
+				best, bestRank = rule, rank
+			}
+		}
+		if bestRank < 0 {
+			return fmt.Errorf("%w: %s", models.ErrNoShippingRate, address.Country)
+		}
+
+		units, weight := 0, 0
+		for _, item := range items {
+			units += item.Quantity
+			weight += item.Book.WeightGrams * item.Quantity
+		}
+		shipping = best.Cost(amount, units, weight)
+		return nil
+	})
+	return shipping, err
+}
+
+// ruleRank orders the rules matching an address from the fallback (0) to
+// the exact country and state (3); -1 when the rule does not match
+func ruleRank(country string, state string, address models.Address) int {
+	rank := 0
+	switch {
+	case country == address.Country:
+		rank += 2
+	case country != "*":
+		return -1
+	}
+
+	switch {
+	case state == "":
+	case state == address.State:
+		rank++
+	default:
+		return -1
+	}
+	return rank
+}
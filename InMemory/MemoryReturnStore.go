@@ -0,0 +1,297 @@
+package inmemory
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"online_bookStore/models"
+)
+
+type MemoryReturnStore struct {
+	db *DB
+}
+
+// Constructor
+func NewMemoryReturnStore(db *DB) *MemoryReturnStore {
+	return &MemoryReturnStore{
+		db: db,
+	}
+}
+
+// CreateReturn records a return for a delivered order. Items naming the
+// same order item are merged, and no order item can be returned more times
+// than it was bought, counting earlier returns that were not rejected.
+func (s *MemoryReturnStore) CreateReturn(ctx context.Context, ret models.OrderReturn) (models.OrderReturn, error) {
+	if len(ret.Items) == 0 {
+		return ret, models.NewValidationError(models.ErrInvalidReturn, "return", "at least one item is required",
+			models.FieldError{Field: "items", Message: "at least one item is required"})
+	}
+
+	wanted := make(map[int]int)
+	for i, item := range ret.Items {
+		if item.Quantity <= 0 {
+			return ret, models.NewValidationError(models.ErrInvalidQuantity, "return", fmt.Sprintf("order item %d", item.OrderItemID),
+				models.FieldError{Field: fmt.Sprintf("items[%d].quantity", i), Message: "must be positive"})
+		}
+		wanted[item.OrderItemID] += item.Quantity
+	}
+
+	var id int
+	err := s.db.write(ctx, func(t *tables) error {
+		order, ok := t.orders[ret.OrderID]
+		if !ok || order.DeletedAt != nil {
+			return notFound("return")
+		}
+
+		// other customers' orders look like missing ones
+		if ret.CustomerID != 0 && ret.CustomerID != order.Customer.ID {
+			return notFound("return")
+		}
+		ret.CustomerID = order.Customer.ID
+
+		if models.NormalizeOrderStatus(order.Status) != models.OrderStatusDelivered {
+			return fmt.Errorf("%w: order is %s", models.ErrOrderNotReturnable, order.Status)
+		}
+
+		left := t.returnableQuantities(order)
+
+		orderItemIDs := make([]int, 0, len(wanted))
+		for orderItemID := range wanted {
+			orderItemIDs = append(orderItemIDs, orderItemID)
+		}
+		sort.Ints(orderItemIDs)
+
+		items := make([]models.ReturnItem, 0, len(orderItemIDs))
+		for _, orderItemID := range orderItemIDs {
+			item, ok := left[orderItemID]
+			if !ok {
+				return fmt.Errorf("%w: order item %d is not part of order %d", models.ErrInvalidReturn, orderItemID, ret.OrderID)
+			}
+			if wanted[orderItemID] > item.Quantity {
+				return fmt.Errorf("%w: only %d of order item %d can be returned", models.ErrInvalidReturn, item.Quantity, orderItemID)
+			}
+
+			item.ID = t.nextID("order_return_items")
+			item.Quantity = wanted[orderItemID]
+			items = append(items, item)
+		}
+
+		id = t.nextID("order_returns")
+		t.returns[id] = models.OrderReturn{
+			ID:         id,
+			OrderID:    ret.OrderID,
+			CustomerID: ret.CustomerID,
+			Status:     models.ReturnStatusRequested,
+			Reason:     ret.Reason,
+			Items:      items,
+			CreatedAt:  time.Now(),
+		}
+		return nil
+	})
+	if err != nil {
+		return ret, err
+	}
+
+	return s.GetReturn(ctx, id)
+}
+
+// returnableQuantities lists the order's items with the copies not yet
+// claimed by a requested or approved return
+func (t *tables) returnableQuantities(order orderRow) map[int]models.ReturnItem {
+	left := make(map[int]models.ReturnItem)
+	for _, item := range order.Items {
+		left[item.ID] = models.ReturnItem{
+			OrderItemID: item.ID,
+			BookID:      item.Book.ID,
+			Quantity:    item.Quantity,
+			UnitPrice:   item.UnitPrice,
+		}
+	}
+
+	for _, ret := range t.returns {
+		if ret.OrderID != order.ID || ret.Status == models.ReturnStatusRejected {
+			continue
+		}
+		for _, claimed := range ret.Items {
+			if item, ok := left[claimed.OrderItemID]; ok {
+				item.Quantity -= claimed.Quantity
+				left[claimed.OrderItemID] = item
+			}
+		}
+	}
+	return left
+}
+
+// orderReturn is a stored return with its refunded amount
+func (t *tables) orderReturn(ret models.OrderReturn) models.OrderReturn {
+	ret.Items = append([]models.ReturnItem{}, ret.Items...)
+	if ret.ReviewedAt != nil {
+		reviewedAt := *ret.ReviewedAt
+		ret.ReviewedAt = &reviewedAt
+	}
+
+	refunded := 0.0
+	for _, refund := range t.refunds {
+		if refund.ReturnID == ret.ID {
+			refunded += refund.Amount
+		}
+	}
+	ret.RefundedAmount = roundCents(refunded)
+	return ret
+}
+
+func (s *MemoryReturnStore) GetReturn(ctx context.Context, id int) (models.OrderReturn, error) {
+	var ret models.OrderReturn
+	err := s.db.read(ctx, func(t *tables) error {
+		stored, ok := t.returns[id]
+		if !ok {
+			return notFound("return")
+		}
+		ret = t.orderReturn(stored)
+		return nil
+	})
+	return ret, err
+}
+
+// GetReturns lists the newest returns first
+func (s *MemoryReturnStore) GetReturns(ctx context.Context, customerID int, status string) ([]models.OrderReturn, error) {
+	status = models.NormalizeReturnStatus(status)
+
+	returns := []models.OrderReturn{}
+	err := s.db.read(ctx, func(t *tables) error {
+		for _, id := range sortedKeys(t.returns) {
+			ret := t.returns[id]
+			if customerID != 0 && ret.CustomerID != customerID {
+				continue
+			}
+			if status != "" && ret.Status != status {
+				continue
+			}
+			returns = append(returns, t.orderReturn(ret))
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	sort.SliceStable(returns, func(i, j int) bool {
+		if !returns[i].CreatedAt.Equal(returns[j].CreatedAt) {
+			return returns[i].CreatedAt.After(returns[j].CreatedAt)
+		}
+		return returns[i].ID > returns[j].ID
+	})
+	return returns, nil
+}
+
+// ReviewReturn joins the transaction carried by ctx so the refund can be
+// recorded with the decision
+func (s *MemoryReturnStore) ReviewReturn(ctx context.Context, id int, status string, reviewedBy int, note string) (models.OrderReturn, error) {
+	status = models.NormalizeReturnStatus(status)
+	if status != models.ReturnStatusApproved && status != models.ReturnStatusRejected {
+		message := fmt.Sprintf("must be %q or %q", models.ReturnStatusApproved, models.ReturnStatusRejected)
+		return models.OrderReturn{}, models.NewValidationError(models.ErrInvalidReturn, "return", "status "+message,
+			models.FieldError{Field: "status", Message: message})
+	}
+
+	err := s.db.write(ctx, func(t *tables) error {
+		ret, ok := t.returns[id]
+		if !ok {
+			return notFound("return")
+		}
+		if ret.Status != models.ReturnStatusRequested {
+			return fmt.Errorf("%w: return is %s", models.ErrReturnReviewed, ret.Status)
+		}
+
+		ret.Status = status
+		ret.ReviewedBy = reviewedBy
+		ret.ReviewNote = note
+		ret.ReviewedAt = now()
+		t.returns[id] = ret
+
+		if status != models.ReturnStatusApproved {
+			return nil
+		}
+
+		returned := make(map[int]int)
+		for _, item := range ret.Items {
+			returned[item.BookID] += item.Quantity
+		}
+		t.moveStock(returned, 1)
+		return nil
+	})
+	if err != nil {
+		return models.OrderReturn{}, err
+	}
+
+	return s.GetReturn(ctx, id)
+}
+
+func (s *MemoryReturnStore) CreateRefund(ctx context.Context, refund models.Refund) (models.Refund, error) {
+	err := s.db.write(ctx, func(t *tables) error {
+		if _, ok := t.orders[refund.OrderID]; !ok {
+			return missingReference("refund", "order_id")
+		}
+		if _, ok := t.returns[refund.ReturnID]; refund.ReturnID != 0 && !ok {
+			return missingReference("refund", "return_id")
+		}
+		if _, ok := t.payments[refund.PaymentID]; refund.PaymentID != 0 && !ok {
+			return missingReference("refund", "payment_id")
+		}
+
+		refund.ID = t.nextID("refunds")
+		refund.Amount = roundCents(refund.Amount)
+		refund.Merchandise = roundCents(refund.Merchandise)
+		refund.Tax = roundCents(refund.Tax)
+		refund.Shipping = roundCents(refund.Shipping)
+		refund.CreatedAt = time.Now()
+		t.refunds[refund.ID] = refund
+		return nil
+	})
+	return refund, err
+}
+
+func (s *MemoryReturnStore) GetRefundedTotals(ctx context.Context, orderID int) (models.Refund, error) {
+	totals := models.Refund{OrderID: orderID}
+	err := s.db.read(ctx, func(t *tables) error {
+		for _, refund := range t.refunds {
+			if refund.OrderID == orderID {
+				totals.Amount += refund.Amount
+				totals.Merchandise += refund.Merchandise
+				totals.Tax += refund.Tax
+				totals.Shipping += refund.Shipping
+			}
+		}
+		return nil
+	})
+
+	totals.Amount = roundCents(totals.Amount)
+	totals.Merchandise = roundCents(totals.Merchandise)
+	totals.Tax = roundCents(totals.Tax)
+	totals.Shipping = roundCents(totals.Shipping)
+	return totals, err
+}
+
+// GetRefundsByDateRange includes both ends of the range, oldest first
+func (s *MemoryReturnStore) GetRefundsByDateRange(ctx context.Context, from time.Time, to time.Time) ([]models.Refund, error) {
+	var refunds []models.Refund
+	err := s.db.read(ctx, func(t *tables) error {
+		for _, id := range sortedKeys(t.refunds) {
+			refund := t.refunds[id]
+			if !refund.CreatedAt.Before(from) && !refund.CreatedAt.After(to) {
+				refunds = append(refunds, refund)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	sort.SliceStable(refunds, func(i, j int) bool {
+		return refunds[i].CreatedAt.Before(refunds[j].CreatedAt)
+	})
+	return refunds, nil
+}
@@ -0,0 +1,22 @@
+package inmemory
+
+import (
+	"context"
+)
+
+type MemoryTransactor struct {
+	db *DB
+}
+
+// Constructor
+func NewMemoryTransactor(db *DB) *MemoryTransactor {
+	return &MemoryTransactor{
+		db: db,
+	}
+}
+
+// WithinTransaction serializes transactions: no other store call runs
+// until fn returns, and fn's changes are dropped when it fails
+func (t *MemoryTransactor) WithinTransaction(ctx context.Context, fn func(ctx context.Context) error) error {
+	return t.db.transaction(ctx, fn)
+}
@@ -0,0 +1,298 @@
+package inmemory
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"online_bookStore/models"
+)
+
+type MemoryUserStore struct {
+	db *DB
+}
+
+// Constructor
+func NewMemoryUserStore(db *DB) *MemoryUserStore {
+	return &MemoryUserStore{db: db}
+}
+
+// userByEmail matches emails case-insensitively, like the MySQL collation
+func (t *tables) userByEmail(email string) (models.User, bool) {
+	for _, id := range sortedKeys(t.users) {
+		if strings.EqualFold(t.users[id].Email, email) {
+			return t.users[id], true
+		}
+	}
+	return models.User{}, false
+}
+
+// checkCustomerLink enforces the customer foreign key and the one user per
+// customer rule
+func (t *tables) checkCustomerLink(userID int, customerID int) error {
+	if customerID == 0 {
+		return nil
+	}
+	if _, ok := t.customers[customerID]; !ok {
+		return missingReference("user", "customer_id")
+	}
+	for _, user := range t.users {
+		if user.CustomerID == customerID && user.ID != userID {
+			return duplicate("user", "customer_id")
+		}
+	}
+	return nil
+}
+
+func (s *MemoryUserStore) GetByEmail(ctx context.Context, email string) (models.User, error) {
+	var user models.User
+	err := s.db.read(ctx, func(t *tables) error {
+		u, ok := t.userByEmail(email)
+		if !ok {
+			return notFound("user")
+		}
+		user = u
+		return nil
+	})
+	return user, err
+}
+
+func (s *MemoryUserStore) GetByID(ctx context.Context, id int) (models.User, error) {
+	var user models.User
+	err := s.db.read(ctx, func(t *tables) error {
+		u, ok := t.users[id]
+		if !ok {
+			return notFound("user")
+		}
+		user = u
+		return nil
+	})
+	return user, err
+}
+
+// CreateUser stores user.Password as given: callers must pass a hash
+// (see services.HashPassword), never the plaintext password.
+func (s *MemoryUserStore) CreateUser(ctx context.Context, user models.User) (models.User, error) {
+	err := s.db.write(ctx, func(t *tables) error {
+		if _, ok := t.userByEmail(user.Email); ok {
+			return duplicate("user", "email")
+		}
+		if err := t.checkCustomerLink(0, user.CustomerID); err != nil {
+			return err
+		}
+
+		user.ID = t.nextID("users")
+		user.CreatedAt = time.Now()
+		t.users[user.ID] = user
+		return nil
+	})
+	return user, err
+}
+
+func (s *MemoryUserStore) GetAllUsers(ctx context.Context) ([]models.User, error) {
+	var users []models.User
+	err := s.db.read(ctx, func(t *tables) error {
+		for _, id := range sortedKeys(t.users) {
+			users = append(users, t.users[id])
+		}
+		return nil
+	})
+	return users, err
+}
+
+// updateUser applies change to an existing user
+func (s *MemoryUserStore) updateUser(ctx context.Context, id int, change func(t *tables, user *models.User) error) (models.User, error) {
+	var user models.User
+	err := s.db.write(ctx, func(t *tables) error {
+		u, ok := t.users[id]
+		if !ok {
+			return notFound("user")
+		}
+		if err := change(t, &u); err != nil {
+			return err
+		}
+		t.users[id] = u
+
+		user = u
+		return nil
+	})
+	if err != nil {
+		return models.User{}, err
+	}
+	return user, nil
+}
+
+func (s *MemoryUserStore) UpdateRole(ctx context.Context, id int, role string) (models.User, error) {
+	return s.updateUser(ctx, id, func(t *tables, user *models.User) error {
+		user.Role = role
+		return nil
+	})
+}
+
+func (s *MemoryUserStore) SetDisabled(ctx context.Context, id int, disabled bool) (models.User, error) {
+	return s.updateUser(ctx, id, func(t *tables, user *models.User) error {
+		user.Disabled = disabled
+		return nil
+	})
+}
+
+// LinkCustomer associates the user with a customer record;
+// customerID 0 removes the association
+func (s *MemoryUserStore) LinkCustomer(ctx context.Context, id int, customerID int) (models.User, error) {
+	return s.updateUser(ctx, id, func(t *tables, user *models.User) error {
+		if err := t.checkCustomerLink(id, customerID); err != nil {
+			return err
+		}
+		user.CustomerID = customerID
+		return nil
+	})
+}
+
+func (s *MemoryUserStore) UpdatePassword(ctx context.Context, id int, passwordHash string) error {
+	_, err := s.updateUser(ctx, id, func(t *tables, user *models.User) error {
+		user.Password = passwordHash
+		return nil
+	})
+	return err
+}
+
+// insertRefreshToken stores a new token; token hashes are unique
+func (t *tables) insertRefreshToken(token models.RefreshToken) (models.RefreshToken, error) {
+	if _, ok := t.users[token.UserID]; !ok {
+		return token, missingReference("token", "user_id")
+	}
+	for _, other := range t.refreshTokens {
+		if other.TokenHash == token.TokenHash {
+			return token, duplicate("token", "token_hash")
+		}
+	}
+
+	token.ID = t.nextID("refresh_tokens")
+	token.RevokedAt = nil
+	token.ReplacedBy = 0
+	token.CreatedAt = time.Now()
+	t.refreshTokens[token.ID] = token
+	return token, nil
+}
+
+func (s *MemoryUserStore) CreateRefreshToken(ctx context.Context, token models.RefreshToken) (models.RefreshToken, error) {
+	var created models.RefreshToken
+	err := s.db.write(ctx, func(t *tables) error {
+		var err error
+		created, err = t.insertRefreshToken(token)
+		return err
+	})
+	if err != nil {
+		return token, err
+	}
+	return created, nil
+}
+
+func (s *MemoryUserStore) GetRefreshToken(ctx context.Context, tokenHash string) (models.RefreshToken, error) {
+	var token models.RefreshToken
+	err := s.db.read(ctx, func(t *tables) error {
+		for _, stored := range t.refreshTokens {
+			if stored.TokenHash == tokenHash {
+				token = stored
+				if stored.RevokedAt != nil {
+					revokedAt := *stored.RevokedAt
+					token.RevokedAt = &revokedAt
+				}
+				return nil
+			}
+		}
+		return notFound("token")
+	})
+	return token, err
+}
+
+// RotateRefreshToken revokes the old token and stores its replacement
+// atomically. It returns sql.ErrNoRows when the old token was already
+// revoked, which means it is being reused.
+func (s *MemoryUserStore) RotateRefreshToken(ctx context.Context, oldID int, next models.RefreshToken) (models.RefreshToken, error) {
+	var created models.RefreshToken
+	err := s.db.write(ctx, func(t *tables) error {
+		old, ok := t.refreshTokens[oldID]
+		if !ok || old.RevokedAt != nil {
+			return notFound("token")
+		}
+
+		var err error
+		created, err = t.insertRefreshToken(next)
+		if err != nil {
+			return err
+		}
+
+		old.RevokedAt = now()
+		old.ReplacedBy = created.ID
+		t.refreshTokens[oldID] = old
+		return nil
+	})
+	if err != nil {
+		return next, err
+	}
+	return created, nil
+}
+
+// revokeRefreshTokens revokes the live tokens matching keep
+func (s *MemoryUserStore) revokeRefreshTokens(ctx context.Context, keep func(token models.RefreshToken) bool) error {
+	return s.db.write(ctx, func(t *tables) error {
+		revokedAt := now()
+		for id, token := range t.refreshTokens {
+			if token.RevokedAt == nil && keep(token) {
+				token.RevokedAt = revokedAt
+				t.refreshTokens[id] = token
+			}
+		}
+		return nil
+	})
+}
+
+func (s *MemoryUserStore) RevokeRefreshTokenFamily(ctx context.Context, familyID string) error {
+	return s.revokeRefreshTokens(ctx, func(token models.RefreshToken) bool {
+		return token.FamilyID == familyID
+	})
+}
+
+func (s *MemoryUserStore) RevokeUserRefreshTokens(ctx context.Context, userID int) error {
+	return s.revokeRefreshTokens(ctx, func(token models.RefreshToken) bool {
+		return token.UserID == userID
+	})
+}
+
+// RevokeAccessToken keeps the first expiry recorded for a jti
+func (s *MemoryUserStore) RevokeAccessToken(ctx context.Context, jti string, expiresAt time.Time) error {
+	return s.db.write(ctx, func(t *tables) error {
+		if _, ok := t.revokedTokens[jti]; !ok {
+			t.revokedTokens[jti] = expiresAt
+		}
+		return nil
+	})
+}
+
+func (s *MemoryUserStore) IsAccessTokenRevoked(ctx context.Context, jti string) (bool, error) {
+	var revoked bool
+	err := s.db.read(ctx, func(t *tables) error {
+		_, revoked = t.revokedTokens[jti]
+		return nil
+	})
+	return revoked, err
+}
+
+// DeleteExpiredTokens purges refresh tokens and revocation entries that
+// can no longer be presented
+func (s *MemoryUserStore) DeleteExpiredTokens(ctx context.Context, before time.Time) error {
+	return s.db.write(ctx, func(t *tables) error {
+		for id, token := range t.refreshTokens {
+			if token.ExpiresAt.Before(before) {
+				delete(t.refreshTokens, id)
+			}
+		}
+		for jti, expiresAt := range t.revokedTokens {
+			if expiresAt.Before(before) {
+				delete(t.revokedTokens, jti)
+			}
+		}
+		return nil
+	})
+}
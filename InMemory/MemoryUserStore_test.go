@@ -0,0 +1,119 @@
+package inmemory
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"testing"
+	"time"
+
+	"online_bookStore/models"
+)
+
+func newRefreshToken(hash string, familyID string) models.RefreshToken {
+	return models.RefreshToken{
+		UserID:    1,
+		TokenHash: hash,
+		FamilyID:  familyID,
+		ExpiresAt: time.Now().Add(time.Hour),
+	}
+}
+
+func TestRotateRefreshToken(t *testing.T) {
+	tests := []struct {
+		name    string
+		revoke  bool // the old token is revoked before rotating
+		rotated bool // the old token was already rotated once
+		wantErr error
+	}{
+		{name: "live token"},
+		{name: "already rotated", rotated: true, wantErr: sql.ErrNoRows},
+		{name: "revoked", revoke: true, wantErr: sql.ErrNoRows},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			store := NewMemoryUserStore(newSeededDB(t))
+
+			old, err := store.CreateRefreshToken(ctx, newRefreshToken("old", "family"))
+			if err != nil {
+				t.Fatalf("CreateRefreshToken: %v", err)
+			}
+			if tt.revoke {
+				if err := store.RevokeRefreshTokenFamily(ctx, "family"); err != nil {
+					t.Fatalf("RevokeRefreshTokenFamily: %v", err)
+				}
+			}
+			if tt.rotated {
+				if _, err := store.RotateRefreshToken(ctx, old.ID, newRefreshToken("first", "family")); err != nil {
+					t.Fatalf("first RotateRefreshToken: %v", err)
+				}
+			}
+
+			next, err := store.RotateRefreshToken(ctx, old.ID, newRefreshToken("next", "family"))
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("RotateRefreshToken error = %v, want %v", err, tt.wantErr)
+			}
+
+			_, err = store.GetRefreshToken(ctx, "next")
+			if tt.wantErr != nil {
+				// a refused rotation stores nothing
+				if !errors.Is(err, sql.ErrNoRows) {
+					t.Errorf("replacement stored after a refused rotation (err = %v)", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("GetRefreshToken(next): %v", err)
+			}
+
+			stored, err := store.GetRefreshToken(ctx, "old")
+			if err != nil {
+				t.Fatalf("GetRefreshToken(old): %v", err)
+			}
+			if stored.RevokedAt == nil || stored.ReplacedBy != next.ID {
+				t.Errorf("old token revoked_at = %v, replaced_by = %d; want revoked and replaced by %d",
+					stored.RevokedAt, stored.ReplacedBy, next.ID)
+			}
+		})
+	}
+}
+
+func TestRevokeRefreshTokenFamily(t *testing.T) {
+	ctx := context.Background()
+	store := NewMemoryUserStore(newSeededDB(t))
+
+	for _, token := range []models.RefreshToken{
+		newRefreshToken("a1", "a"),
+		newRefreshToken("a2", "a"),
+		newRefreshToken("b1", "b"),
+	} {
+		if _, err := store.CreateRefreshToken(ctx, token); err != nil {
+			t.Fatalf("CreateRefreshToken(%s): %v", token.TokenHash, err)
+		}
+	}
+
+	if err := store.RevokeRefreshTokenFamily(ctx, "a"); err != nil {
+		t.Fatalf("RevokeRefreshTokenFamily: %v", err)
+	}
+
+	tests := []struct {
+		hash        string
+		wantRevoked bool
+	}{
+		{hash: "a1", wantRevoked: true},
+		{hash: "a2", wantRevoked: true},
+		{hash: "b1", wantRevoked: false},
+	}
+
+	for _, tt := range tests {
+		token, err := store.GetRefreshToken(ctx, tt.hash)
+		if err != nil {
+			t.Fatalf("GetRefreshToken(%s): %v", tt.hash, err)
+		}
+		if revoked := token.RevokedAt != nil; revoked != tt.wantRevoked {
+			t.Errorf("token %s revoked = %v, want %v", tt.hash, revoked, tt.wantRevoked)
+		}
+	}
+}
@@ -0,0 +1,268 @@
+package inmemory
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"math"
+	"sort"
+	"sync"
+	"time"
+
+	"online_bookStore/Interfaces"
+	"online_bookStore/models"
+)
+
+// DB holds every table of the in-memory stores. All stores built on the
+// same DB see the same data, like the MySQL stores sharing one database.
+//
+// Writes work on a copy of the tables that replaces them only when the
+// write succeeds, so a failed write changes nothing. Stored rows are never
+// modified in place: slices and pointers are shared between copies and
+// replaced, not mutated.
+type DB struct {
+	mu   sync.RWMutex
+	data *tables
+}
+
+// Constructor
+func NewDB() *DB {
+	return &DB{data: newTables()}
+}
+
+type tables struct {
+	seq map[string]int // last id handed out per table
+
+	authors       map[int]models.Author
+	books         map[int]models.Book // Author holds the author id only
+	genres        map[int]string      // genre names by id
+	customers     map[int]models.Customer
+	addresses     map[int]models.CustomerAddress
+	coupons       map[int]models.Coupon
+	orders        map[int]orderRow
+	statusHistory map[int][]models.OrderStatusChange // by order id
+	payments      map[int]models.Payment
+	returns       map[int]models.OrderReturn
+	refunds       map[int]models.Refund
+	users         map[int]models.User
+	refreshTokens map[int]models.RefreshToken
+	revokedTokens map[string]time.Time // jti -> expiry
+	carts         map[int]cartRow      // by customer id
+	idempotency   map[idempotencyKey]models.IdempotencyRecord
+	taxRules      []models.TaxRule
+	shippingRules []models.ShippingRule
+}
+
+func newTables() *tables {
+	return &tables{
+		seq:           make(map[string]int),
+		authors:       make(map[int]models.Author),
+		books:         make(map[int]models.Book),
+		genres:        make(map[int]string),
+		customers:     make(map[int]models.Customer),
+		addresses:     make(map[int]models.CustomerAddress),
+		coupons:       make(map[int]models.Coupon),
+		orders:        make(map[int]orderRow),
+		statusHistory: make(map[int][]models.OrderStatusChange),
+		payments:      make(map[int]models.Payment),
+		returns:       make(map[int]models.OrderReturn),
+		refunds:       make(map[int]models.Refund),
+		users:         make(map[int]models.User),
+		refreshTokens: make(map[int]models.RefreshToken),
+		revokedTokens: make(map[string]time.Time),
+		carts:         make(map[int]cartRow),
+		idempotency:   make(map[idempotencyKey]models.IdempotencyRecord),
+	}
+}
+
+func (t *tables) clone() *tables {
+	c := &tables{
+		seq:           copyMap(t.seq),
+		authors:       copyMap(t.authors),
+		books:         copyMap(t.books),
+		genres:        copyMap(t.genres),
+		customers:     copyMap(t.customers),
+		addresses:     copyMap(t.addresses),
+		coupons:       copyMap(t.coupons),
+		orders:        copyMap(t.orders),
+		statusHistory: copyMap(t.statusHistory),
+		payments:      copyMap(t.payments),
+		returns:       copyMap(t.returns),
+		refunds:       copyMap(t.refunds),
+		users:         copyMap(t.users),
+		refreshTokens: copyMap(t.refreshTokens),
+		revokedTokens: copyMap(t.revokedTokens),
+		carts:         make(map[int]cartRow, len(t.carts)),
+		idempotency:   copyMap(t.idempotency),
+		taxRules:      t.taxRules,
+		shippingRules: t.shippingRules,
+	}
+	// cart rows are the only ones holding a map
+	for customerID, cart := range t.carts {
+		cart.items = copyMap(cart.items)
+		c.carts[customerID] = cart
+	}
+	return c
+}
+
+func copyMap[K comparable, V any](m map[K]V) map[K]V {
+	c := make(map[K]V, len(m))
+	for k, v := range m {
+		c[k] = v
+	}
+	return c
+}
+
+// nextID hands out the next id of table, like AUTO_INCREMENT
+func (t *tables) nextID(table string) int {
+	t.seq[table]++
+	return t.seq[table]
+}
+
+type txKey struct{}
+
+// transaction is what ctx carries inside WithinTransaction
+type transaction struct {
+	db   *DB
+	data *tables
+}
+
+func (db *DB) inTx(ctx context.Context) (*tables, bool) {
+	tx, ok := ctx.Value(txKey{}).(*transaction)
+	if !ok || tx.db != db {
+		return nil, false
+	}
+	return tx.data, true
+}
+
+// read runs fn on the tables, joining the transaction carried by ctx
+func (db *DB) read(ctx context.Context, fn func(t *tables) error) error {
+	if err := ctxError(ctx); err != nil {
+		return err
+	}
+	if t, ok := db.inTx(ctx); ok {
+		return fn(t)
+	}
+
+	db.mu.RLock()
+	defer db.mu.RUnlock()
+	return fn(db.data)
+}
+
+// write runs fn on a copy of the tables that is kept when fn succeeds.
+// Inside a transaction fn changes the transaction's copy directly; the
+// transaction decides what is kept.
+func (db *DB) write(ctx context.Context, fn func(t *tables) error) error {
+	if err := ctxError(ctx); err != nil {
+		return err
+	}
+	if t, ok := db.inTx(ctx); ok {
+		return fn(t)
+	}
+
+	db.mu.Lock()
+	defer db.mu.Unlock()
+
+	t := db.data.clone()
+	if err := fn(t); err != nil {
+		return err
+	}
+	db.data = t
+	return nil
+}
+
+// transaction runs fn with a context carrying a copy of the tables that
+// every store call made with it reads and writes. The write lock is held
+// while fn runs, so transactions are serialized, and fn's changes are kept
+// only when it returns nil. A transaction carried by ctx is joined.
+func (db *DB) transaction(ctx context.Context, fn func(ctx context.Context) error) error {
+	if _, ok := db.inTx(ctx); ok {
+		return fn(ctx)
+	}
+	if err := ctxError(ctx); err != nil {
+		return err
+	}
+
+	db.mu.Lock()
+	defer db.mu.Unlock()
+
+	tx := &transaction{db: db, data: db.data.clone()}
+	if err := fn(context.WithValue(ctx, txKey{}, tx)); err != nil {
+		return err
+	}
+	db.data = tx.data
+	return nil
+}
+
+// ctxError mirrors what the MySQL driver reports for a context that is done
+func ctxError(ctx context.Context) error {
+	err := ctx.Err()
+	if errors.Is(err, context.DeadlineExceeded) {
+		return &models.UnavailableError{Err: err}
+	}
+	return err
+}
+
+// visible reports whether a record deleted at deletedAt is seen by reads
+// made with ctx (see interfaces.WithDeleted)
+func visible(ctx context.Context, deletedAt *time.Time) bool {
+	return deletedAt == nil || interfaces.IncludeDeleted(ctx)
+}
+
+func now() *time.Time {
+	t := time.Now()
+	return &t
+}
+
+// sortedKeys lists the ids of a table in insertion order
+func sortedKeys[V any](m map[int]V) []int {
+	ids := make([]int, 0, len(m))
+	for id := range m {
+		ids = append(ids, id)
+	}
+	sort.Ints(ids)
+	return ids
+}
+
+// The errors below are the ones the MySQL stores translate driver errors
+// into, so callers cannot tell the two implementations apart.
+
+func notFound(entity string) error {
+	return &models.NotFoundError{Entity: entity, Err: sql.ErrNoRows}
+}
+
+func duplicate(entity string, field string) error {
+	return &models.ConflictError{
+		Entity:  entity,
+		Field:   field,
+		Message: entity + " with this " + field + " already exists",
+	}
+}
+
+func missingReference(entity string, field string) error {
+	return &models.ValidationError{
+		Entity:  entity,
+		Fields:  []models.FieldError{{Field: field, Message: "refers to a record that does not exist"}},
+		Message: field + " refers to a record that does not exist",
+	}
+}
+
+// dependents is the *models.DependentsError refusing a delete, listing
+// the blockers that count records; nil when none does
+func dependents(entity string, id int, blockers []models.Blocker) error {
+	var found []models.Blocker
+	for _, blocker := range blockers {
+		if blocker.Count > 0 {
+			found = append(found, blocker)
+		}
+	}
+	if len(found) == 0 {
+		return nil
+	}
+	return &models.DependentsError{Entity: entity, ID: id, Blockers: found}
+}
+
+// money is kept in DECIMAL(10,2) columns
+func roundCents(amount float64) float64 {
+	return math.Round(amount*100) / 100
+}
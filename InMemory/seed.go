@@ -0,0 +1,211 @@
+package inmemory
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"online_bookStore/models"
+)
+
+// Seed fills db with the sample data of DataBase/data.sql, so a server
+// running on the in-memory stores can be demoed right away. Seeded orders
+// keep the stored totals and, like the SQL seed, do not move stock.
+func Seed(ctx context.Context, db *DB) error {
+	return db.write(ctx, func(t *tables) error {
+		createdAt := time.Now()
+
+		for _, a := range []struct{ first, last, bio string }{
+			{"Maya", "Torres", "Writes contemporary fiction and short stories."},
+			{"Daniel", "Hughes", "Focuses on technology and AI ethics."},
+			{"Leila", "Khan", "Author of historical mystery novels."},
+			{"Jon", "Wright", "Specializes in business and leadership."},
+			{"Priya", "Desai", "Poet and essayist."},
+			{"Nate", "Collins", "Writes science fiction and thrillers."},
+			{"Ava", "Brooks", "Children’s author and illustrator."},
+			{"Omar", "Hassan", "Researcher in data science and analytics."},
+			{"Grace", "Kim", "Young adult fiction writer."},
+			{"Hector", "Vega", "Writes travel and memoirs."},
+		} {
+			id := t.nextID("authors")
+			t.authors[id] = models.Author{ID: id, FirstName: a.first, LastName: a.last, Bio: a.bio}
+		}
+
+		for _, name := range []string{
+			"Adventure", "Business", "Children", "Data", "Drama", "Essay", "Fiction", "Historical", "Leadership", "Memoir",
+			"Mystery", "Non-Fiction", "Picture Book", "Poetry", "Sci-Fi", "Technology", "Thriller", "Travel", "YA",
+		} {
+			t.genres[t.nextID("genres")] = name
+		}
+
+		for _, b := range []struct {
+			title, genres, published string
+			price                    float64
+			stock, weight, authorID  int
+		}{
+			{"The Quiet Shore", "Fiction,Drama", "2019-05-14", 14.99, 42, 420, 1},
+			{"Ethics of Machines", "Technology,Non-Fiction", "2021-09-21", 29.50, 12, 610, 2},
+			{"Ashes of the Crown", "Historical,Mystery", "2018-02-01", 18.75, 7, 480, 3},
+			{"Signals in the Dark", "Sci-Fi,Thriller", "2023-11-03", 22.00, 19, 390, 6},
+			{"Leading with Clarity", "Business,Leadership", "2020-03-10", 24.00, 15, 530, 4},
+			{"City of Paper", "Poetry,Essay", "2017-08-19", 12.50, 30, 210, 5},
+			{"Starlight Protocol", "Sci-Fi", "2022-06-12", 19.99, 9, 370, 6},
+			{"Tiny Atlas", "Children,Adventure", "2016-04-22", 9.99, 50, 300, 7},
+			{"Data Stories", "Technology,Data", "2021-01-05", 27.00, 14, 560, 8},
+			{"Winter Lines", "YA,Fiction", "2019-12-02", 15.25, 18, 400, 9},
+			{"Sunset Roads", "Travel,Memoir", "2018-10-11", 21.40, 11, 450, 10},
+			{"Glass Horizon", "Sci-Fi,Drama", "2024-02-15", 23.60, 13, 440, 6},
+			{"Team Metrics", "Business,Data", "2020-07-07", 26.80, 10, 520, 8},
+			{"Hidden Harbor", "Mystery,Fiction", "2017-01-29", 16.90, 17, 380, 3},
+			{"Bright Kite", "Children,Picture Book", "2015-09-09", 8.75, 60, 260, 7},
+		} {
+			published, err := time.Parse("2006-01-02", b.published)
+			if err != nil {
+				return err
+			}
+
+			id := t.nextID("books")
+			t.books[id] = models.Book{
+				ID:     id,
+				Title:  b.title,
+				Author: models.Author{ID: b.authorID},
+				Contributors: []models.Contributor{
+					{Author: models.Author{ID: b.authorID}, Role: models.RoleAuthor},
+				},
+				Genres:      t.genreNames(strings.Split(b.genres, ",")),
+				PublishedAt: published,
+				Price:       b.price,
+				Stock:       b.stock,
+				WeightGrams: b.weight,
+			}
+		}
+
+		for _, c := range []struct {
+			name, email                          string
+			street, city, state, postal, country string
+		}{
+			{"Caroline Reed", "caroline.reed@example.com", "1457 Maple Ave", "Seattle", "WA", "98109", "USA"},
+			{"Marcus Hill", "marcus.hill@example.com", "88 Pine Street", "Boston", "MA", "02108", "USA"},
+			{"Aisha Patel", "aisha.patel@example.com", "2100 Market St", "San Francisco", "CA", "94114", "USA"},
+			{"Liam Chen", "liam.chen@example.com", "19 River Lane", "Austin", "TX", "78701", "USA"},
+			{"Sofia Alvarez", "sofia.alvarez@example.com", "502 Oak Blvd", "Denver", "CO", "80202", "USA"},
+			{"Noah Bennett", "noah.bennett@example.com", "73 Hillcrest Rd", "Portland", "OR", "97205", "USA"},
+			{"Ivy Sanders", "ivy.sanders@example.com", "900 Lake Dr", "Chicago", "IL", "60611", "USA"},
+			{"Ethan Park", "ethan.park@example.com", "12 Rose Ct", "Miami", "FL", "33130", "USA"},
+			{"Zara Coleman", "zara.coleman@example.com", "600 Elm St", "Raleigh", "NC", "27601", "USA"},
+			{"Miguel Santos", "miguel.santos@example.com", "33 Sunset Ave", "Phoenix", "AZ", "85004", "USA"},
+			{"Olivia Grant", "olivia.grant@example.com", "410 Birch Pkwy", "Nashville", "TN", "37203", "USA"},
+			{"Jackson Lee", "jackson.lee@example.com", "5 Harbor Way", "San Diego", "CA", "92101", "USA"},
+		} {
+			id := t.nextID("customers")
+			t.customers[id] = models.Customer{ID: id, Name: c.name, Email: c.email, CreatedAt: createdAt}
+			t.insertAddress(models.CustomerAddress{
+				Address:           models.Address{Street: c.street, City: c.city, State: c.state, PostalCode: c.postal, Country: c.country},
+				CustomerID:        id,
+				Label:             models.DefaultAddressLabel,
+				IsDefaultShipping: true,
+				IsDefaultBilling:  true,
+			})
+		}
+		t.insertAddress(models.CustomerAddress{
+			Address:    models.Address{Street: "400 Fairview Ave N", City: "Seattle", State: "WA", PostalCode: "98109", Country: "USA"},
+			CustomerID: 1,
+			Label:      "work",
+		})
+
+		// country "*" is everywhere else
+		for _, rule := range []models.TaxRule{
+			{Country: "USA", Rate: 0.05},
+			{Country: "USA", State: "WA", Rate: 0.065},
+			{Country: "USA", State: "CA", Rate: 0.0725},
+			{Country: "USA", State: "TX", Rate: 0.0625},
+			{Country: "USA", State: "OR", Rate: 0},
+		} {
+			rule.ID = t.nextID("tax_rules")
+			t.taxRules = append(t.taxRules, rule)
+		}
+		for _, rule := range []models.ShippingRule{
+			{Country: "USA", BaseFee: 3.99, PerItemFee: 0.50, PerKgFee: 1.00, FreeShippingThreshold: 50.00},
+			{Country: "*", BaseFee: 12.99, PerItemFee: 1.00, PerKgFee: 4.00},
+		} {
+			rule.ID = t.nextID("shipping_rules")
+			t.shippingRules = append(t.shippingRules, rule)
+		}
+
+		for _, coupon := range []models.Coupon{
+			{Code: "WELCOME10", Type: models.CouponPercentage, Value: 10, Active: true},
+			{Code: "SAVE5", Type: models.CouponFixed, Value: 5, MinOrderValue: 30, UsageLimit: 100, Active: true},
+			{Code: "FICTION3FOR2", Type: models.CouponBuyXGetY, BuyQuantity: 2, FreeQuantity: 1, Genre: "Fiction", Active: true},
+		} {
+			if err := t.putCoupon(t.nextID("coupons"), coupon); err != nil {
+				return err
+			}
+		}
+
+		type item struct {
+			bookID, quantity int
+			unitPrice        float64
+		}
+		for _, o := range []struct {
+			customerID int
+			total      float64
+			status     string
+			items      []item
+		}{
+			{1, 29.98, models.OrderStatusPaid, []item{{1, 2, 14.99}}},
+			{2, 29.50, models.OrderStatusShipped, []item{{2, 1, 29.50}}},
+			{3, 18.75, models.OrderStatusPending, []item{{3, 1, 18.75}}},
+			{4, 46.00, models.OrderStatusDelivered, []item{{5, 1, 24.00}, {8, 1, 9.99}, {15, 1, 8.75}}},
+			{5, 22.00, models.OrderStatusCancelled, []item{{4, 1, 22.00}}},
+			{6, 34.99, models.OrderStatusPaid, []item{{7, 1, 19.99}, {1, 1, 14.99}}},
+			{7, 39.98, models.OrderStatusDelivered, []item{{12, 1, 23.60}, {14, 1, 16.90}}},
+			{8, 12.50, models.OrderStatusPaid, []item{{6, 1, 12.50}}},
+			{9, 43.80, models.OrderStatusShipped, []item{{13, 1, 26.80}, {10, 1, 15.25}}},
+			{10, 21.40, models.OrderStatusDelivered, []item{{11, 1, 21.40}}},
+			{11, 24.00, models.OrderStatusPending, []item{{5, 1, 24.00}}},
+			{12, 32.15, models.OrderStatusPaid, []item{{1, 1, 14.99}, {10, 1, 15.25}}},
+			{1, 27.00, models.OrderStatusPaid, []item{{9, 1, 27.00}}},
+			{2, 16.90, models.OrderStatusDelivered, []item{{14, 1, 16.90}}},
+			{3, 9.99, models.OrderStatusPaid, []item{{8, 1, 9.99}}},
+			{4, 52.75, models.OrderStatusShipped, []item{{3, 1, 18.75}, {2, 1, 29.50}}},
+			{5, 23.60, models.OrderStatusPaid, []item{{12, 1, 23.60}}},
+			{6, 26.80, models.OrderStatusDelivered, []item{{13, 1, 26.80}}},
+		} {
+			// seed orders ship and bill to the customer's default address
+			customer, _ := t.customer(o.customerID)
+			address := customer.Address
+			address.ID = 0
+
+			row := orderRow{Order: models.Order{
+				ID:              t.nextID("orders"),
+				Customer:        models.Customer{ID: o.customerID},
+				Subtotal:        o.total,
+				TotalPrice:      o.total,
+				ShippingAddress: copyAddress(&address),
+				BillingAddress:  copyAddress(&address),
+				CreatedAt:       createdAt,
+				Status:          o.status,
+			}}
+			for _, i := range o.items {
+				row.Items = append(row.Items, models.OrderItem{
+					ID:        t.nextID("order_items"),
+					Book:      models.Book{ID: i.bookID},
+					Quantity:  i.quantity,
+					UnitPrice: i.unitPrice,
+				})
+			}
+			t.orders[row.ID] = row
+		}
+
+		// bcrypt hashes; admin password: Admin1234, shopper password: Shopper123
+		for _, user := range []models.User{
+			{Email: "admin@example.com", Password: "$2a$10$ui.e51beQKKbHhboG1F7Pur7X43.TfbJWmPRyE0oty4ijI0p8K612", Role: models.RoleAdmin},
+			{Email: "caroline.reed@example.com", Password: "$2a$10$r2qTF3ZNji/.r8GfPxUiYOeZmF7Mq0738uzjN7rp1Pz1rg6Ha41zq", Role: models.RoleUser, CustomerID: 1},
+		} {
+			user.ID = t.nextID("users")
+			user.CreatedAt = createdAt
+			t.users[user.ID] = user
+		}
+		return nil
+	})
+}
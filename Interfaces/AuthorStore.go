@@ -9,8 +9,11 @@ type AuthorStore interface {
 	CreateAuthor(ctx context.Context, author models.Author) (models.Author, error)
 	GetAuthor(ctx context.Context, id int) (models.Author, error)
 	UpdateAuthor(ctx context.Context, id int, author models.Author) (models.Author, error)
-	DeleteAuthor(ctx context.Context, id int) error
+	// DeleteAuthor returns *models.DependentsError while records depend
+	// on the author, unless force is set
+	DeleteAuthor(ctx context.Context, id int, force bool) error
 	GetAllAuthors(ctx context.Context) ([]models.Author, error)
+	RestoreAuthor(ctx context.Context, id int) (models.Author, error)
 	
 }
 
@@ -9,6 +9,9 @@ type BookStore interface {
  CreateBook(ctx context.Context,book models.Book) (models.Book, error) 
  GetBook(ctx context.Context,id int) (models.Book, error) 
  UpdateBook(ctx context.Context,id int, book models.Book) (models.Book, error) 
- DeleteBook(ctx context.Context,id int) error 
+ // DeleteBook returns *models.DependentsError while records depend on
+ // the book, unless force is set
+ DeleteBook(ctx context.Context, id int, force bool) error
  SearchBooks(ctx context.Context,searchCriteria models.SearchCriteria)([]models.Book, error)
+ RestoreBook(ctx context.Context, id int) (models.Book, error)
 } 
\ No newline at end of file
@@ -0,0 +1,17 @@
+package interfaces
+
+import (
+	"context"
+	"online_bookStore/models"
+	"time"
+)
+
+type CartStore interface {
+	// GetCart returns the customer's cart; an expired or missing cart is empty
+	GetCart(ctx context.Context, customerID int) (models.Cart, error)
+	AddItem(ctx context.Context, customerID int, bookID int, quantity int) (models.Cart, error)
+	SetItemQuantity(ctx context.Context, customerID int, bookID int, quantity int) (models.Cart, error)
+	RemoveItem(ctx context.Context, customerID int, bookID int) (models.Cart, error)
+	ClearCart(ctx context.Context, customerID int) error
+	DeleteExpiredCarts(ctx context.Context, before time.Time) (int64, error)
+}
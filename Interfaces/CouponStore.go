@@ -0,0 +1,15 @@
+package interfaces
+
+import (
+	"context"
+	"online_bookStore/models"
+)
+
+type CouponStore interface {
+	CreateCoupon(ctx context.Context, coupon models.Coupon) (models.Coupon, error)
+	GetCoupon(ctx context.Context, id int) (models.Coupon, error)
+	GetCouponByCode(ctx context.Context, code string) (models.Coupon, error)
+	UpdateCoupon(ctx context.Context, id int, coupon models.Coupon) (models.Coupon, error)
+	DeleteCoupon(ctx context.Context, id int) error
+	GetAllCoupons(ctx context.Context) ([]models.Coupon, error)
+}
@@ -9,6 +9,17 @@ type CustomerStore interface {
 	CreateCustomer(ctx context.Context,customer models.Customer) (models.Customer, error)
 	GetCustomer(ctx context.Context,id int) (models.Customer, error)
 	UpdateCustomer(ctx context.Context,id int, customer models.Customer) (models.Customer, error)
-	DeleteCustomer(ctx context.Context,id int) error
+	// DeleteCustomer returns *models.DependentsError while records depend
+	// on the customer, unless force is set
+	DeleteCustomer(ctx context.Context, id int, force bool) error
 	GetAllCustomers(ctx context.Context) ([]models.Customer, error)
-}
\ No newline at end of file
+	RestoreCustomer(ctx context.Context, id int) (models.Customer, error)
+
+	// saved addresses; every method returns sql.ErrNoRows when the
+	// address does not belong to the customer
+	GetAddresses(ctx context.Context, customerID int) ([]models.CustomerAddress, error)
+	GetAddress(ctx context.Context, customerID int, addressID int) (models.CustomerAddress, error)
+	AddAddress(ctx context.Context, customerID int, address models.CustomerAddress) (models.CustomerAddress, error)
+	UpdateAddress(ctx context.Context, customerID int, addressID int, address models.CustomerAddress) (models.CustomerAddress, error)
+	DeleteAddress(ctx context.Context, customerID int, addressID int) error
+}
@@ -0,0 +1,11 @@
+package interfaces
+
+import (
+	"context"
+	"online_bookStore/models"
+)
+
+type GenreStore interface {
+	// ListGenres returns every genre by name with its book count
+	ListGenres(ctx context.Context) ([]models.Genre, error)
+}
@@ -0,0 +1,19 @@
+package interfaces
+
+import (
+	"context"
+	"online_bookStore/models"
+	"time"
+)
+
+type IdempotencyStore interface {
+	// Reserve stores record if no live record exists for its user and key.
+	// It reports whether the key was reserved; otherwise the existing
+	// record is returned.
+	Reserve(ctx context.Context, record models.IdempotencyRecord) (models.IdempotencyRecord, bool, error)
+	// Complete saves the response of a reserved key
+	Complete(ctx context.Context, userID int, key string, statusCode int, contentType string, body []byte) error
+	// Release drops a reservation so the request can be retried
+	Release(ctx context.Context, userID int, key string) error
+	DeleteExpiredKeys(ctx context.Context, before time.Time) (int64, error)
+}
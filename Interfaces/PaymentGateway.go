@@ -0,0 +1,19 @@
+package interfaces
+
+import (
+	"context"
+	"online_bookStore/models"
+)
+
+// PaymentGateway talks to a payment provider. Authorize reserves the
+// amount on the customer's card, Capture collects an authorization, Void
+// releases one that was not captured and Refund gives a capture back.
+// Each call returns the provider's reference for the operation; a refusal
+// by the provider is a *models.GatewayError.
+type PaymentGateway interface {
+	Name() string
+	Authorize(ctx context.Context, charge models.GatewayCharge) (string, error)
+	Capture(ctx context.Context, authorizationID string, amount float64) (string, error)
+	Void(ctx context.Context, authorizationID string) error
+	Refund(ctx context.Context, captureID string, amount float64) (string, error)
+}
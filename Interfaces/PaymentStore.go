@@ -0,0 +1,13 @@
+package interfaces
+
+import (
+	"context"
+	"online_bookStore/models"
+)
+
+type PaymentStore interface {
+	CreatePayment(ctx context.Context, payment models.Payment) (models.Payment, error)
+	UpdatePayment(ctx context.Context, payment models.Payment) (models.Payment, error)
+	GetPayment(ctx context.Context, id int) (models.Payment, error)
+	GetPaymentsByOrder(ctx context.Context, orderID int) ([]models.Payment, error)
+}
@@ -0,0 +1,17 @@
+package interfaces
+
+import (
+	"context"
+	"online_bookStore/models"
+)
+
+// TaxCalculator returns the tax owed on taxable for a delivery address
+type TaxCalculator interface {
+	CalculateTax(ctx context.Context, address models.Address, taxable float64) (float64, error)
+}
+
+// ShippingCalculator prices delivering the priced items (books carry
+// their weight) to an address; amount is the discounted merchandise value
+type ShippingCalculator interface {
+	CalculateShipping(ctx context.Context, address models.Address, items []models.OrderItem, amount float64) (float64, error)
+}
@@ -0,0 +1,24 @@
+package interfaces
+
+import (
+	"context"
+	"online_bookStore/models"
+	"time"
+)
+
+type ReturnStore interface {
+	// CreateReturn checks the items against the order; a CustomerID other
+	// than 0 must own the order
+	CreateReturn(ctx context.Context, ret models.OrderReturn) (models.OrderReturn, error)
+	GetReturn(ctx context.Context, id int) (models.OrderReturn, error)
+	// GetReturns filters by customer and status; 0 and "" match all
+	GetReturns(ctx context.Context, customerID int, status string) ([]models.OrderReturn, error)
+	// ReviewReturn locks the order, closes a requested return and, when it
+	// is approved, puts the returned copies back in stock
+	ReviewReturn(ctx context.Context, id int, status string, reviewedBy int, note string) (models.OrderReturn, error)
+	CreateRefund(ctx context.Context, refund models.Refund) (models.Refund, error)
+	// GetRefundedTotals sums the refunds of an order and of each of their
+	// parts into one Refund
+	GetRefundedTotals(ctx context.Context, orderID int) (models.Refund, error)
+	GetRefundsByDateRange(ctx context.Context, from time.Time, to time.Time) ([]models.Refund, error)
+}
@@ -0,0 +1,20 @@
+package interfaces
+
+import (
+	"context"
+)
+
+type includeDeletedKey struct{}
+
+// WithDeleted asks the stores to return soft-deleted authors, books,
+// customers and orders too. By default every read leaves them out, as if
+// they did not exist.
+func WithDeleted(ctx context.Context) context.Context {
+	return context.WithValue(ctx, includeDeletedKey{}, true)
+}
+
+// IncludeDeleted reports whether ctx was built with WithDeleted
+func IncludeDeleted(ctx context.Context) bool {
+	include, _ := ctx.Value(includeDeletedKey{}).(bool)
+	return include
+}
@@ -0,0 +1,12 @@
+package interfaces
+
+import (
+	"context"
+)
+
+// Transactor runs fn in a single database transaction. Store calls made
+// with the context passed to fn join that transaction; it is committed
+// when fn returns nil and rolled back otherwise.
+type Transactor interface {
+	WithinTransaction(ctx context.Context, fn func(ctx context.Context) error) error
+}
@@ -3,11 +3,26 @@ package interfaces
 import (
 	"context"
 	"online_bookStore/models"
+	"time"
 )
 
 type UserStore interface {
 	GetByEmail(ctx context.Context, email string) (models.User, error)
 	GetByID(ctx context.Context, id int) (models.User, error)
 	CreateUser(ctx context.Context, user models.User) (models.User, error)
-}
+	GetAllUsers(ctx context.Context) ([]models.User, error)
+	UpdateRole(ctx context.Context, id int, role string) (models.User, error)
+	SetDisabled(ctx context.Context, id int, disabled bool) (models.User, error)
+	LinkCustomer(ctx context.Context, id int, customerID int) (models.User, error)
+	UpdatePassword(ctx context.Context, id int, passwordHash string) error
 
+	// refresh tokens and access token revocation
+	CreateRefreshToken(ctx context.Context, token models.RefreshToken) (models.RefreshToken, error)
+	GetRefreshToken(ctx context.Context, tokenHash string) (models.RefreshToken, error)
+	RotateRefreshToken(ctx context.Context, oldID int, next models.RefreshToken) (models.RefreshToken, error)
+	RevokeRefreshTokenFamily(ctx context.Context, familyID string) error
+	RevokeUserRefreshTokens(ctx context.Context, userID int) error
+	RevokeAccessToken(ctx context.Context, jti string, expiresAt time.Time) error
+	IsAccessTokenRevoked(ctx context.Context, jti string) (bool, error)
+	DeleteExpiredTokens(ctx context.Context, before time.Time) error
+}
@@ -1,76 +1,215 @@
 package main
 
 import (
+	"cmp"
 	"context"
+	"fmt"
 	"log"
 	"net/http"
 	"os"
 	"os/signal"
+	"strconv"
 	"syscall"
 	"time"
 
 	"online_bookStore/Database"
 	"online_bookStore/concreteimplemetations"
 	"online_bookStore/Handlers"
+	"online_bookStore/InMemory"
+	"online_bookStore/Interfaces"
 	"online_bookStore/services"
 )
 
 func main() {
+	// "migrate [up | down [n] | status]" manages the schema and exits
+	if len(os.Args) > 1 && os.Args[1] == "migrate" {
+		runMigrations(os.Args[2:])
+		return
+	}
+
 	log.Println("Starting Online Bookstore API")
 
 	// Root context (for shutdown + background jobs)
 	ctx, cancel := context.WithCancel(context.Background())
 	defer cancel()
 
-	// ---- DATABASE ----
-	db := database.NewMySQLDBFromEnv()
-	defer db.Close()
-	log.Println("Database connected")
-
 	// ---- STORES ----
-	authorStore := concreteimplemetations.NewMySQLAuthorStore(db)
-	bookStore := concreteimplemetations.NewMySQLBookStore(db)
-	customerStore := concreteimplemetations.NewMySQLCustomerStore(db)
-	orderStore := concreteimplemetations.NewMySQLOrderStore(db)
-	userStore := concreteimplemetations.NewMySQLUserStore(db)
+	// STORE_BACKEND picks where data lives: "sql" (default), in the database
+	// DB_DRIVER names, or "memory", which needs no database and starts with
+	// the sample data
+	var (
+		authorStore      interfaces.AuthorStore
+		bookStore        interfaces.BookStore
+		genreStore       interfaces.GenreStore
+		customerStore    interfaces.CustomerStore
+		orderStore       interfaces.OrderStore
+		userStore        interfaces.UserStore
+		cartStore        interfaces.CartStore
+		transactor       interfaces.Transactor
+		idempotencyStore interfaces.IdempotencyStore
+		couponStore      interfaces.CouponStore
+		paymentStore     interfaces.PaymentStore
+		returnStore      interfaces.ReturnStore
+	)
+	cartTTL := services.DurationFromEnv("CART_TTL", services.DefaultCartTTL)
+	defaultGateway := ""
+
+	switch backend := os.Getenv("STORE_BACKEND"); backend {
+	case "", "sql", "mysql":
+		db := database.NewDBFromEnv()
+		defer db.Close()
+		log.Printf("Database connected (%s)", cmp.Or(os.Getenv("DB_DRIVER"), "mysql"))
+
+		// MIGRATE_ON_START=true brings the schema up to date before serving
+		if raw := os.Getenv("MIGRATE_ON_START"); raw != "" {
+			migrateOnStart, err := strconv.ParseBool(raw)
+			if err != nil {
+				log.Fatalf("invalid MIGRATE_ON_START %q", raw)
+			}
+			if migrateOnStart {
+				applied, err := database.Migrate(ctx, db)
+				if err != nil {
+					log.Fatalf("Migration failed: %v", err)
+				}
+				log.Printf("Schema up to date (%d migrations applied)", len(applied))
+			}
+		}
+
+		authorStore = concreteimplemetations.NewMySQLAuthorStore(db)
+		bookStore = concreteimplemetations.NewMySQLBookStore(db)
+		genreStore = concreteimplemetations.NewMySQLGenreStore(db)
+		customerStore = concreteimplemetations.NewMySQLCustomerStore(db)
+		taxCalculator := concreteimplemetations.NewMySQLTaxCalculator(db)
+		shippingCalculator := concreteimplemetations.NewMySQLShippingCalculator(db)
+		orderStore = concreteimplemetations.NewMySQLOrderStore(db, taxCalculator, shippingCalculator)
+		userStore = concreteimplemetations.NewMySQLUserStore(db)
+		cartStore = concreteimplemetations.NewMySQLCartStore(db, cartTTL)
+		transactor = concreteimplemetations.NewMySQLTransactor(db)
+		idempotencyStore = concreteimplemetations.NewMySQLIdempotencyStore(db)
+		couponStore = concreteimplemetations.NewMySQLCouponStore(db)
+		paymentStore = concreteimplemetations.NewMySQLPaymentStore(db)
+		returnStore = concreteimplemetations.NewMySQLReturnStore(db)
+
+	case "memory":
+		db := inmemory.NewDB()
+		if err := inmemory.Seed(ctx, db); err != nil {
+			log.Fatalf("Seeding in-memory stores: %v", err)
+		}
+		log.Println("Using in-memory stores (data is lost on shutdown)")
+
+		authorStore = inmemory.NewMemoryAuthorStore(db)
+		bookStore = inmemory.NewMemoryBookStore(db)
+		genreStore = inmemory.NewMemoryGenreStore(db)
+		customerStore = inmemory.NewMemoryCustomerStore(db)
+		taxCalculator := inmemory.NewMemoryTaxCalculator(db)
+		shippingCalculator := inmemory.NewMemoryShippingCalculator(db)
+		orderStore = inmemory.NewMemoryOrderStore(db, taxCalculator, shippingCalculator)
+		userStore = inmemory.NewMemoryUserStore(db)
+		cartStore = inmemory.NewMemoryCartStore(db, cartTTL)
+		transactor = inmemory.NewMemoryTransactor(db)
+		idempotencyStore = inmemory.NewMemoryIdempotencyStore(db)
+		couponStore = inmemory.NewMemoryCouponStore(db)
+		paymentStore = inmemory.NewMemoryPaymentStore(db)
+		returnStore = inmemory.NewMemoryReturnStore(db)
+		defaultGateway = "fake"
 
-	_ = userStore // used later for JWT auth
+	default:
+		log.Fatalf("Unknown STORE_BACKEND %q (want sql or memory)", backend)
+	}
+
+	// ---- PAYMENT GATEWAY ----
+	// PAYMENT_GATEWAY picks the provider. The fake forgets its captures on
+	// restart, so stored payments cannot be refunded afterwards: it is the
+	// default in memory only and has to be asked for on a database.
+	var paymentGateway interfaces.PaymentGateway
+	switch gateway := cmp.Or(os.Getenv("PAYMENT_GATEWAY"), defaultGateway); gateway {
+	case "fake":
+		paymentGateway = concreteimplemetations.NewFakePaymentGateway()
+		if defaultGateway == "" {
+			log.Println("Using the fake payment gateway: payments made before a restart cannot be refunded")
+		}
+	case "":
+		log.Fatal("PAYMENT_GATEWAY is not set (PAYMENT_GATEWAY=fake for development)")
+	default:
+		log.Fatalf("Unknown PAYMENT_GATEWAY %q (want fake)", gateway)
+	}
 
 	// ---- SERVICES ----
-	salesReportService := services.NewSalesReportService(orderStore)
+	salesReportService := services.NewSalesReportService(orderStore, returnStore)
+	authService := services.NewAuthServiceFromEnv(userStore)
+	userService := services.NewUserService(userStore, customerStore, transactor)
+	cartService := services.NewCartService(cartStore, orderStore, transactor)
+	paymentService := services.NewPaymentService(paymentGateway, paymentStore, orderStore, returnStore, transactor)
+	returnService := services.NewReturnService(returnStore, orderStore, paymentService, transactor)
 
 	// ---- BACKGROUND JOBS ----
 	services.StartSalesReportJob(ctx, salesReportService)
+	services.StartTokenCleanupJob(ctx, authService)
+	services.StartCartCleanupJob(ctx, cartStore)
+	services.StartIdempotencyCleanupJob(ctx, idempotencyStore)
 
 	// ---- HANDLERS ----
 	authorHandler := handlers.NewAuthorHandler(authorStore)
 	bookHandler := handlers.NewBookHandler(bookStore)
+	genreHandler := handlers.NewGenreHandler(genreStore)
 	customerHandler := handlers.NewCustomerHandler(customerStore)
-	orderHandler := handlers.NewOrderHandler(orderStore)
+	orderHandler := handlers.NewOrderHandler(orderStore, paymentService)
 	reportHandler := handlers.NewReportHandler()
+	authHandler := handlers.NewAuthHandler(authService, userService)
+	userHandler := handlers.NewUserHandler(userService)
+	meHandler := handlers.NewMeHandler(customerStore, orderStore)
+	cartHandler := handlers.NewCartHandler(cartService)
+	couponHandler := handlers.NewCouponHandler(couponStore)
+	returnHandler := handlers.NewReturnHandler(returnService)
+
+	// ---- MIDDLEWARE ----
+	auth := handlers.NewAuthMiddleware(authService)
+	idem := handlers.NewIdempotencyMiddleware(idempotencyStore, services.DurationFromEnv("IDEMPOTENCY_TTL", services.DefaultIdempotencyTTL))
 
 	// ---- ROUTES ----
 	mux := http.NewServeMux()
 
-	mux.HandleFunc("/authors", authorHandler.AuthorsHandler)
-	mux.HandleFunc("/authors/", authorHandler.AuthorsByIDHandler)
+	mux.HandleFunc("/auth/login", authHandler.LoginHandler)
+	mux.HandleFunc("/auth/register", authHandler.RegisterHandler)
+	mux.HandleFunc("/auth/refresh", authHandler.RefreshHandler)
+	mux.HandleFunc("/auth/logout", auth.Protect(handlers.UserPolicy, authHandler.LogoutHandler))
+
+	mux.HandleFunc("/users", auth.Protect(handlers.AdminPolicy, idem.Wrap(userHandler.UsersHandler)))
+	mux.HandleFunc("/users/", auth.Protect(handlers.AdminPolicy, userHandler.UserByIDHandler))
+
+	mux.HandleFunc("/authors", auth.Protect(handlers.PublicReadAdminWrite, idem.Wrap(authorHandler.AuthorsHandler)))
+	mux.HandleFunc("/authors/", auth.Protect(handlers.PublicReadAdminWrite, authorHandler.AuthorsByIDHandler))
+
+	mux.HandleFunc("/books", auth.Protect(handlers.PublicReadAdminWrite, idem.Wrap(bookHandler.BooksHandler)))
+	mux.HandleFunc("/books/", auth.Protect(handlers.PublicReadAdminWrite, bookHandler.BookByIDHandler))
+
+	mux.HandleFunc("/genres", auth.Protect(handlers.PublicReadAdminWrite, genreHandler.GenresHandler))
+
+	mux.HandleFunc("/customers", auth.Protect(handlers.AdminPolicy, idem.Wrap(customerHandler.CustomersHandler)))
+	mux.HandleFunc("/customers/", auth.Protect(handlers.UserPolicy, idem.Wrap(customerHandler.CustomersByIDHandler)))
+
+	mux.HandleFunc("/orders", auth.Protect(handlers.UserPolicy, idem.Wrap(orderHandler.OrdersHandler)))
+	mux.HandleFunc("/orders/", auth.Protect(handlers.Policy{http.MethodGet: handlers.AccessUser, http.MethodPost: handlers.AccessUser, "*": handlers.AccessAdmin}, idem.Wrap(orderHandler.OrdersByIDHandler)))
+
+	mux.HandleFunc("/cart", auth.Protect(handlers.UserPolicy, cartHandler.CartRouter))
+	mux.HandleFunc("/cart/", auth.Protect(handlers.UserPolicy, idem.Wrap(cartHandler.CartRouter)))
 
-	mux.HandleFunc("/books", bookHandler.BooksHandler)
-	mux.HandleFunc("/books/", bookHandler.BookByIDHandler)
+	mux.HandleFunc("/coupons", auth.Protect(handlers.AdminPolicy, idem.Wrap(couponHandler.CouponsHandler)))
+	mux.HandleFunc("/coupons/", auth.Protect(handlers.AdminPolicy, couponHandler.CouponByIDHandler))
 
-	mux.HandleFunc("/customers", customerHandler.CustomersHandler)
-	mux.HandleFunc("/customers/", customerHandler.CustomersByIDHandler)
+	mux.HandleFunc("/returns", auth.Protect(handlers.UserPolicy, idem.Wrap(returnHandler.ReturnsHandler)))
+	mux.HandleFunc("/returns/", auth.Protect(handlers.UserPolicy, returnHandler.ReturnByIDHandler))
 
-	mux.HandleFunc("/orders", orderHandler.OrdersHandler)
-	mux.HandleFunc("/orders/", orderHandler.OrdersByIDHandler)
+	mux.HandleFunc("/me", auth.Protect(handlers.UserPolicy, meHandler.MeRouter))
+	mux.HandleFunc("/me/", auth.Protect(handlers.UserPolicy, meHandler.MeRouter))
 
-	mux.HandleFunc("/reports", reportHandler.GetReports)
-	mux.HandleFunc("/reports/", reportHandler.GetReportByDate)
+	mux.HandleFunc("/reports", auth.Protect(handlers.AdminPolicy, reportHandler.GetReports))
+	mux.HandleFunc("/reports/", auth.Protect(handlers.AdminPolicy, reportHandler.GetReportByDate))
 
 	// ---- SERVER ----
 	server := &http.Server{
 		Addr:         ":8081",
-		Handler:      mux,
+		Handler:      handlers.RequestIDMiddleware(mux),
 		ReadTimeout:  10 * time.Second,
 		WriteTimeout: 10 * time.Second,
 		IdleTimeout:  60 * time.Second,
@@ -101,3 +240,69 @@ func main() {
 	cancel() // stop background jobs
 	log.Println("Server stopped cleanly")
 }
+
+// runMigrations is the migrate subcommand; it uses the DB_* settings of the
+// server
+func runMigrations(args []string) {
+	db := database.NewDBFromEnv()
+	defer db.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Minute)
+	defer cancel()
+
+	command := "up"
+	if len(args) > 0 {
+		command = args[0]
+	}
+
+	switch command {
+	case "up":
+		applied, err := database.Migrate(ctx, db)
+		if err != nil {
+			log.Fatalf("Migration failed: %v", err)
+		}
+		for _, m := range applied {
+			log.Printf("Applied migration %04d_%s", m.Version, m.Name)
+		}
+		if len(applied) == 0 {
+			log.Println("Schema is already up to date")
+		}
+
+	case "down":
+		steps := 1
+		if len(args) > 1 {
+			n, err := strconv.Atoi(args[1])
+			if err != nil || n <= 0 {
+				log.Fatalf("invalid number of migrations %q", args[1])
+			}
+			steps = n
+		}
+
+		reverted, err := database.MigrateDown(ctx, db, steps)
+		if err != nil {
+			log.Fatalf("Migration failed: %v", err)
+		}
+		for _, m := range reverted {
+			log.Printf("Reverted migration %04d_%s", m.Version, m.Name)
+		}
+		if len(reverted) == 0 {
+			log.Println("No migrations to revert")
+		}
+
+	case "status":
+		states, err := database.MigrationStatus(ctx, db)
+		if err != nil {
+			log.Fatalf("Reading migration status failed: %v", err)
+		}
+		for _, state := range states {
+			applied := "pending"
+			if state.AppliedAt != nil {
+				applied = "applied " + state.AppliedAt.Format(time.RFC3339)
+			}
+			fmt.Printf("%04d_%s\t%s\n", state.Version, state.Name, applied)
+		}
+
+	default:
+		log.Fatalf("usage: %s migrate [up | down [n] | status]", os.Args[0])
+	}
+}
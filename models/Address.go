@@ -1,11 +1,36 @@
 package models
 
+import (
+	"strings"
+)
 
 type Address struct { 
-    ID        int
-    Street     string 
-    City       string 
-    State      string  
-    PostalCode string  
-    Country    string  
-}
\ No newline at end of file
+    ID         int    `json:"id,omitempty"`
+    Street     string `json:"street"`
+    City       string `json:"city"`
+    State      string `json:"state"`
+    PostalCode string `json:"postal_code"`
+    Country    string `json:"country"`
+}
+
+// Validate checks the fields needed to deliver to the address
+func (a Address) Validate() error {
+	var missing []string
+	var fields []FieldError
+	for _, f := range []struct{ name, value string }{
+		{"street", a.Street},
+		{"city", a.City},
+		{"postal_code", a.PostalCode},
+		{"country", a.Country},
+	} {
+		if strings.TrimSpace(f.value) == "" {
+			missing = append(missing, f.name)
+			fields = append(fields, FieldError{Field: f.name, Message: "is required"})
+		}
+	}
+
+	if len(missing) > 0 {
+		return NewValidationError(ErrInvalidAddress, "address", "missing "+strings.Join(missing, ", "), fields...)
+	}
+	return nil
+}
@@ -1,8 +1,13 @@
 package models
 
+import (
+	"time"
+)
+
 type Author struct { 
-    ID        int    `json:"id"` 
-    FirstName string `json:"first_name"` 
-    LastName  string `json:"last_name"` 
-    Bio       string `json:"bio"` 
+    ID        int        `json:"id"` 
+    FirstName string     `json:"first_name"` 
+    LastName  string     `json:"last_name"` 
+    Bio       string     `json:"bio"` 
+    DeletedAt *time.Time `json:"deleted_at,omitempty"` // set when soft-deleted
 }
\ No newline at end of file
@@ -7,17 +7,20 @@ import (
 type Book struct { 
     ID          int       `json:"id"` 
     Title       string    `json:"title"` 
-    Author      Author    `json:"author"` 
+    Author      Author    `json:"author"` // primary author, also the first contributor
+    Contributors []Contributor `json:"contributors"`
     Genres      []string  `json:"genres"` 
     PublishedAt time.Time `json:"published_at"` 
     Price       float64   `json:"price"` 
     Stock       int       `json:"stock"` 
+    WeightGrams int       `json:"weight_grams"`
+    DeletedAt   *time.Time `json:"deleted_at,omitempty"` // set when soft-deleted
 } 
 
 
 type SearchCriteria struct {
     Title string
-    AuthorId int
+    AuthorId int // any contributor
     Genre string
     MinPrice float64
     MaxPrice float64
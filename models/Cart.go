@@ -0,0 +1,38 @@
+package models
+
+import (
+	"time"
+)
+
+// A customer's cart. Prices and stock are read live from the catalogue
+// every time the cart is loaded; nothing is reserved until checkout.
+type Cart struct {
+	ID         int        `json:"id"`
+	CustomerID int        `json:"customer_id"`
+	Items      []CartItem `json:"items"`
+	Subtotal   float64    `json:"subtotal"`
+	UpdatedAt  time.Time  `json:"updated_at"`
+	ExpiresAt  time.Time  `json:"expires_at"`
+}
+
+type CartItem struct {
+	Book      Book    `json:"book"`
+	Quantity  int     `json:"quantity"`
+	UnitPrice float64 `json:"unit_price"` // current book price
+	InStock   bool    `json:"in_stock"`   // stock covers the quantity right now
+}
+
+type CartItemRequest struct {
+	BookID   int `json:"book_id"`
+	Quantity int `json:"quantity"`
+}
+
+// CheckoutRequest fields are optional; the addresses default to the
+// customer's default shipping and billing addresses
+type CheckoutRequest struct {
+	CouponCode        string   `json:"coupon_code,omitempty"`
+	ShippingAddress   *Address `json:"shipping_address,omitempty"`
+	BillingAddress    *Address `json:"billing_address,omitempty"`
+	ShippingAddressID int      `json:"shipping_address_id,omitempty"`
+	BillingAddressID  int      `json:"billing_address_id,omitempty"`
+}
@@ -0,0 +1,83 @@
+package models
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Contributor roles, as the book_contributors table allows them
+const (
+	RoleAuthor      = "author"
+	RoleEditor      = "editor"
+	RoleTranslator  = "translator"
+	RoleIllustrator = "illustrator"
+)
+
+// Contributor is someone credited on a book. Books list their
+// contributors in order, the primary author first.
+type Contributor struct {
+	Author Author `json:"author"`
+	Role   string `json:"role"`
+}
+
+func validContributorRole(role string) bool {
+	switch role {
+	case RoleAuthor, RoleEditor, RoleTranslator, RoleIllustrator:
+		return true
+	}
+	return false
+}
+
+// NormalizeContributors settles a book's primary author and contributors.
+// A missing role means author and repeats of the same person in the same
+// role are dropped. Without an author id the first contributor with the
+// author role is the primary author; the primary author is then moved to
+// the front, so books written before contributors existed keep working.
+func NormalizeContributors(book Book) (Book, error) {
+	contributors := make([]Contributor, 0, len(book.Contributors)+1)
+	seen := make(map[Contributor]bool)
+
+	for i, contributor := range book.Contributors {
+		role := strings.ToLower(strings.TrimSpace(contributor.Role))
+		if role == "" {
+			role = RoleAuthor
+		}
+		if !validContributorRole(role) {
+			return book, NewValidationError(ErrInvalidContributor, "book", fmt.Sprintf("unknown contributor role %q", contributor.Role),
+				FieldError{Field: fmt.Sprintf("contributors[%d].role", i), Message: "must be author, editor, translator or illustrator"})
+		}
+		if contributor.Author.ID <= 0 {
+			return book, NewValidationError(ErrInvalidContributor, "book", "contributor without an author id",
+				FieldError{Field: fmt.Sprintf("contributors[%d].author.id", i), Message: "is required"})
+		}
+
+		key := Contributor{Author: Author{ID: contributor.Author.ID}, Role: role}
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+		contributors = append(contributors, key)
+	}
+
+	if book.Author.ID == 0 {
+		for _, contributor := range contributors {
+			if contributor.Role == RoleAuthor {
+				book.Author = Author{ID: contributor.Author.ID}
+				break
+			}
+		}
+	}
+
+	primary := Contributor{Author: Author{ID: book.Author.ID}, Role: RoleAuthor}
+	book.Contributors = append([]Contributor{primary}, contributors...)
+	if seen[primary] {
+		for i := 1; i < len(book.Contributors); i++ {
+			if book.Contributors[i] == primary {
+				book.Contributors = append(book.Contributors[:i], book.Contributors[i+1:]...)
+				break
+			}
+		}
+	}
+
+	return book, nil
+}
@@ -0,0 +1,160 @@
+package models
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Coupon types
+const (
+	CouponPercentage = "percentage"  // Value percent off the eligible items
+	CouponFixed      = "fixed"       // Value off the eligible items
+	CouponBuyXGetY   = "buy_x_get_y" // every BuyQuantity+FreeQuantity eligible units, the FreeQuantity cheapest are free
+)
+
+// A coupon can be scoped to one genre and/or one author; only matching
+// order items are discounted. Zero values mean "no restriction".
+type Coupon struct {
+	ID            int        `json:"id"`
+	Code          string     `json:"code"`
+	Type          string     `json:"type"`
+	Value         float64    `json:"value,omitempty"`
+	BuyQuantity   int        `json:"buy_quantity,omitempty"`
+	FreeQuantity  int        `json:"free_quantity,omitempty"`
+	Genre         string     `json:"genre,omitempty"`
+	AuthorID      int        `json:"author_id,omitempty"`
+	MinOrderValue float64    `json:"min_order_value,omitempty"`
+	UsageLimit    int        `json:"usage_limit,omitempty"`
+	UsedCount     int        `json:"used_count"`
+	ValidFrom     *time.Time `json:"valid_from,omitempty"`
+	ValidUntil    *time.Time `json:"valid_until,omitempty"`
+	Active        bool       `json:"active"`
+	CreatedAt     time.Time  `json:"created_at"`
+}
+
+// NormalizeCouponCode makes codes case-insensitive
+func NormalizeCouponCode(code string) string {
+	return strings.ToUpper(strings.TrimSpace(code))
+}
+
+func invalidCoupon(message string, fields ...FieldError) error {
+	return NewValidationError(ErrInvalidCoupon, "coupon", message, fields...)
+}
+
+// Validate checks the coupon definition sent by an admin
+func (c Coupon) Validate() error {
+	if c.Code == "" {
+		return invalidCoupon("code is required", FieldError{"code", "is required"})
+	}
+
+	switch c.Type {
+	case CouponPercentage:
+		if c.Value <= 0 || c.Value > 100 {
+			return invalidCoupon("percentage must be between 0 and 100", FieldError{"value", "must be between 0 and 100"})
+		}
+	case CouponFixed:
+		if c.Value <= 0 {
+			return invalidCoupon("amount must be positive", FieldError{"value", "must be positive"})
+		}
+	case CouponBuyXGetY:
+		if c.BuyQuantity <= 0 || c.FreeQuantity <= 0 {
+			return invalidCoupon("buy_quantity and free_quantity must be positive",
+				FieldError{"buy_quantity", "must be positive"}, FieldError{"free_quantity", "must be positive"})
+		}
+	default:
+		return invalidCoupon(fmt.Sprintf("unknown type %q", c.Type),
+			FieldError{"type", fmt.Sprintf("must be %q, %q or %q", CouponPercentage, CouponFixed, CouponBuyXGetY)})
+	}
+
+	if c.MinOrderValue < 0 || c.UsageLimit < 0 {
+		return invalidCoupon("min_order_value and usage_limit cannot be negative",
+			FieldError{"min_order_value", "cannot be negative"}, FieldError{"usage_limit", "cannot be negative"})
+	}
+	if c.ValidFrom != nil && c.ValidUntil != nil && !c.ValidUntil.After(*c.ValidFrom) {
+		return invalidCoupon("valid_until must be after valid_from", FieldError{"valid_until", "must be after valid_from"})
+	}
+	return nil
+}
+
+// CheckUsable reports why the coupon cannot be redeemed at the given time
+func (c Coupon) CheckUsable(now time.Time) error {
+	switch {
+	case !c.Active:
+		return fmt.Errorf("%w: %s is inactive", ErrCouponNotApplicable, c.Code)
+	case c.ValidFrom != nil && now.Before(*c.ValidFrom):
+		return fmt.Errorf("%w: %s is not valid yet", ErrCouponNotApplicable, c.Code)
+	case c.ValidUntil != nil && now.After(*c.ValidUntil):
+		return fmt.Errorf("%w: %s has expired", ErrCouponNotApplicable, c.Code)
+	case c.UsageLimit > 0 && c.UsedCount >= c.UsageLimit:
+		return fmt.Errorf("%w: %s has reached its usage limit", ErrCouponNotApplicable, c.Code)
+	}
+	return nil
+}
+
+// AppliesTo reports whether the book is in the coupon's scope
+func (c Coupon) AppliesTo(book Book) bool {
+	if c.AuthorID != 0 && book.Author.ID != c.AuthorID {
+		return false
+	}
+	if c.Genre == "" {
+		return true
+	}
+	for _, genre := range book.Genres {
+		if strings.EqualFold(strings.TrimSpace(genre), c.Genre) {
+			return true
+		}
+	}
+	return false
+}
+
+// Discount computes the discount for priced order items. The items'
+// books must carry their author and genres for scoped coupons.
+func (c Coupon) Discount(items []OrderItem) (float64, error) {
+	subtotal := 0.0
+	eligible := 0.0
+	var unitPrices []float64
+	for _, item := range items {
+		line := item.UnitPrice * float64(item.Quantity)
+		subtotal += line
+		if !c.AppliesTo(item.Book) {
+			continue
+		}
+		eligible += line
+		for i := 0; i < item.Quantity; i++ {
+			unitPrices = append(unitPrices, item.UnitPrice)
+		}
+	}
+
+	if subtotal < c.MinOrderValue {
+		return 0, fmt.Errorf("%w: %s requires an order of at least %.2f", ErrCouponNotApplicable, c.Code, c.MinOrderValue)
+	}
+	if len(unitPrices) == 0 {
+		return 0, fmt.Errorf("%w: no item in the order is eligible for %s", ErrCouponNotApplicable, c.Code)
+	}
+
+	var discount float64
+	switch c.Type {
+	case CouponPercentage:
+		discount = eligible * c.Value / 100
+	case CouponFixed:
+		discount = math.Min(c.Value, eligible)
+	case CouponBuyXGetY:
+		// most expensive first, so the free units are the cheapest of each group
+		sort.Sort(sort.Reverse(sort.Float64Slice(unitPrices)))
+		group := c.BuyQuantity + c.FreeQuantity
+		complete := len(unitPrices) / group * group
+		for i := 0; i < complete; i++ {
+			if i%group >= c.BuyQuantity {
+				discount += unitPrices[i]
+			}
+		}
+		if discount == 0 {
+			return 0, fmt.Errorf("%w: %s needs %d eligible items", ErrCouponNotApplicable, c.Code, group)
+		}
+	}
+
+	return roundCents(discount), nil
+}
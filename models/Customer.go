@@ -10,4 +10,5 @@ type Customer struct {
     Email     string    `json:"email"` 
     Address   Address   `json:"address"` 
     CreatedAt time.Time `json:"created_at"` 
+    DeletedAt *time.Time `json:"deleted_at,omitempty"` // set when soft-deleted
 } 
\ No newline at end of file
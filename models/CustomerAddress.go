@@ -0,0 +1,35 @@
+package models
+
+import (
+	"strings"
+	"time"
+)
+
+const DefaultAddressLabel = "home"
+
+// CustomerAddress is one of a customer's saved addresses. The embedded
+// Address.ID is the id of the saved address.
+type CustomerAddress struct {
+	Address
+	CustomerID        int       `json:"customer_id"`
+	Label             string    `json:"label"`
+	IsDefaultShipping bool      `json:"is_default_shipping"`
+	IsDefaultBilling  bool      `json:"is_default_billing"`
+	CreatedAt         time.Time `json:"created_at"`
+}
+
+// Normalize lower-cases the label and falls back to DefaultAddressLabel
+func (a *CustomerAddress) Normalize() {
+	a.Label = strings.ToLower(strings.TrimSpace(a.Label))
+	if a.Label == "" {
+		a.Label = DefaultAddressLabel
+	}
+}
+
+func (a CustomerAddress) Validate() error {
+	if len(a.Label) > 50 {
+		return NewValidationError(ErrInvalidAddress, "address", "label must be at most 50 characters",
+			FieldError{Field: "label", Message: "must be at most 50 characters"})
+	}
+	return a.Address.Validate()
+}
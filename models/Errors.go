@@ -0,0 +1,206 @@
+package models
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// Order validation errors returned by the order stores
+var (
+	ErrEmptyOrder        = errors.New("order must contain at least one item")
+	ErrInvalidQuantity   = errors.New("item quantity must be positive")
+	ErrUnknownBook       = errors.New("book does not exist")
+	ErrTotalMismatch     = errors.New("total_price does not match the computed order total")
+	ErrUnknownStatus     = errors.New("unknown order status")
+	ErrEmptyCart         = errors.New("cart is empty")
+	ErrUnknownCustomer   = errors.New("customer does not exist")
+	ErrNoShippingRate    = errors.New("no shipping rate for the destination")
+	ErrInvalidAddress    = errors.New("invalid address")
+	ErrUnknownAddress    = errors.New("address does not belong to the customer")
+	ErrLastAddress       = errors.New("a customer must keep at least one address")
+	ErrOrderNotDeletable = errors.New("pending and paid orders must be cancelled before they are deleted")
+)
+
+// Book errors
+var (
+	ErrInvalidGenre       = errors.New("invalid genre")
+	ErrInvalidContributor = errors.New("invalid contributor")
+)
+
+// Coupon errors
+var (
+	ErrInvalidCoupon       = errors.New("invalid coupon")
+	ErrCouponCodeTaken     = errors.New("coupon code already exists")
+	ErrUnknownCoupon       = errors.New("coupon does not exist")
+	ErrCouponNotApplicable = errors.New("coupon cannot be applied")
+)
+
+// Payment errors
+var (
+	ErrOrderNotPayable = errors.New("only pending orders can be paid")
+	ErrMissingToken    = errors.New("payment token is required")
+)
+
+// Return and refund errors
+var (
+	ErrInvalidReturn      = errors.New("invalid return")
+	ErrOrderNotReturnable = errors.New("only delivered orders can be returned")
+	ErrReturnReviewed     = errors.New("return has already been reviewed")
+	ErrInvalidRefund      = errors.New("refund amount cannot be negative")
+	ErrRefundTooLarge     = errors.New("refund exceeds what is left to refund on the order")
+)
+
+// GatewayError is a refusal reported by the payment gateway, such as a
+// declined card
+type GatewayError struct {
+	Code    string
+	Message string
+}
+
+func (e *GatewayError) Error() string {
+	return fmt.Sprintf("payment gateway error %s: %s", e.Code, e.Message)
+}
+
+// InsufficientStockError lists the books that cannot cover the ordered quantity
+type InsufficientStockError struct {
+	BookIDs []int
+}
+
+func (e *InsufficientStockError) Error() string {
+	return fmt.Sprintf("insufficient stock for books %v", e.BookIDs)
+}
+
+// InvalidTransitionError is returned when an order cannot move
+// from its current status to the requested one
+type InvalidTransitionError struct {
+	From string
+	To   string
+}
+
+func (e *InvalidTransitionError) Error() string {
+	return fmt.Sprintf("order cannot move from %q to %q", e.From, e.To)
+}
+
+// Blocker counts the records of one kind that still depend on a record
+type Blocker struct {
+	Type  string `json:"type"`
+	Count int    `json:"count"`
+}
+
+// DependentsError is returned when a record cannot be deleted because
+// other records still depend on it
+type DependentsError struct {
+	Entity   string
+	ID       int
+	Blockers []Blocker
+}
+
+func (e *DependentsError) Error() string {
+	return fmt.Sprintf("%s %d still has dependent records %v", e.Entity, e.ID, e.Blockers)
+}
+
+// Storage errors. The stores translate driver errors into these types so
+// callers do not depend on the database in use; each one keeps the
+// original error, so errors.Is(err, sql.ErrNoRows) still holds for a
+// *NotFoundError.
+
+// NotFoundError is returned when the record does not exist or is deleted
+type NotFoundError struct {
+	Entity string
+	Err    error
+}
+
+func (e *NotFoundError) Error() string {
+	return e.Entity + " not found"
+}
+
+func (e *NotFoundError) Unwrap() error {
+	return e.Err
+}
+
+// ConflictError is returned when a write collides with stored data, such
+// as a duplicate unique value in Field
+type ConflictError struct {
+	Entity  string
+	Field   string
+	Message string
+	Err     error
+}
+
+func (e *ConflictError) Error() string {
+	return e.Message
+}
+
+func (e *ConflictError) Unwrap() error {
+	return e.Err
+}
+
+// FieldError tells what is wrong with one input field; Field is a path
+// such as "address.street" or "items[0].quantity"
+type FieldError struct {
+	Field   string `json:"field"`
+	Message string `json:"message"`
+}
+
+// ValidationError is returned when the input is rejected, such as a
+// missing field or a reference to a record that does not exist
+type ValidationError struct {
+	Entity  string
+	Fields  []FieldError
+	Message string
+	Err     error
+}
+
+// NewValidationError rejects the input of entity for the listed fields;
+// the error matches sentinel with errors.Is and reads
+// "<sentinel>: <message>"
+func NewValidationError(sentinel error, entity string, message string, fields ...FieldError) *ValidationError {
+	return &ValidationError{
+		Entity:  entity,
+		Fields:  fields,
+		Message: sentinel.Error() + ": " + message,
+		Err:     sentinel,
+	}
+}
+
+func (e *ValidationError) Error() string {
+	return e.Message
+}
+
+func (e *ValidationError) Unwrap() error {
+	return e.Err
+}
+
+// PrefixFields nests the fields of a *ValidationError under prefix, so
+// "street" becomes "address.street"; other errors are returned as they are
+func PrefixFields(err error, prefix string) error {
+	var validation *ValidationError
+	if !errors.As(err, &validation) {
+		return err
+	}
+
+	prefixed := *validation
+	prefixed.Fields = make([]FieldError, len(validation.Fields))
+	for i, field := range validation.Fields {
+		prefixed.Fields[i] = FieldError{Field: prefix + "." + field.Field, Message: field.Message}
+		if strings.HasPrefix(field.Field, "[") {
+			prefixed.Fields[i].Field = prefix + field.Field
+		}
+	}
+	return &prefixed
+}
+
+// UnavailableError is returned when the storage cannot be reached or
+// did not answer in time; the request may succeed when retried
+type UnavailableError struct {
+	Err error
+}
+
+func (e *UnavailableError) Error() string {
+	return "storage unavailable: " + e.Err.Error()
+}
+
+func (e *UnavailableError) Unwrap() error {
+	return e.Err
+}
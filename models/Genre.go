@@ -0,0 +1,45 @@
+package models
+
+import (
+	"fmt"
+	"strings"
+	"unicode/utf8"
+)
+
+// MaxGenreLength is the longest genre name the genres table holds
+const MaxGenreLength = 100
+
+// Genre is a catalogue genre; BookCount counts the books filed under it,
+// leaving out soft-deleted ones
+type Genre struct {
+	ID        int    `json:"id"`
+	Name      string `json:"name"`
+	BookCount int    `json:"book_count"`
+}
+
+// NormalizeGenres trims the names of a book's genres and drops empty ones
+// and repeats, which compare case-insensitively like the genres table
+func NormalizeGenres(genres []string) ([]string, error) {
+	normalized := make([]string, 0, len(genres))
+	seen := make(map[string]bool)
+
+	for i, genre := range genres {
+		genre = strings.TrimSpace(genre)
+		if genre == "" {
+			continue
+		}
+		if utf8.RuneCountInString(genre) > MaxGenreLength {
+			return nil, NewValidationError(ErrInvalidGenre, "book", fmt.Sprintf("%q is too long", genre),
+				FieldError{Field: fmt.Sprintf("genres[%d]", i), Message: fmt.Sprintf("must be at most %d characters", MaxGenreLength)})
+		}
+
+		key := strings.ToLower(genre)
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+		normalized = append(normalized, genre)
+	}
+
+	return normalized, nil
+}
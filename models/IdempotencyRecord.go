@@ -0,0 +1,24 @@
+package models
+
+import (
+	"time"
+)
+
+// IdempotencyRecord remembers the outcome of a request sent with an
+// Idempotency-Key header. Keys are scoped to the user that sent them
+// (UserID 0 for anonymous callers). A record without a StatusCode is
+// still being processed.
+type IdempotencyRecord struct {
+	UserID       int
+	Key          string
+	RequestHash  string
+	StatusCode   int
+	ContentType  string
+	ResponseBody []byte
+	CreatedAt    time.Time
+	ExpiresAt    time.Time
+}
+
+func (r IdempotencyRecord) Completed() bool {
+	return r.StatusCode != 0
+}
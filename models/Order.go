@@ -2,6 +2,7 @@ package models
 
 
 import (
+	"strings"
 	"time"
 )
 
@@ -9,7 +10,96 @@ type Order struct {
     ID         int          `json:"id"` 
     Customer   Customer     `json:"customer"` 
     Items      []OrderItem  `json:"items"` 
+    Subtotal   float64      `json:"subtotal"`
+    Discount   float64      `json:"discount"`
+    CouponCode string       `json:"coupon_code,omitempty"`
+    Tax        float64      `json:"tax"`
+    Shipping   float64      `json:"shipping"`
     TotalPrice float64      `json:"total_price"` 
+    // copies of the addresses taken when the order was placed; later
+    // changes to the customer do not affect them
+    ShippingAddress *Address `json:"shipping_address,omitempty"`
+    BillingAddress  *Address `json:"billing_address,omitempty"`
+    // saved customer addresses to copy when no address is sent
+    ShippingAddressID int    `json:"shipping_address_id,omitempty"`
+    BillingAddressID  int    `json:"billing_address_id,omitempty"`
     CreatedAt  time.Time    `json:"created_at"` 
     Status     string       `json:"status"` 
-}
\ No newline at end of file
+    DeletedAt  *time.Time   `json:"deleted_at,omitempty"` // set when soft-deleted
+}
+
+// Kinds of address snapshots stored with an order
+const (
+	AddressShipping = "shipping"
+	AddressBilling  = "billing"
+)
+
+// Order lifecycle: pending -> paid -> shipped -> delivered,
+// with cancelled and refunded as terminal states
+const (
+	OrderStatusPending   = "pending"
+	OrderStatusPaid      = "paid"
+	OrderStatusShipped   = "shipped"
+	OrderStatusDelivered = "delivered"
+	OrderStatusCancelled = "cancelled"
+	OrderStatusRefunded  = "refunded"
+)
+
+var orderTransitions = map[string][]string{
+	OrderStatusPending:   {OrderStatusPaid, OrderStatusCancelled},
+	OrderStatusPaid:      {OrderStatusShipped, OrderStatusCancelled, OrderStatusRefunded},
+	OrderStatusShipped:   {OrderStatusDelivered},
+	OrderStatusDelivered: {OrderStatusRefunded},
+	OrderStatusCancelled: {},
+	OrderStatusRefunded:  {},
+}
+
+// NormalizeOrderStatus lower-cases a status and maps the "canceled"
+// spelling found in older rows to "cancelled"
+func NormalizeOrderStatus(status string) string {
+	status = strings.ToLower(strings.TrimSpace(status))
+	if status == "canceled" {
+		return OrderStatusCancelled
+	}
+	return status
+}
+
+func IsValidOrderStatus(status string) bool {
+	_, ok := orderTransitions[status]
+	return ok
+}
+
+func CanTransitionOrder(from string, to string) bool {
+	for _, next := range orderTransitions[from] {
+		if next == to {
+			return true
+		}
+	}
+	return false
+}
+
+// ReleasesStock reports whether moving between the two statuses gives the
+// reserved quantities back: the goods never left the warehouse
+func ReleasesStock(from string, to string) bool {
+	return to == OrderStatusCancelled || (from == OrderStatusPaid && to == OrderStatusRefunded)
+}
+
+// HoldsStock reports whether an order in the status still has its
+// quantities reserved: they are taken when the order is placed and leave
+// the shelf for good once it ships, or come back when it is cancelled or
+// refunded before shipping
+func HoldsStock(status string) bool {
+	status = NormalizeOrderStatus(status)
+	return status == OrderStatusPending || status == OrderStatusPaid
+}
+
+// CountsAsSale reports whether an order in the status was paid for and so
+// counts towards revenue; refunded orders still count, their refunds are
+// taken off separately
+func CountsAsSale(status string) bool {
+	switch NormalizeOrderStatus(status) {
+	case OrderStatusPaid, OrderStatusShipped, OrderStatusDelivered, OrderStatusRefunded:
+		return true
+	}
+	return false
+}
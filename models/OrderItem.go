@@ -2,7 +2,8 @@ package models
 
 
 type OrderItem struct {
-	ID       int  `json:"id"`
-	Book     Book `json:"book"`
-	Quantity int  `json:"quantity"`
+	ID        int     `json:"id"`
+	Book      Book    `json:"book"`
+	Quantity  int     `json:"quantity"`
+	UnitPrice float64 `json:"unit_price"` // book price when the order was placed
 }
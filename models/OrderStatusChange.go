@@ -0,0 +1,17 @@
+package models
+
+import (
+	"time"
+)
+
+// One row of an order's status history. FromStatus is empty for the
+// entry recorded when the order is created, ChangedBy is 0 when the
+// change was not made by a logged-in user.
+type OrderStatusChange struct {
+	ID         int       `json:"id"`
+	OrderID    int       `json:"order_id"`
+	FromStatus string    `json:"from_status,omitempty"`
+	ToStatus   string    `json:"to_status"`
+	ChangedBy  int       `json:"changed_by,omitempty"`
+	ChangedAt  time.Time `json:"changed_at"`
+}
@@ -0,0 +1,46 @@
+package models
+
+import (
+	"time"
+)
+
+// Payment is one attempt at charging an order through a payment gateway.
+// A failed attempt keeps the gateway's error; the order stays pending and
+// can be paid again.
+type Payment struct {
+	ID              int       `json:"id"`
+	OrderID         int       `json:"order_id"`
+	Amount          float64   `json:"amount"`
+	Status          string    `json:"status"`
+	Gateway         string    `json:"gateway"`
+	AuthorizationID string    `json:"authorization_id,omitempty"`
+	CaptureID       string    `json:"capture_id,omitempty"`
+	RefundID        string    `json:"refund_id,omitempty"` // latest refund
+	GatewayError    string    `json:"gateway_error,omitempty"`
+	CreatedAt       time.Time `json:"created_at"`
+	UpdatedAt       time.Time `json:"updated_at"`
+}
+
+// Payment lifecycle: pending -> authorized -> captured, ending in failed,
+// voided (authorization released) or refunded
+const (
+	PaymentStatusPending    = "pending"
+	PaymentStatusAuthorized = "authorized"
+	PaymentStatusCaptured   = "captured"
+	PaymentStatusFailed     = "failed"
+	PaymentStatusVoided     = "voided"
+	PaymentStatusRefunded   = "refunded"
+)
+
+// PaymentRequest is what a customer sends to pay an order; Token stands
+// for the card details held by the gateway
+type PaymentRequest struct {
+	Token string `json:"token"`
+}
+
+// GatewayCharge is what the gateway is asked to authorize
+type GatewayCharge struct {
+	Amount    float64
+	Token     string
+	Reference string
+}
@@ -0,0 +1,23 @@
+package models
+
+import (
+	"time"
+)
+
+// Refresh tokens are stored hashed. Every token issued by rotating
+// another one shares its FamilyID, so a whole login session can be
+// revoked at once.
+type RefreshToken struct {
+	ID         int
+	UserID     int
+	TokenHash  string
+	FamilyID   string
+	ExpiresAt  time.Time
+	RevokedAt  *time.Time
+	ReplacedBy int
+	CreatedAt  time.Time
+}
+
+type RefreshRequest struct {
+	RefreshToken string `json:"refresh_token"`
+}
@@ -0,0 +1,112 @@
+package models
+
+import (
+	"math"
+	"strings"
+	"time"
+)
+
+// OrderReturn is a customer's request to send back part of a delivered
+// order. Approving it puts the items back in stock and refunds the
+// customer; RefundedAmount is what has been paid back for it.
+type OrderReturn struct {
+	ID             int          `json:"id"`
+	OrderID        int          `json:"order_id"`
+	CustomerID     int          `json:"customer_id"`
+	Status         string       `json:"status"`
+	Reason         string       `json:"reason"`
+	Items          []ReturnItem `json:"items"`
+	RefundedAmount float64      `json:"refunded_amount"`
+	ReviewedBy     int          `json:"reviewed_by,omitempty"`
+	ReviewNote     string       `json:"review_note,omitempty"`
+	CreatedAt      time.Time    `json:"created_at"`
+	ReviewedAt     *time.Time   `json:"reviewed_at,omitempty"`
+}
+
+// ReturnItem names an order item and how many of its copies come back;
+// BookID and UnitPrice are copied from the order item
+type ReturnItem struct {
+	ID          int     `json:"id"`
+	OrderItemID int     `json:"order_item_id"`
+	BookID      int     `json:"book_id"`
+	Quantity    int     `json:"quantity"`
+	UnitPrice   float64 `json:"unit_price"`
+}
+
+// ReturnReview is an admin's decision. RefundAmount is optional: without
+// it an approval refunds the returned items at the price paid for them.
+type ReturnReview struct {
+	Status       string   `json:"status"`
+	RefundAmount *float64 `json:"refund_amount,omitempty"`
+	Note         string   `json:"note,omitempty"`
+}
+
+// Refund is money paid back on an order. PaymentID is 0 when the order
+// had no captured payment and the refund was made outside the gateway.
+// Amount is split between the order's merchandise (after coupon
+// discounts), tax and shipping, so reports can take each off its own
+// figure (see SplitRefund).
+type Refund struct {
+	ID              int       `json:"id"`
+	OrderID         int       `json:"order_id"`
+	ReturnID        int       `json:"return_id"`
+	PaymentID       int       `json:"payment_id,omitempty"`
+	Amount          float64   `json:"amount"`
+	Merchandise     float64   `json:"merchandise"`
+	Tax             float64   `json:"tax"`
+	Shipping        float64   `json:"shipping"`
+	GatewayRefundID string    `json:"gateway_refund_id,omitempty"`
+	CreatedAt       time.Time `json:"created_at"`
+}
+
+// SplitRefund returns a refund of amount on the order, charged first to
+// what is left of its merchandise, then of its tax, then of its shipping;
+// refunded holds the totals already paid back. Refunding everything that
+// is left therefore gives back each part exactly.
+func SplitRefund(order Order, refunded Refund, amount float64) Refund {
+	refund := Refund{OrderID: order.ID, Amount: roundCents(amount)}
+	left := refund.Amount
+
+	take := func(part float64, alreadyRefunded float64) float64 {
+		taken := math.Max(0, math.Min(left, roundCents(part-alreadyRefunded)))
+		left = roundCents(left - taken)
+		return taken
+	}
+	refund.Merchandise = take(order.Subtotal-order.Discount, refunded.Merchandise)
+	refund.Tax = take(order.Tax, refunded.Tax)
+	refund.Shipping = take(order.Shipping, refunded.Shipping)
+
+	// orders from before tax and shipping were kept have no breakdown
+	refund.Merchandise = roundCents(refund.Merchandise + left)
+	return refund
+}
+
+// Return lifecycle: requested -> approved | rejected
+const (
+	ReturnStatusRequested = "requested"
+	ReturnStatusApproved  = "approved"
+	ReturnStatusRejected  = "rejected"
+)
+
+func NormalizeReturnStatus(status string) string {
+	return strings.ToLower(strings.TrimSpace(status))
+}
+
+// ItemsValue is what the returned copies cost before any order discount
+func (r OrderReturn) ItemsValue() float64 {
+	value := 0.0
+	for _, item := range r.Items {
+		value += item.UnitPrice * float64(item.Quantity)
+	}
+	return math.Round(value*100) / 100
+}
+
+// DefaultRefund is the value of the returned items less their share of
+// the order's coupon discount
+func (r OrderReturn) DefaultRefund(order Order) float64 {
+	value := r.ItemsValue()
+	if order.Subtotal > 0 && order.Discount > 0 {
+		value -= value * order.Discount / order.Subtotal
+	}
+	return math.Round(value*100) / 100
+}
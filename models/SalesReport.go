@@ -7,7 +7,13 @@ import (
 
 type SalesReport struct { 
     Timestamp       time.Time    `json:"timestamp"` 
-    TotalRevenue    float64      `json:"total_revenue"` 
+    TotalRevenue    float64      `json:"total_revenue"` // same as NetRevenue
+    GrossRevenue    float64      `json:"gross_revenue"`
+    TotalDiscounts  float64      `json:"total_discounts"`
+    Refunds         float64      `json:"refunds"`
+    NetRevenue      float64      `json:"net_revenue"`
+    TaxCollected    float64      `json:"tax_collected"`
+    ShippingFees    float64      `json:"shipping_fees"`
     TotalOrders     int          `json:"total_orders"` 
     TopSellingBooks []BookSales  `json:"top_selling_books"` 
 }
\ No newline at end of file
@@ -0,0 +1,34 @@
+package models
+
+import (
+	"math"
+)
+
+// ShippingRule prices delivery to a country, optionally narrowed to a
+// state. Country "*" is the fallback for every other destination.
+// The cost is BaseFee plus PerItemFee for every unit and PerKgFee for
+// every started kilogram; orders reaching FreeShippingThreshold ship free.
+type ShippingRule struct {
+	ID                    int     `json:"id"`
+	Country               string  `json:"country"`
+	State                 string  `json:"state,omitempty"`
+	BaseFee               float64 `json:"base_fee"`
+	PerItemFee            float64 `json:"per_item_fee"`
+	PerKgFee              float64 `json:"per_kg_fee"`
+	FreeShippingThreshold float64 `json:"free_shipping_threshold,omitempty"` // 0 means never free
+}
+
+// Cost prices a parcel; amount is the discounted merchandise value
+func (r ShippingRule) Cost(amount float64, items int, weightGrams int) float64 {
+	if r.FreeShippingThreshold > 0 && amount >= r.FreeShippingThreshold {
+		return 0
+	}
+
+	kilograms := math.Ceil(float64(weightGrams) / 1000)
+	return roundCents(r.BaseFee + r.PerItemFee*float64(items) + r.PerKgFee*kilograms)
+}
+
+// money is kept in DECIMAL(10,2) columns
+func roundCents(amount float64) float64 {
+	return math.Round(amount*100) / 100
+}
@@ -0,0 +1,14 @@
+package models
+
+// TaxRule is the sales tax rate for a country, optionally narrowed to a
+// state. Country "*" is the fallback for every other destination.
+type TaxRule struct {
+	ID      int     `json:"id"`
+	Country string  `json:"country"`
+	State   string  `json:"state,omitempty"`
+	Rate    float64 `json:"rate"` // 0.0825 for 8.25%
+}
+
+func (r TaxRule) Tax(taxable float64) float64 {
+	return roundCents(taxable * r.Rate)
+}
@@ -1,8 +1,39 @@
 package models
 
+import (
+	"time"
+)
+
 type User struct {
-	ID       int    `json:"id"`
+	ID         int       `json:"id"`
+	Email      string    `json:"email"`
+	Password   string    `json:"-"`    // never expose
+	Role       string    `json:"role"` // "admin" | "user"
+	Disabled   bool      `json:"disabled"`
+	CustomerID int       `json:"customer_id,omitempty"` // 0 when no customer is linked
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+const (
+	RoleAdmin = "admin"
+	RoleUser  = "user"
+)
+
+type CreateUserRequest struct {
 	Email    string `json:"email"`
-	Password string `json:"-"`   // never expose
-	Role     string `json:"role"` // "admin" | "user"
+	Password string `json:"password"`
+	Role     string `json:"role"`
+}
+
+// Fields left out of the payload are not changed
+type UpdateUserRequest struct {
+	Role       *string `json:"role"`
+	Disabled   *bool   `json:"disabled"`
+	CustomerID *int    `json:"customer_id"` // 0 unlinks
+}
+
+// what GET /me returns
+type Profile struct {
+	User     User      `json:"user"`
+	Customer *Customer `json:"customer,omitempty"`
 }
@@ -0,0 +1,395 @@
+package services
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+
+	"online_bookStore/Interfaces"
+	"online_bookStore/models"
+)
+
+var (
+	ErrInvalidCredentials = errors.New("invalid credentials")
+	ErrInvalidToken       = errors.New("invalid token")
+	ErrUserDisabled       = errors.New("user account is disabled")
+	ErrRefreshTokenReused = errors.New("refresh token reuse detected")
+)
+
+const (
+	defaultTokenTTL   = 15 * time.Minute
+	defaultRefreshTTL = 30 * 24 * time.Hour
+)
+
+// Claims carried by the access tokens we issue.
+// The user id lives in the standard "sub" claim and the token id in "jti".
+type Claims struct {
+	Role string `json:"role"`
+	jwt.RegisteredClaims
+}
+
+type AuthService struct {
+	userStore  interfaces.UserStore
+	secret     []byte
+	tokenTTL   time.Duration
+	refreshTTL time.Duration
+}
+
+// Constructor
+func NewAuthService(userStore interfaces.UserStore, secret []byte, tokenTTL time.Duration, refreshTTL time.Duration) *AuthService {
+	return &AuthService{
+		userStore:  userStore,
+		secret:     secret,
+		tokenTTL:   tokenTTL,
+		refreshTTL: refreshTTL,
+	}
+}
+
+// Reads JWT_SECRET (required), JWT_TTL and JWT_REFRESH_TTL (optional, e.g. "15m", "720h")
+func NewAuthServiceFromEnv(userStore interfaces.UserStore) *AuthService {
+	secret := os.Getenv("JWT_SECRET")
+	if secret == "" {
+		log.Fatal("JWT_SECRET environment variable is not set")
+	}
+
+	return NewAuthService(
+		userStore,
+		[]byte(secret),
+		DurationFromEnv("JWT_TTL", defaultTokenTTL),
+		DurationFromEnv("JWT_REFRESH_TTL", defaultRefreshTTL),
+	)
+}
+
+// DurationFromEnv reads a duration such as "15m" or "72h" from the
+// environment, falling back to the default when the variable is unset
+func DurationFromEnv(name string, fallback time.Duration) time.Duration {
+	raw := os.Getenv(name)
+	if raw == "" {
+		return fallback
+	}
+
+	d, err := time.ParseDuration(raw)
+	if err != nil || d <= 0 {
+		log.Fatalf("invalid %s %q", name, raw)
+	}
+	return d
+}
+
+// Login checks the credentials and starts a new session:
+// a signed access token plus a refresh token opening a new token family
+func (s *AuthService) Login(ctx context.Context, email string, password string) (models.LoginResponse, error) {
+	user, err := s.userStore.GetByEmail(ctx, email)
+	if errors.Is(err, sql.ErrNoRows) {
+		return models.LoginResponse{}, ErrInvalidCredentials
+	}
+	if err != nil {
+		return models.LoginResponse{}, err
+	}
+
+	ok, needsRehash := VerifyPassword(user.Password, password)
+	if !ok {
+		return models.LoginResponse{}, ErrInvalidCredentials
+	}
+
+	if user.Disabled {
+		return models.LoginResponse{}, ErrUserDisabled
+	}
+
+	// upgrade legacy plaintext rows now that we know the password
+	if needsRehash {
+		s.rehashPassword(ctx, user.ID, password)
+	}
+
+	familyID, err := randomToken(16)
+	if err != nil {
+		return models.LoginResponse{}, err
+	}
+
+	refreshToken, refresh, err := s.newRefreshToken(user.ID, familyID)
+	if err != nil {
+		return models.LoginResponse{}, err
+	}
+
+	if _, err := s.userStore.CreateRefreshToken(ctx, refresh); err != nil {
+		return models.LoginResponse{}, err
+	}
+
+	return s.tokenResponse(user, refreshToken, refresh.ExpiresAt)
+}
+
+// Refresh exchanges a refresh token for a new access/refresh token pair.
+// The presented token is revoked; presenting it again revokes its family.
+func (s *AuthService) Refresh(ctx context.Context, refreshToken string) (models.LoginResponse, error) {
+	stored, err := s.userStore.GetRefreshToken(ctx, hashToken(refreshToken))
+	if errors.Is(err, sql.ErrNoRows) {
+		return models.LoginResponse{}, ErrInvalidToken
+	}
+	if err != nil {
+		return models.LoginResponse{}, err
+	}
+
+	if stored.RevokedAt != nil {
+		s.revokeFamily(ctx, stored)
+		return models.LoginResponse{}, ErrRefreshTokenReused
+	}
+
+	if time.Now().After(stored.ExpiresAt) {
+		return models.LoginResponse{}, fmt.Errorf("%w: refresh token expired", ErrInvalidToken)
+	}
+
+	user, err := s.userStore.GetByID(ctx, stored.UserID)
+	if err != nil {
+		return models.LoginResponse{}, err
+	}
+
+	if user.Disabled {
+		s.revokeFamily(ctx, stored)
+		return models.LoginResponse{}, ErrUserDisabled
+	}
+
+	nextToken, next, err := s.newRefreshToken(user.ID, stored.FamilyID)
+	if err != nil {
+		return models.LoginResponse{}, err
+	}
+
+	// a concurrent refresh already rotated this token
+	_, err = s.userStore.RotateRefreshToken(ctx, stored.ID, next)
+	if errors.Is(err, sql.ErrNoRows) {
+		s.revokeFamily(ctx, stored)
+		return models.LoginResponse{}, ErrRefreshTokenReused
+	}
+	if err != nil {
+		return models.LoginResponse{}, err
+	}
+
+	return s.tokenResponse(user, nextToken, next.ExpiresAt)
+}
+
+// Logout revokes the access token and, when given, the refresh token
+// family it belongs to
+func (s *AuthService) Logout(ctx context.Context, accessToken string, refreshToken string) error {
+	claims, err := s.ParseToken(accessToken)
+	if err != nil {
+		return err
+	}
+
+	if err := s.userStore.RevokeAccessToken(ctx, claims.ID, claims.ExpiresAt.Time); err != nil {
+		return err
+	}
+
+	if refreshToken == "" {
+		return nil
+	}
+
+	stored, err := s.userStore.GetRefreshToken(ctx, hashToken(refreshToken))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	// only the owner may end a session
+	if strconv.Itoa(stored.UserID) != claims.Subject {
+		return nil
+	}
+
+	return s.userStore.RevokeRefreshTokenFamily(ctx, stored.FamilyID)
+}
+
+func (s *AuthService) revokeFamily(ctx context.Context, token models.RefreshToken) {
+	log.Printf("REFRESH TOKEN FAMILY REVOKED user=%d family=%s", token.UserID, token.FamilyID)
+
+	if err := s.userStore.RevokeRefreshTokenFamily(ctx, token.FamilyID); err != nil {
+		log.Printf("ERROR revoking refresh token family %s: %v", token.FamilyID, err)
+	}
+}
+
+func (s *AuthService) rehashPassword(ctx context.Context, userID int, password string) {
+	hash, err := HashPassword(password)
+	if err != nil {
+		log.Printf("ERROR hashing legacy password for user %d: %v", userID, err)
+		return
+	}
+
+	if err := s.userStore.UpdatePassword(ctx, userID, hash); err != nil {
+		log.Printf("ERROR storing rehashed password for user %d: %v", userID, err)
+		return
+	}
+
+	log.Printf("PASSWORD REHASHED user=%d", userID)
+}
+
+func (s *AuthService) tokenResponse(user models.User, refreshToken string, refreshExpiresAt time.Time) (models.LoginResponse, error) {
+	token, expiresAt, err := s.IssueToken(user)
+	if err != nil {
+		return models.LoginResponse{}, err
+	}
+
+	return models.LoginResponse{
+		Token:            token,
+		TokenType:        "Bearer",
+		ExpiresAt:        expiresAt,
+		RefreshToken:     refreshToken,
+		RefreshExpiresAt: refreshExpiresAt,
+	}, nil
+}
+
+// newRefreshToken returns the opaque token handed to the client and
+// the hashed record to persist
+func (s *AuthService) newRefreshToken(userID int, familyID string) (string, models.RefreshToken, error) {
+	token, err := randomToken(32)
+	if err != nil {
+		return "", models.RefreshToken{}, err
+	}
+
+	return token, models.RefreshToken{
+		UserID:    userID,
+		TokenHash: hashToken(token),
+		FamilyID:  familyID,
+		ExpiresAt: time.Now().Add(s.refreshTTL),
+	}, nil
+}
+
+// IssueToken signs an HS256 access token for the given user
+func (s *AuthService) IssueToken(user models.User) (string, time.Time, error) {
+	jti, err := randomToken(16)
+	if err != nil {
+		return "", time.Time{}, err
+	}
+
+	now := time.Now()
+	expiresAt := now.Add(s.tokenTTL)
+
+	claims := Claims{
+		Role: user.Role,
+		RegisteredClaims: jwt.RegisteredClaims{
+			ID:        jti,
+			Subject:   strconv.Itoa(user.ID),
+			IssuedAt:  jwt.NewNumericDate(now),
+			ExpiresAt: jwt.NewNumericDate(expiresAt),
+		},
+	}
+
+	token, err := jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString(s.secret)
+	if err != nil {
+		return "", time.Time{}, err
+	}
+
+	return token, expiresAt, nil
+}
+
+// ParseToken verifies the signature and expiry of an access token
+func (s *AuthService) ParseToken(tokenString string) (Claims, error) {
+	var claims Claims
+
+	_, err := jwt.ParseWithClaims(
+		tokenString,
+		&claims,
+		func(token *jwt.Token) (interface{}, error) {
+			return s.secret, nil
+		},
+		jwt.WithValidMethods([]string{jwt.SigningMethodHS256.Alg()}),
+		jwt.WithExpirationRequired(),
+	)
+	if err != nil {
+		return Claims{}, fmt.Errorf("%w: %v", ErrInvalidToken, err)
+	}
+
+	if claims.ID == "" {
+		return Claims{}, fmt.Errorf("%w: missing jti", ErrInvalidToken)
+	}
+
+	return claims, nil
+}
+
+// Authenticate resolves a bearer token to the user it was issued for
+func (s *AuthService) Authenticate(ctx context.Context, tokenString string) (models.User, error) {
+	claims, err := s.ParseToken(tokenString)
+	if err != nil {
+		return models.User{}, err
+	}
+
+	revoked, err := s.userStore.IsAccessTokenRevoked(ctx, claims.ID)
+	if err != nil {
+		return models.User{}, err
+	}
+	if revoked {
+		return models.User{}, fmt.Errorf("%w: token %s was revoked", ErrInvalidToken, claims.ID)
+	}
+
+	userID, err := strconv.Atoi(claims.Subject)
+	if err != nil {
+		return models.User{}, fmt.Errorf("%w: bad subject %q", ErrInvalidToken, claims.Subject)
+	}
+
+	user, err := s.userStore.GetByID(ctx, userID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return models.User{}, fmt.Errorf("%w: unknown user %d", ErrInvalidToken, userID)
+	}
+	if err != nil {
+		return models.User{}, err
+	}
+
+	if user.Disabled {
+		return models.User{}, fmt.Errorf("%w: user %d is disabled", ErrInvalidToken, userID)
+	}
+
+	return user, nil
+}
+
+// Removes refresh tokens and revocation entries past their expiry
+func (s *AuthService) PurgeExpiredTokens(ctx context.Context) error {
+	return s.userStore.DeleteExpiredTokens(ctx, time.Now())
+}
+
+// randomToken returns n random bytes, URL-safe base64 encoded
+func randomToken(n int) (string, error) {
+	buf := make([]byte, n)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(buf), nil
+}
+
+func hashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// purge expired tokens every hour
+
+func StartTokenCleanupJob(
+	ctx context.Context,
+	authService *AuthService,
+) {
+	ticker := time.NewTicker(time.Hour)
+
+	go func() {
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ticker.C:
+				if err := authService.PurgeExpiredTokens(ctx); err != nil {
+					log.Printf("Failed to purge expired tokens: %v", err)
+				}
+
+			case <-ctx.Done():
+				log.Println("Stopping token cleanup job")
+				return
+			}
+		}
+	}()
+}
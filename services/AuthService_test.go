@@ -0,0 +1,53 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"online_bookStore/InMemory"
+)
+
+func TestRefreshReuseDetection(t *testing.T) {
+	tests := []struct {
+		name    string
+		replay  bool // the first refresh token is presented again after rotating
+		wantErr error
+	}{
+		{name: "rotated token refreshes"},
+		{name: "replayed token revokes the family", replay: true, wantErr: ErrRefreshTokenReused},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			db := inmemory.NewDB()
+			if err := inmemory.Seed(ctx, db); err != nil {
+				t.Fatalf("seeding: %v", err)
+			}
+			auth := NewAuthService(inmemory.NewMemoryUserStore(db), []byte("test-secret"), time.Minute, time.Hour)
+
+			login, err := auth.Login(ctx, "admin@example.com", "Admin1234")
+			if err != nil {
+				t.Fatalf("Login: %v", err)
+			}
+			rotated, err := auth.Refresh(ctx, login.RefreshToken)
+			if err != nil {
+				t.Fatalf("first Refresh: %v", err)
+			}
+
+			if tt.replay {
+				if _, err := auth.Refresh(ctx, login.RefreshToken); !errors.Is(err, ErrRefreshTokenReused) {
+					t.Fatalf("replayed Refresh error = %v, want %v", err, ErrRefreshTokenReused)
+				}
+			}
+
+			// after a replay even the latest token of the family is refused
+			_, err = auth.Refresh(ctx, rotated.RefreshToken)
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("Refresh with the rotated token error = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,129 @@
+package services
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"online_bookStore/Interfaces"
+	"online_bookStore/models"
+)
+
+const DefaultCartTTL = 72 * time.Hour
+
+type CartService struct {
+	cartStore  interfaces.CartStore
+	orderStore interfaces.OrderStore
+	transactor interfaces.Transactor
+}
+
+// Constructor
+func NewCartService(
+	cartStore interfaces.CartStore,
+	orderStore interfaces.OrderStore,
+	transactor interfaces.Transactor,
+) *CartService {
+	return &CartService{
+		cartStore:  cartStore,
+		orderStore: orderStore,
+		transactor: transactor,
+	}
+}
+
+func (s *CartService) GetCart(ctx context.Context, customerID int) (models.Cart, error) {
+	return s.cartStore.GetCart(ctx, customerID)
+}
+
+func (s *CartService) AddItem(ctx context.Context, customerID int, bookID int, quantity int) (models.Cart, error) {
+	return s.cartStore.AddItem(ctx, customerID, bookID, quantity)
+}
+
+func (s *CartService) SetItemQuantity(ctx context.Context, customerID int, bookID int, quantity int) (models.Cart, error) {
+	return s.cartStore.SetItemQuantity(ctx, customerID, bookID, quantity)
+}
+
+func (s *CartService) RemoveItem(ctx context.Context, customerID int, bookID int) (models.Cart, error) {
+	return s.cartStore.RemoveItem(ctx, customerID, bookID)
+}
+
+func (s *CartService) ClearCart(ctx context.Context, customerID int) error {
+	return s.cartStore.ClearCart(ctx, customerID)
+}
+
+// Checkout turns the cart into an order and empties the cart in one
+// transaction: if the order cannot be created (stock, pricing, coupon)
+// the cart is left as it was.
+func (s *CartService) Checkout(ctx context.Context, customerID int, req models.CheckoutRequest) (models.Order, error) {
+	var order models.Order
+
+	err := s.transactor.WithinTransaction(ctx, func(txCtx context.Context) error {
+		cart, err := s.cartStore.GetCart(txCtx, customerID)
+		if err != nil {
+			return err
+		}
+
+		if len(cart.Items) == 0 {
+			return models.ErrEmptyCart
+		}
+
+		newOrder := models.Order{
+			Customer:   models.Customer{ID: customerID},
+			Status:     models.OrderStatusPending,
+			CouponCode: req.CouponCode,
+
+			ShippingAddress:   req.ShippingAddress,
+			BillingAddress:    req.BillingAddress,
+			ShippingAddressID: req.ShippingAddressID,
+			BillingAddressID:  req.BillingAddressID,
+		}
+		for _, item := range cart.Items {
+			newOrder.Items = append(newOrder.Items, models.OrderItem{
+				Book:     models.Book{ID: item.Book.ID},
+				Quantity: item.Quantity,
+			})
+		}
+
+		order, err = s.orderStore.CreateOrder(txCtx, newOrder)
+		if err != nil {
+			return err
+		}
+
+		return s.cartStore.ClearCart(txCtx, customerID)
+	})
+	if err != nil {
+		return models.Order{}, err
+	}
+
+	return order, nil
+}
+
+// purge expired carts every hour
+
+func StartCartCleanupJob(
+	ctx context.Context,
+	cartStore interfaces.CartStore,
+) {
+	ticker := time.NewTicker(time.Hour)
+
+	go func() {
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ticker.C:
+				deleted, err := cartStore.DeleteExpiredCarts(ctx, time.Now())
+				if err != nil {
+					log.Printf("Failed to purge expired carts: %v", err)
+					continue
+				}
+				if deleted > 0 {
+					log.Printf("Expired carts purged: %d", deleted)
+				}
+
+			case <-ctx.Done():
+				log.Println("Stopping cart cleanup job")
+				return
+			}
+		}
+	}()
+}
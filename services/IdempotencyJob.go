@@ -0,0 +1,40 @@
+package services
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"online_bookStore/Interfaces"
+)
+
+const DefaultIdempotencyTTL = 24 * time.Hour
+
+func StartIdempotencyCleanupJob(
+	ctx context.Context,
+	store interfaces.IdempotencyStore,
+) {
+	ticker := time.NewTicker(time.Hour)
+
+	go func() {
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ticker.C:
+				deleted, err := store.DeleteExpiredKeys(ctx, time.Now())
+				if err != nil {
+					log.Printf("Failed to purge expired idempotency keys: %v", err)
+					continue
+				}
+				if deleted > 0 {
+					log.Printf("Expired idempotency keys purged: %d", deleted)
+				}
+
+			case <-ctx.Done():
+				log.Println("Stopping idempotency cleanup job")
+				return
+			}
+		}
+	}()
+}
@@ -0,0 +1,286 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"math"
+	"time"
+
+	"online_bookStore/Interfaces"
+	"online_bookStore/models"
+)
+
+// settleTimeout bounds the steps that follow a capture or a refund, which
+// run past the request's own deadline
+const settleTimeout = 10 * time.Second
+
+type PaymentService struct {
+	gateway      interfaces.PaymentGateway
+	paymentStore interfaces.PaymentStore
+	orderStore   interfaces.OrderStore
+	returnStore  interfaces.ReturnStore
+	transactor   interfaces.Transactor
+}
+
+// Constructor
+func NewPaymentService(
+	gateway interfaces.PaymentGateway,
+	paymentStore interfaces.PaymentStore,
+	orderStore interfaces.OrderStore,
+	returnStore interfaces.ReturnStore,
+	transactor interfaces.Transactor,
+) *PaymentService {
+	return &PaymentService{
+		gateway:      gateway,
+		paymentStore: paymentStore,
+		orderStore:   orderStore,
+		returnStore:  returnStore,
+		transactor:   transactor,
+	}
+}
+
+func (s *PaymentService) GetPaymentsByOrder(ctx context.Context, orderID int) ([]models.Payment, error) {
+	return s.paymentStore.GetPaymentsByOrder(ctx, orderID)
+}
+
+// PayOrder charges the total of a pending order: the amount is authorized
+// and captured, then the payment and the move to "paid" are stored together.
+// When the gateway refuses, the failed payment is returned with the
+// *models.GatewayError and the order stays pending. Every attempt is kept.
+func (s *PaymentService) PayOrder(ctx context.Context, orderID int, req models.PaymentRequest, actorID int) (models.Payment, error) {
+	if req.Token == "" {
+		return models.Payment{}, models.ErrMissingToken
+	}
+
+	order, err := s.orderStore.GetOrder(ctx, orderID)
+	if err != nil {
+		return models.Payment{}, err
+	}
+	if models.NormalizeOrderStatus(order.Status) != models.OrderStatusPending {
+		return models.Payment{}, fmt.Errorf("%w: order is %s", models.ErrOrderNotPayable, order.Status)
+	}
+
+	payment, err := s.paymentStore.CreatePayment(ctx, models.Payment{
+		OrderID: order.ID,
+		Amount:  order.TotalPrice,
+		Status:  models.PaymentStatusPending,
+		Gateway: s.gateway.Name(),
+	})
+	if err != nil {
+		return payment, err
+	}
+
+	authorizationID, err := s.gateway.Authorize(ctx, models.GatewayCharge{
+		Amount:    order.TotalPrice,
+		Token:     req.Token,
+		Reference: fmt.Sprintf("order-%d", order.ID),
+	})
+	if err != nil {
+		return s.fail(ctx, payment, models.PaymentStatusFailed, err)
+	}
+
+	payment.AuthorizationID = authorizationID
+	payment.Status = models.PaymentStatusAuthorized
+	if payment, err = s.paymentStore.UpdatePayment(ctx, payment); err != nil {
+		s.voidQuietly(ctx, payment)
+		return payment, err
+	}
+
+	// once the capture is asked for, the payment is seen through even if
+	// the request gives up: a capture whose outcome is not stored, or an
+	// order left pending with the money taken, has to be settled by hand
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), settleTimeout)
+	defer cancel()
+
+	captureID, err := s.gateway.Capture(ctx, authorizationID, order.TotalPrice)
+	if err != nil {
+		s.voidQuietly(ctx, payment)
+		return s.fail(ctx, payment, models.PaymentStatusFailed, err)
+	}
+
+	payment.CaptureID = captureID
+	payment.Status = models.PaymentStatusCaptured
+
+	var captured models.Payment
+	err = s.transactor.WithinTransaction(ctx, func(txCtx context.Context) error {
+		var err error
+		captured, err = s.paymentStore.UpdatePayment(txCtx, payment)
+		if err != nil {
+			return err
+		}
+
+		_, err = s.orderStore.UpdateOrderStatus(txCtx, order.ID, models.OrderStatusPaid, actorID)
+		return err
+	})
+	if err != nil {
+		// the money was taken but the order could not be marked paid
+		// (e.g. it was cancelled or paid meanwhile): give it back
+		return s.refundCapture(ctx, payment, err)
+	}
+
+	return captured, nil
+}
+
+// UpdateOrderStatus moves an order to status. Cancelling or refunding an
+// order gives back what is left of its captured payment through the
+// gateway, in the same transaction, so the status only changes once the
+// money is returned. A refunded order also gets a refund record, netted
+// out of the sales report; a cancelled one leaves the report altogether.
+func (s *PaymentService) UpdateOrderStatus(ctx context.Context, orderID int, status string, actorID int) (models.Order, error) {
+	status = models.NormalizeOrderStatus(status)
+
+	var updated models.Order
+	var payment models.Payment
+	var paidOut *models.Refund
+
+	err := s.transactor.WithinTransaction(ctx, func(txCtx context.Context) error {
+		var err error
+		updated, err = s.orderStore.UpdateOrderStatus(txCtx, orderID, status, actorID)
+		if err != nil {
+			return err
+		}
+		if status != models.OrderStatusCancelled && status != models.OrderStatusRefunded {
+			return nil
+		}
+
+		refunded, err := s.returnStore.GetRefundedTotals(txCtx, orderID)
+		if err != nil {
+			return err
+		}
+		amount := math.Round((updated.TotalPrice-refunded.Amount)*100) / 100
+		if amount <= 0 {
+			return nil
+		}
+
+		refund := models.SplitRefund(updated, refunded, amount)
+		payment, refund.GatewayRefundID, err = s.RefundPayment(txCtx, orderID, amount, true)
+		refund.PaymentID = payment.ID
+		if refund.GatewayRefundID != "" {
+			paidOut = &refund
+		}
+		if err != nil || status != models.OrderStatusRefunded {
+			return err
+		}
+
+		// like returns, orders paid outside the gateway are recorded as
+		// refunded outside it
+		_, err = s.returnStore.CreateRefund(txCtx, refund)
+		return err
+	})
+	if err != nil {
+		if paidOut != nil {
+			s.recordStatusRefund(ctx, payment, *paidOut, status, err)
+		}
+		return models.Order{}, err
+	}
+
+	if paidOut != nil {
+		// significant business event
+		log.Printf("PAYMENT REFUNDED order=%d payment=%d gateway_refund=%s amount=%.2f status=%s",
+			orderID, payment.ID, paidOut.GatewayRefundID, paidOut.Amount, status)
+	}
+
+	return updated, nil
+}
+
+// recordStatusRefund stores a refund the gateway made when the status
+// change around it failed afterwards, on a context of its own as the
+// request's may be what failed. The payment is marked refunded, so trying
+// the status change again does not refund it twice.
+func (s *PaymentService) recordStatusRefund(ctx context.Context, payment models.Payment, refund models.Refund, status string, cause error) {
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), settleTimeout)
+	defer cancel()
+
+	payment.RefundID = refund.GatewayRefundID
+	payment.Status = models.PaymentStatusRefunded
+	if _, err := s.paymentStore.UpdatePayment(ctx, payment); err != nil {
+		log.Printf("ERROR gateway refund %s of %.2f on order %d is not recorded: %v (status change to %s failed: %v)",
+			refund.GatewayRefundID, refund.Amount, refund.OrderID, err, status, cause)
+		return
+	}
+	if status == models.OrderStatusRefunded {
+		if _, err := s.returnStore.CreateRefund(ctx, refund); err != nil {
+			log.Printf("ERROR refund record of gateway refund %s on order %d: %v", refund.GatewayRefundID, refund.OrderID, err)
+		}
+	}
+
+	log.Printf("PAYMENT REFUNDED WITHOUT STATUS CHANGE order=%d payment=%d gateway_refund=%s amount=%.2f: change to %s failed: %v",
+		refund.OrderID, payment.ID, refund.GatewayRefundID, refund.Amount, status, cause)
+}
+
+// RefundPayment gives amount back on the order's captured payment and
+// returns that payment with the gateway's refund reference. An order
+// without a captured payment (e.g. paid before payments were recorded)
+// returns an empty Payment: the refund is made outside the gateway. When
+// final is set the payment is marked refunded. It joins the transaction
+// carried by ctx.
+func (s *PaymentService) RefundPayment(ctx context.Context, orderID int, amount float64, final bool) (models.Payment, string, error) {
+	payments, err := s.paymentStore.GetPaymentsByOrder(ctx, orderID)
+	if err != nil {
+		return models.Payment{}, "", err
+	}
+
+	var payment models.Payment
+	for _, p := range payments {
+		if p.Status == models.PaymentStatusCaptured {
+			payment = p
+		}
+	}
+	if payment.ID == 0 {
+		return payment, "", nil
+	}
+
+	refundID, err := s.gateway.Refund(ctx, payment.CaptureID, amount)
+	if err != nil {
+		return payment, "", err
+	}
+
+	payment.RefundID = refundID
+	if final {
+		payment.Status = models.PaymentStatusRefunded
+	}
+
+	payment, err = s.paymentStore.UpdatePayment(ctx, payment)
+	if err != nil {
+		log.Printf("ERROR recording refund %s of payment %d: %v", refundID, payment.ID, err)
+		return payment, refundID, err
+	}
+	return payment, refundID, nil
+}
+
+// fail records why a payment did not go through and returns cause
+func (s *PaymentService) fail(ctx context.Context, payment models.Payment, status string, cause error) (models.Payment, error) {
+	payment.Status = status
+	payment.GatewayError = cause.Error()
+
+	var gatewayErr *models.GatewayError
+	if errors.As(cause, &gatewayErr) {
+		payment.GatewayError = gatewayErr.Code + ": " + gatewayErr.Message
+	}
+
+	updated, err := s.paymentStore.UpdatePayment(ctx, payment)
+	if err != nil {
+		log.Printf("ERROR recording failed payment %d: %v", payment.ID, err)
+		return payment, cause
+	}
+	return updated, cause
+}
+
+func (s *PaymentService) voidQuietly(ctx context.Context, payment models.Payment) {
+	if err := s.gateway.Void(ctx, payment.AuthorizationID); err != nil {
+		log.Printf("ERROR voiding authorization %s of payment %d: %v", payment.AuthorizationID, payment.ID, err)
+	}
+}
+
+func (s *PaymentService) refundCapture(ctx context.Context, payment models.Payment, cause error) (models.Payment, error) {
+	refundID, err := s.gateway.Refund(ctx, payment.CaptureID, payment.Amount)
+	if err != nil {
+		log.Printf("ERROR refunding capture %s of payment %d: %v", payment.CaptureID, payment.ID, err)
+		return s.fail(ctx, payment, models.PaymentStatusCaptured, cause)
+	}
+
+	payment.RefundID = refundID
+	return s.fail(ctx, payment, models.PaymentStatusRefunded, cause)
+}
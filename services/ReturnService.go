@@ -0,0 +1,146 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"math"
+	"time"
+
+	"online_bookStore/Interfaces"
+	"online_bookStore/models"
+)
+
+type ReturnService struct {
+	returnStore    interfaces.ReturnStore
+	orderStore     interfaces.OrderStore
+	paymentService *PaymentService
+	transactor     interfaces.Transactor
+}
+
+// Constructor
+func NewReturnService(
+	returnStore interfaces.ReturnStore,
+	orderStore interfaces.OrderStore,
+	paymentService *PaymentService,
+	transactor interfaces.Transactor,
+) *ReturnService {
+	return &ReturnService{
+		returnStore:    returnStore,
+		orderStore:     orderStore,
+		paymentService: paymentService,
+		transactor:     transactor,
+	}
+}
+
+func (s *ReturnService) RequestReturn(ctx context.Context, ret models.OrderReturn) (models.OrderReturn, error) {
+	return s.returnStore.CreateReturn(ctx, ret)
+}
+
+func (s *ReturnService) GetReturn(ctx context.Context, id int) (models.OrderReturn, error) {
+	return s.returnStore.GetReturn(ctx, id)
+}
+
+func (s *ReturnService) GetReturns(ctx context.Context, customerID int, status string) ([]models.OrderReturn, error) {
+	return s.returnStore.GetReturns(ctx, customerID, status)
+}
+
+// ReviewReturn approves or rejects a requested return in one transaction.
+// Approving restocks the items and refunds review.RefundAmount, or the
+// discounted value of the items when it is not given, capped at what is
+// left to refund on the order. Once the whole order total has been given
+// back the order moves to "refunded". A gateway refusal undoes everything;
+// a failure after the gateway paid out still rolls the review back, but the
+// refund itself is recorded (see recordGatewayRefund).
+func (s *ReturnService) ReviewReturn(ctx context.Context, id int, review models.ReturnReview, reviewedBy int) (models.OrderReturn, error) {
+	if review.RefundAmount != nil && *review.RefundAmount < 0 {
+		return models.OrderReturn{}, models.ErrInvalidRefund
+	}
+
+	// set once the gateway has paid money back, which no rollback undoes
+	var paidOut *models.Refund
+
+	err := s.transactor.WithinTransaction(ctx, func(txCtx context.Context) error {
+		ret, err := s.returnStore.ReviewReturn(txCtx, id, review.Status, reviewedBy, review.Note)
+		if err != nil {
+			return err
+		}
+		if ret.Status != models.ReturnStatusApproved {
+			return nil
+		}
+
+		order, err := s.orderStore.GetOrder(txCtx, ret.OrderID)
+		if err != nil {
+			return err
+		}
+
+		refunded, err := s.returnStore.GetRefundedTotals(txCtx, order.ID)
+		if err != nil {
+			return err
+		}
+		remaining := math.Round((order.TotalPrice-refunded.Amount)*100) / 100
+
+		amount := math.Min(ret.DefaultRefund(order), remaining)
+		if review.RefundAmount != nil {
+			amount = math.Round(*review.RefundAmount*100) / 100
+			if amount > remaining {
+				return fmt.Errorf("%w: %.2f left on order %d", models.ErrRefundTooLarge, remaining, order.ID)
+			}
+		}
+		if amount <= 0 {
+			return nil
+		}
+
+		final := amount >= remaining
+		if final {
+			_, err := s.orderStore.UpdateOrderStatus(txCtx, order.ID, models.OrderStatusRefunded, reviewedBy)
+			if err != nil {
+				return err
+			}
+		}
+
+		payment, gatewayRefundID, err := s.paymentService.RefundPayment(txCtx, order.ID, amount, final)
+		refund := models.SplitRefund(order, refunded, amount)
+		refund.ReturnID = ret.ID
+		refund.PaymentID = payment.ID
+		refund.GatewayRefundID = gatewayRefundID
+		if gatewayRefundID != "" {
+			paidOut = &refund
+		}
+		if err != nil {
+			return err
+		}
+
+		_, err = s.returnStore.CreateRefund(txCtx, refund)
+		return err
+	})
+	if err != nil {
+		if paidOut != nil {
+			s.recordGatewayRefund(ctx, *paidOut, err)
+		}
+		return models.OrderReturn{}, err
+	}
+
+	return s.returnStore.GetReturn(ctx, id)
+}
+
+// recordGatewayRefund stores a refund the gateway made when the review
+// transaction around it failed afterwards. It is stored on its own, outside
+// the rollback and on a context of its own, as the request's may be what
+// failed. The return is still requested, so the refund is not linked to
+// it; reviewing the return again only refunds what is left on the order.
+func (s *ReturnService) recordGatewayRefund(ctx context.Context, refund models.Refund, cause error) {
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), 5*time.Second)
+	defer cancel()
+
+	returnID := refund.ReturnID
+	refund.ReturnID = 0
+	if _, err := s.returnStore.CreateRefund(ctx, refund); err != nil {
+		log.Printf("ERROR gateway refund %s of %.2f on order %d (return %d) is not recorded: %v (review failed: %v)",
+			refund.GatewayRefundID, refund.Amount, refund.OrderID, returnID, err, cause)
+		return
+	}
+
+	log.Printf("REFUND RECORDED WITHOUT RETURN order=%d return=%d gateway_refund=%s amount=%.2f: review failed: %v",
+		refund.OrderID, returnID, refund.GatewayRefundID, refund.Amount, cause)
+}
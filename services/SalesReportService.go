@@ -5,6 +5,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"log"
+	"math"
 	"os"
 	"path/filepath"
 	"sort"
@@ -15,13 +16,15 @@ import (
 )
 
 type SalesReportService struct {
-	orderStore interfaces.OrderStore
+	orderStore  interfaces.OrderStore
+	returnStore interfaces.ReturnStore
 }
 
 // Constructor
-func NewSalesReportService(orderStore interfaces.OrderStore) *SalesReportService {
+func NewSalesReportService(orderStore interfaces.OrderStore, returnStore interfaces.ReturnStore) *SalesReportService {
 	return &SalesReportService{
-		orderStore: orderStore,
+		orderStore:  orderStore,
+		returnStore: returnStore,
 	}
 }
 
@@ -63,19 +66,49 @@ func (s *SalesReportService) GenerateSalesReport(
 		return models.SalesReport{}, err
 	}
 
-	totalRevenue := 0.0
-	totalOrders := len(orders)
+	refunds, err := s.returnStore.GetRefundsByDateRange(ctx, from, to)
+	if err != nil {
+		return models.SalesReport{}, err
+	}
+
+	grossRevenue := 0.0
+	totalDiscounts := 0.0
+	netRevenue := 0.0
+	taxCollected := 0.0
+	shippingFees := 0.0
+	totalRefunds := 0.0
+	totalOrders := 0
 
 	bookCounter := make(map[int]int) // bookID → quantity sold
 
 	for _, order := range orders {
-		totalRevenue += order.TotalPrice
+		// pending and cancelled orders were never paid for
+		if !models.CountsAsSale(order.Status) {
+			continue
+		}
+		totalOrders++
+
+		// gross is before coupons, net after; tax and shipping are not revenue
+		grossRevenue += order.Subtotal
+		totalDiscounts += order.Discount
+		netRevenue += order.Subtotal - order.Discount
+		taxCollected += order.Tax
+		shippingFees += order.Shipping
 
 		for _, item := range order.Items {
 			bookCounter[item.Book.ID] += item.Quantity
 		}
 	}
 
+	// refunds count against the period they were paid in, each part
+	// against its own figure
+	for _, refund := range refunds {
+		totalRefunds += refund.Amount
+		netRevenue -= refund.Merchandise
+		taxCollected -= refund.Tax
+		shippingFees -= refund.Shipping
+	}
+
 	var sales []models.BookSales
 	for bookID, qty := range bookCounter {
 		sales = append(sales, models.BookSales{
@@ -97,7 +130,13 @@ func (s *SalesReportService) GenerateSalesReport(
 
 	return models.SalesReport{
 		Timestamp:       time.Now(),
-		TotalRevenue:    totalRevenue,
+		TotalRevenue:    math.Round(netRevenue*100) / 100,
+		GrossRevenue:    math.Round(grossRevenue*100) / 100,
+		TotalDiscounts:  math.Round(totalDiscounts*100) / 100,
+		Refunds:         math.Round(totalRefunds*100) / 100,
+		NetRevenue:      math.Round(netRevenue*100) / 100,
+		TaxCollected:    math.Round(taxCollected*100) / 100,
+		ShippingFees:    math.Round(shippingFees*100) / 100,
 		TotalOrders:     totalOrders,
 		TopSellingBooks: topSellingBooks,
 	}, nil
@@ -137,9 +176,12 @@ if err := SaveReportToJson(report); err != nil {
 }
 
 log.Printf(
-	"Sales report SAVED: orders=%d revenue=%.2f",
+	"Sales report SAVED: orders=%d gross=%.2f discounts=%.2f refunds=%.2f net=%.2f",
 	report.TotalOrders,
-	report.TotalRevenue,
+	report.GrossRevenue,
+	report.TotalDiscounts,
+	report.Refunds,
+	report.NetRevenue,
 )
 
 
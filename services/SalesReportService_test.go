@@ -0,0 +1,122 @@
+package services
+
+import (
+	"context"
+	"math"
+	"testing"
+	"time"
+
+	"online_bookStore/ConcreteImplemetations"
+	"online_bookStore/InMemory"
+	"online_bookStore/models"
+)
+
+func TestSalesReportRefunds(t *testing.T) {
+	tests := []struct {
+		name     string
+		statuses []string // moves made after the order is paid
+		returned bool     // one of the two copies is returned once delivered
+		counted  bool     // the order counts as a sale
+		refunded bool     // everything paid has been given back
+	}{
+		{name: "paid", counted: true},
+		{name: "cancelled after payment", statuses: []string{"cancelled"}},
+		{name: "refunded after payment", statuses: []string{"refunded"}, counted: true, refunded: true},
+		{name: "refunded after delivery", statuses: []string{"shipped", "delivered", "refunded"}, counted: true, refunded: true},
+		{name: "returned in part, then refunded", statuses: []string{"shipped", "delivered"}, returned: true, counted: true, refunded: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			db := inmemory.NewDB()
+			if err := inmemory.Seed(ctx, db); err != nil {
+				t.Fatalf("seeding: %v", err)
+			}
+
+			orderStore := inmemory.NewMemoryOrderStore(db, inmemory.NewMemoryTaxCalculator(db), inmemory.NewMemoryShippingCalculator(db))
+			returnStore := inmemory.NewMemoryReturnStore(db)
+			transactor := inmemory.NewMemoryTransactor(db)
+			payments := NewPaymentService(concreteimplemetations.NewFakePaymentGateway(), inmemory.NewMemoryPaymentStore(db), orderStore, returnStore, transactor)
+			returns := NewReturnService(returnStore, orderStore, payments, transactor)
+			reports := NewSalesReportService(orderStore, returnStore)
+
+			// the seeded orders fall in the same range
+			from, to := time.Now().Add(-time.Hour), time.Now().Add(time.Hour)
+			before, err := reports.GenerateSalesReport(ctx, from, to)
+			if err != nil {
+				t.Fatalf("GenerateSalesReport: %v", err)
+			}
+
+			order, err := orderStore.CreateOrder(ctx, models.Order{
+				Customer: models.Customer{ID: 1},
+				Items:    []models.OrderItem{{Book: models.Book{ID: 1}, Quantity: 2}},
+			})
+			if err != nil {
+				t.Fatalf("CreateOrder: %v", err)
+			}
+			if order.Tax == 0 || order.Shipping == 0 {
+				t.Fatalf("order has tax %.2f and shipping %.2f, want both charged", order.Tax, order.Shipping)
+			}
+			if _, err := payments.PayOrder(ctx, order.ID, models.PaymentRequest{Token: "tok_ok"}, 1); err != nil {
+				t.Fatalf("PayOrder: %v", err)
+			}
+			for _, status := range tt.statuses {
+				if _, err := payments.UpdateOrderStatus(ctx, order.ID, status, 1); err != nil {
+					t.Fatalf("UpdateOrderStatus(%s): %v", status, err)
+				}
+			}
+			if tt.returned {
+				ret, err := returnStore.CreateReturn(ctx, models.OrderReturn{
+					OrderID: order.ID,
+					Reason:  "damaged",
+					Items:   []models.ReturnItem{{OrderItemID: order.Items[0].ID, Quantity: 1}},
+				})
+				if err != nil {
+					t.Fatalf("CreateReturn: %v", err)
+				}
+				if _, err := returns.ReviewReturn(ctx, ret.ID, models.ReturnReview{Status: models.ReturnStatusApproved}, 1); err != nil {
+					t.Fatalf("ReviewReturn: %v", err)
+				}
+				if _, err := payments.UpdateOrderStatus(ctx, order.ID, models.OrderStatusRefunded, 1); err != nil {
+					t.Fatalf("UpdateOrderStatus(refunded): %v", err)
+				}
+			}
+
+			report, err := reports.GenerateSalesReport(ctx, from, to)
+			if err != nil {
+				t.Fatalf("GenerateSalesReport: %v", err)
+			}
+
+			var want models.SalesReport
+			if tt.counted {
+				want.TotalOrders = 1
+				want.GrossRevenue = order.Subtotal
+			}
+			if tt.counted && !tt.refunded {
+				want.NetRevenue = order.Subtotal - order.Discount
+				want.TaxCollected = order.Tax
+				want.ShippingFees = order.Shipping
+			}
+			if tt.refunded {
+				want.Refunds = order.TotalPrice
+			}
+
+			for _, figure := range []struct {
+				name      string
+				got, want float64
+			}{
+				{"total_orders", float64(report.TotalOrders - before.TotalOrders), float64(want.TotalOrders)},
+				{"gross_revenue", report.GrossRevenue - before.GrossRevenue, want.GrossRevenue},
+				{"net_revenue", report.NetRevenue - before.NetRevenue, want.NetRevenue},
+				{"tax_collected", report.TaxCollected - before.TaxCollected, want.TaxCollected},
+				{"shipping_fees", report.ShippingFees - before.ShippingFees, want.ShippingFees},
+				{"refunds", report.Refunds - before.Refunds, want.Refunds},
+			} {
+				if math.Abs(figure.got-figure.want) > 0.005 {
+					t.Errorf("the order adds %.2f to %s, want %.2f", figure.got, figure.name, figure.want)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,172 @@
+package services
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+
+	"online_bookStore/Interfaces"
+	"online_bookStore/models"
+)
+
+var (
+	ErrEmailTaken      = errors.New("email already registered")
+	ErrInvalidRole     = errors.New("role must be \"admin\" or \"user\"")
+	ErrInvalidEmail    = errors.New("invalid email address")
+	ErrSelfLockout     = errors.New("admins cannot disable or demote their own account")
+	ErrUnknownCustomer = errors.New("customer does not exist")
+)
+
+type UserService struct {
+	userStore     interfaces.UserStore
+	customerStore interfaces.CustomerStore
+	transactor    interfaces.Transactor
+}
+
+// Constructor
+func NewUserService(userStore interfaces.UserStore, customerStore interfaces.CustomerStore, transactor interfaces.Transactor) *UserService {
+	return &UserService{
+		userStore:     userStore,
+		customerStore: customerStore,
+		transactor:    transactor,
+	}
+}
+
+// Register creates a regular user account. When the request carries a
+// name and address, a customer profile is created and linked as well, in
+// the same transaction: a failed registration leaves nothing behind.
+func (s *UserService) Register(ctx context.Context, req models.RegisterRequest) (models.User, error) {
+	if req.Name == "" || req.Address == nil {
+		return s.CreateUser(ctx, req.Email, req.Password, models.RoleUser)
+	}
+
+	var user models.User
+	err := s.transactor.WithinTransaction(ctx, func(txCtx context.Context) error {
+		var err error
+		user, err = s.CreateUser(txCtx, req.Email, req.Password, models.RoleUser)
+		if err != nil {
+			return err
+		}
+
+		customer, err := s.customerStore.CreateCustomer(txCtx, models.Customer{
+			Name:    req.Name,
+			Email:   user.Email,
+			Address: *req.Address,
+		})
+		if err != nil {
+			return err
+		}
+
+		user, err = s.userStore.LinkCustomer(txCtx, user.ID, customer.ID)
+		return err
+	})
+	if err != nil {
+		return models.User{}, err
+	}
+
+	return user, nil
+}
+
+// CreateUser validates the input and stores the user with a hashed password
+func (s *UserService) CreateUser(ctx context.Context, email string, password string, role string) (models.User, error) {
+	email = strings.TrimSpace(strings.ToLower(email))
+	if !strings.Contains(email, "@") {
+		return models.User{}, ErrInvalidEmail
+	}
+	if !validRole(role) {
+		return models.User{}, ErrInvalidRole
+	}
+	if err := ValidatePassword(password); err != nil {
+		return models.User{}, err
+	}
+
+	_, err := s.userStore.GetByEmail(ctx, email)
+	if err == nil {
+		return models.User{}, ErrEmailTaken
+	}
+	if !errors.Is(err, sql.ErrNoRows) {
+		return models.User{}, err
+	}
+
+	hash, err := HashPassword(password)
+	if err != nil {
+		return models.User{}, err
+	}
+
+	return s.userStore.CreateUser(ctx, models.User{
+		Email:    email,
+		Password: hash,
+		Role:     role,
+	})
+}
+
+func (s *UserService) ListUsers(ctx context.Context) ([]models.User, error) {
+	return s.userStore.GetAllUsers(ctx)
+}
+
+func (s *UserService) GetUser(ctx context.Context, id int) (models.User, error) {
+	return s.userStore.GetByID(ctx, id)
+}
+
+// UpdateUser applies a role change and/or enables/disables the account.
+// actorID is the admin performing the change.
+func (s *UserService) UpdateUser(ctx context.Context, actorID int, id int, req models.UpdateUserRequest) (models.User, error) {
+	if req.Role != nil && !validRole(*req.Role) {
+		return models.User{}, ErrInvalidRole
+	}
+
+	if actorID == id {
+		demoting := req.Role != nil && *req.Role != models.RoleAdmin
+		disabling := req.Disabled != nil && *req.Disabled
+		if demoting || disabling {
+			return models.User{}, ErrSelfLockout
+		}
+	}
+
+	user, err := s.userStore.GetByID(ctx, id)
+	if err != nil {
+		return models.User{}, err
+	}
+
+	if req.Role != nil {
+		user, err = s.userStore.UpdateRole(ctx, id, *req.Role)
+		if err != nil {
+			return models.User{}, err
+		}
+	}
+
+	if req.CustomerID != nil {
+		if *req.CustomerID != 0 {
+			if _, err := s.customerStore.GetCustomer(ctx, *req.CustomerID); err != nil {
+				return models.User{}, fmt.Errorf("%w: customer %d", ErrUnknownCustomer, *req.CustomerID)
+			}
+		}
+
+		user, err = s.userStore.LinkCustomer(ctx, id, *req.CustomerID)
+		if err != nil {
+			return models.User{}, err
+		}
+	}
+
+	if req.Disabled != nil {
+		user, err = s.userStore.SetDisabled(ctx, id, *req.Disabled)
+		if err != nil {
+			return models.User{}, err
+		}
+
+		// end every open session of a disabled account
+		if user.Disabled {
+			if err := s.userStore.RevokeUserRefreshTokens(ctx, id); err != nil {
+				return models.User{}, err
+			}
+		}
+	}
+
+	return user, nil
+}
+
+func validRole(role string) bool {
+	return role == models.RoleAdmin || role == models.RoleUser
+}